@@ -3,6 +3,7 @@ package ast
 import (
 	"bytes"
 	"github.com/butlermatt/monkey/token"
+	"math/big"
 	"strings"
 )
 
@@ -62,6 +63,7 @@ func (bs *BlockStatement) String() string {
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
+	Names []*Identifier // set instead of Name when unpacking, as in let a, b = f();
 	Value Expression
 }
 
@@ -71,7 +73,15 @@ func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Name != nil {
+		out.WriteString(ls.Name.String())
+	}
+	for i, name := range ls.Names {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(name.String())
+	}
 	out.WriteString(" = ")
 	if ls.Value != nil {
 		out.WriteString(ls.Value.String())
@@ -162,14 +172,44 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
-type NumberLiteral struct {
+type IntegerLiteral struct {
+	Token token.Token
+	Value int64
+}
+
+func (il *IntegerLiteral) expressionNode()      {}
+func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) String() string       { return il.Token.Literal }
+
+// BigIntLiteral is an integer literal too large for an int64.
+type BigIntLiteral struct {
+	Token token.Token
+	Value *big.Int
+}
+
+func (bl *BigIntLiteral) expressionNode()      {}
+func (bl *BigIntLiteral) TokenLiteral() string { return bl.Token.Literal }
+func (bl *BigIntLiteral) String() string       { return bl.Token.Literal }
+
+// DecimalLiteral is a number with a `d` suffix, such as 1.10d. Value holds the digits without
+// the suffix.
+type DecimalLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (dl *DecimalLiteral) expressionNode()      {}
+func (dl *DecimalLiteral) TokenLiteral() string { return dl.Token.Literal }
+func (dl *DecimalLiteral) String() string       { return dl.Token.Literal }
+
+type FloatLiteral struct {
 	Token token.Token
 	Value float64
 }
 
-func (nl *NumberLiteral) expressionNode()      {}
-func (nl *NumberLiteral) TokenLiteral() string { return nl.Token.Literal }
-func (nl *NumberLiteral) String() string       { return nl.Token.Literal }
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
 
 type Boolean struct {
 	Token token.Token
@@ -214,6 +254,15 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Value }
 
+type BytesLiteral struct {
+	Token token.Token // the literal's body, with escapes
+	Value []byte
+}
+
+func (bl *BytesLiteral) expressionNode()      {}
+func (bl *BytesLiteral) TokenLiteral() string { return bl.Token.Literal }
+func (bl *BytesLiteral) String() string       { return `b"` + bl.Token.Literal + `"` }
+
 type ArrayLiteral struct {
 	Token    token.Token // the leading '[' token
 	Elements []Expression
@@ -285,9 +334,10 @@ func (ce *CallExpression) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token // The '[' token
-	Left  Expression
-	Index Expression
+	Token    token.Token // The '[' or '?[' token
+	Left     Expression
+	Index    Expression
+	Optional bool
 }
 
 func (ie *IndexExpression) expressionNode()      {}
@@ -297,6 +347,9 @@ func (ie *IndexExpression) String() string {
 
 	out.WriteByte('(')
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteByte('?')
+	}
 	out.WriteByte('[')
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -307,6 +360,7 @@ func (ie *IndexExpression) String() string {
 type HashLiteral struct {
 	Token token.Token // the '{' token
 	Pairs map[Expression]Expression
+	Keys  []Expression // the keys of Pairs in source order
 }
 
 func (hl *HashLiteral) expressionNode()      {}
@@ -315,8 +369,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 	var pairs []string
 
-	for k, v := range hl.Pairs {
-		pairs = append(pairs, k.String()+":"+v.String())
+	for _, k := range hl.Keys {
+		pairs = append(pairs, k.String()+":"+hl.Pairs[k].String())
 	}
 
 	out.WriteByte('{')
@@ -325,3 +379,295 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+type TupleLiteral struct {
+	Token    token.Token // the '(' token, or the ',' of return a, b
+	Elements []Expression
+}
+
+func (tl *TupleLiteral) expressionNode()      {}
+func (tl *TupleLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TupleLiteral) String() string {
+	var out bytes.Buffer
+
+	var els []string
+	for _, el := range tl.Elements {
+		els = append(els, el.String())
+	}
+
+	out.WriteByte('(')
+	out.WriteString(strings.Join(els, ", "))
+	if len(els) == 1 {
+		out.WriteByte(',')
+	}
+	out.WriteByte(')')
+	return out.String()
+}
+
+type SetLiteral struct {
+	Token    token.Token // the '{' token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode()      {}
+func (sl *SetLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SetLiteral) String() string {
+	var out bytes.Buffer
+
+	var els []string
+	for _, el := range sl.Elements {
+		els = append(els, el.String())
+	}
+
+	out.WriteByte('{')
+	out.WriteString(strings.Join(els, ", "))
+	out.WriteByte('}')
+	return out.String()
+}
+
+type RangeExpression struct {
+	Token     token.Token // the '..' or '..=' token
+	Start     Expression
+	End       Expression
+	Step      Expression
+	Inclusive bool
+}
+
+func (re *RangeExpression) expressionNode()      {}
+func (re *RangeExpression) TokenLiteral() string { return re.Token.Literal }
+func (re *RangeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteByte('(')
+	out.WriteString(re.Start.String())
+	out.WriteString(re.Token.Literal)
+	out.WriteString(re.End.String())
+	if re.Step != nil {
+		out.WriteString(" step ")
+		out.WriteString(re.Step.String())
+	}
+	out.WriteByte(')')
+
+	return out.String()
+}
+
+// ComprehensionClause is the `for a, b in iterable if condition` tail shared by comprehensions.
+type ComprehensionClause struct {
+	Token     token.Token // the 'for' token
+	Variables []*Identifier
+	Iterable  Expression
+	Condition Expression
+}
+
+func (cc *ComprehensionClause) String() string {
+	var out bytes.Buffer
+
+	var vars []string
+	for _, v := range cc.Variables {
+		vars = append(vars, v.String())
+	}
+
+	out.WriteString(" for ")
+	out.WriteString(strings.Join(vars, ", "))
+	out.WriteString(" in ")
+	out.WriteString(cc.Iterable.String())
+	if cc.Condition != nil {
+		out.WriteString(" if ")
+		out.WriteString(cc.Condition.String())
+	}
+
+	return out.String()
+}
+
+type ArrayComprehension struct {
+	Token   token.Token // the '[' token
+	Element Expression
+	Clause  *ComprehensionClause
+}
+
+func (ac *ArrayComprehension) expressionNode()      {}
+func (ac *ArrayComprehension) TokenLiteral() string { return ac.Token.Literal }
+func (ac *ArrayComprehension) String() string {
+	var out bytes.Buffer
+
+	out.WriteByte('[')
+	out.WriteString(ac.Element.String())
+	out.WriteString(ac.Clause.String())
+	out.WriteByte(']')
+
+	return out.String()
+}
+
+type HashComprehension struct {
+	Token  token.Token // the '{' token
+	Key    Expression
+	Value  Expression
+	Clause *ComprehensionClause
+}
+
+func (hc *HashComprehension) expressionNode()      {}
+func (hc *HashComprehension) TokenLiteral() string { return hc.Token.Literal }
+func (hc *HashComprehension) String() string {
+	var out bytes.Buffer
+
+	out.WriteByte('{')
+	out.WriteString(hc.Key.String())
+	out.WriteByte(':')
+	out.WriteString(hc.Value.String())
+	out.WriteString(hc.Clause.String())
+	out.WriteByte('}')
+
+	return out.String()
+}
+
+type StructStatement struct {
+	Token  token.Token // the 'struct' token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	var fields []string
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+
+	out.WriteString(ss.TokenLiteral() + " ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+type FieldExpression struct {
+	Token    token.Token // the '.' or '?.' token
+	Left     Expression
+	Field    *Identifier
+	Optional bool
+}
+
+func (fe *FieldExpression) expressionNode()      {}
+func (fe *FieldExpression) TokenLiteral() string { return fe.Token.Literal }
+func (fe *FieldExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteByte('(')
+	out.WriteString(fe.Left.String())
+	if fe.Optional {
+		out.WriteByte('?')
+	}
+	out.WriteByte('.')
+	out.WriteString(fe.Field.String())
+	out.WriteByte(')')
+
+	return out.String()
+}
+
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ev *EnumVariant) String() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name.String()
+	}
+
+	var fields []string
+	for _, f := range ev.Fields {
+		fields = append(fields, f.String())
+	}
+
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+type EnumStatement struct {
+	Token    token.Token // the 'enum' token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+func (es *EnumStatement) statementNode()       {}
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+
+	var variants []string
+	for _, v := range es.Variants {
+		variants = append(variants, v.String())
+	}
+
+	out.WriteString(es.TokenLiteral() + " ")
+	out.WriteString(es.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(variants, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// MatchArm is a single `pattern => body` case of a match expression. A nil Pattern is the
+// `_` wildcard. When Bindings is set the pattern is an enum variant whose fields are bound
+// to those identifiers, otherwise the subject is compared to the value of Pattern.
+type MatchArm struct {
+	Token    token.Token // the first token of the pattern
+	Pattern  Expression
+	Bindings []*Identifier
+	Body     *BlockStatement
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	switch {
+	case ma.Pattern == nil:
+		out.WriteByte('_')
+	case ma.Bindings != nil:
+		var binds []string
+		for _, b := range ma.Bindings {
+			binds = append(binds, b.String())
+		}
+		out.WriteString(ma.Pattern.String())
+		out.WriteByte('(')
+		out.WriteString(strings.Join(binds, ", "))
+		out.WriteByte(')')
+	default:
+		out.WriteString(ma.Pattern.String())
+	}
+
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+type MatchExpression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	var arms []string
+	for _, a := range me.Arms {
+		arms = append(arms, a.String())
+	}
+
+	out.WriteString("match ")
+	out.WriteString(me.Subject.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
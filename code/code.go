@@ -25,12 +25,17 @@ const (
 	OpNotEqual
 	OpGreater
 	OpGreaterEqual
+	OpLess
+	OpLessEqual
+	OpIn
 
 	OpMinus
 	OpBang
 
 	OpJumpNotTrue
 	OpJump
+	OpJumpNull
+	OpJumpNotNull
 
 	OpGetGlobal
 	OpSetGlobal
@@ -41,7 +46,20 @@ const (
 
 	OpArray
 	OpHash
+	OpSet
+	OpTuple
+	OpUnpack
 	OpIndex
+	OpField
+	OpRange
+
+	OpIter
+	OpIterNext
+	OpAppend
+	OpInsert
+
+	OpMatchValue
+	OpMatchVariant
 
 	OpCall
 	OpReturn
@@ -50,6 +68,12 @@ const (
 	OpClosure
 )
 
+// Flags for the operand of OpRange.
+const (
+	RangeInclusive = 1 << iota
+	RangeStep
+)
+
 type Instructions []byte
 
 func (ins Instructions) String() string {
@@ -113,12 +137,17 @@ var definitions = map[OpCode]*Definition{
 	OpNotEqual:     {"OpNotEqual", []int{}},
 	OpGreater:      {"OpGreater", []int{}},
 	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpLess:         {"OpLess", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpIn:           {"OpIn", []int{}},
 
 	OpMinus: {"OpMinus", []int{}},
 	OpBang:  {"OpBang", []int{}},
 
 	OpJumpNotTrue: {"OpJumpNotTrue", []int{2}},
 	OpJump:        {"OpJump", []int{2}},
+	OpJumpNull:    {"OpJumpNull", []int{2}},
+	OpJumpNotNull: {"OpJumpNotNull", []int{2}},
 
 	OpGetGlobal:  {"OpGetGlobal", []int{2}},
 	OpSetGlobal:  {"OpSetGlobal", []int{2}},
@@ -127,9 +156,22 @@ var definitions = map[OpCode]*Definition{
 	OpGetBuiltin: {"OpGetBuiltin", []int{1}},
 	OpGetFree:    {"OpGetFree", []int{1}},
 
-	OpArray: {"OpArray", []int{2}},
-	OpHash:  {"OpHash", []int{2}},
-	OpIndex: {"OpIndex", []int{}},
+	OpArray:  {"OpArray", []int{2}},
+	OpHash:   {"OpHash", []int{2}},
+	OpSet:    {"OpSet", []int{2}},
+	OpTuple:  {"OpTuple", []int{2}},
+	OpUnpack: {"OpUnpack", []int{2}},
+	OpIndex:  {"OpIndex", []int{}},
+	OpField:  {"OpField", []int{2}},
+	OpRange:  {"OpRange", []int{1}},
+
+	OpIter:     {"OpIter", []int{}},
+	OpIterNext: {"OpIterNext", []int{1, 2}},
+	OpAppend:   {"OpAppend", []int{}},
+	OpInsert:   {"OpInsert", []int{}},
+
+	OpMatchValue:   {"OpMatchValue", []int{2}},
+	OpMatchVariant: {"OpMatchVariant", []int{1, 2}},
 
 	OpCall:        {"OpCall", []int{1}},
 	OpReturn:      {"OpReturn", []int{}},
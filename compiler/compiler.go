@@ -5,7 +5,7 @@ import (
 	"github.com/butlermatt/monkey/ast"
 	"github.com/butlermatt/monkey/code"
 	"github.com/butlermatt/monkey/object"
-	"sort"
+	"strings"
 )
 
 type EmittedInstruction struct {
@@ -27,9 +27,13 @@ type CompilationScope struct {
 type Compiler struct {
 	constants   []object.Object
 	symbolTable *SymbolTable
+	enums       map[string]*object.EnumType
+	variants    map[string]*object.Variant
+	warnings    []string
 
-	scopes   []CompilationScope
-	scopeInd int
+	scopes         []CompilationScope
+	scopeInd       int
+	comprehensions int
 }
 
 func New() *Compiler {
@@ -48,6 +52,8 @@ func New() *Compiler {
 	return &Compiler{
 		constants:   []object.Object{},
 		symbolTable: table,
+		enums:       make(map[string]*object.EnumType),
+		variants:    make(map[string]*object.Variant),
 		scopes:      []CompilationScope{mainScope},
 		scopeInd:    0,
 	}
@@ -64,6 +70,11 @@ func (c *Compiler) ByteCode() *ByteCode {
 	return &ByteCode{Instructions: c.instructions(), Constants: c.constants}
 }
 
+// Warnings returns problems found during compilation which do not prevent the program running.
+func (c *Compiler) Warnings() []string {
+	return c.warnings
+}
+
 func (c *Compiler) Compile(node ast.Node) error {
 	switch node := node.(type) {
 	case *ast.Program:
@@ -101,21 +112,18 @@ func (c *Compiler) Compile(node ast.Node) error {
 			return fmt.Errorf("unknown operator %s", node.Operator)
 		}
 	case *ast.InfixExpression:
-		if node.Operator == "<" || node.Operator == "<=" {
-			err := c.Compile(node.Right)
+		if node.Operator == "??" {
+			err := c.Compile(node.Left)
 			if err != nil {
 				return err
 			}
 
-			err = c.Compile(node.Left)
+			jumpPos := c.emit(code.OpJumpNotNull, 9999)
+			err = c.Compile(node.Right)
 			if err != nil {
 				return err
 			}
-			if node.Operator == "<=" {
-				c.emit(code.OpGreaterEqual)
-			} else {
-				c.emit(code.OpGreater)
-			}
+			c.changeOperand(jumpPos, len(c.instructions()))
 			return nil
 		}
 
@@ -142,10 +150,16 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.emit(code.OpGreater)
 		case ">=":
 			c.emit(code.OpGreaterEqual)
+		case "<":
+			c.emit(code.OpLess)
+		case "<=":
+			c.emit(code.OpLessEqual)
 		case "==":
 			c.emit(code.OpEqual)
 		case "!=":
 			c.emit(code.OpNotEqual)
+		case "in":
+			c.emit(code.OpIn)
 		default:
 			return fmt.Errorf("unknown operator %s", node.Operator)
 		}
@@ -186,8 +200,20 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 		afterAltPos := len(c.instructions())
 		c.changeOperand(jumpPos, afterAltPos)
-	case *ast.NumberLiteral:
-		num := &object.Number{Value: node.Value}
+	case *ast.IntegerLiteral:
+		num := &object.Integer{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(num))
+	case *ast.BigIntLiteral:
+		num := &object.BigInt{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(num))
+	case *ast.DecimalLiteral:
+		num, err := object.ParseDecimal(node.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpConstant, c.addConstant(num))
+	case *ast.FloatLiteral:
+		num := &object.Float{Value: node.Value}
 		c.emit(code.OpConstant, c.addConstant(num))
 	case *ast.Boolean:
 		if node.Value {
@@ -198,6 +224,9 @@ func (c *Compiler) Compile(node ast.Node) error {
 	case *ast.StringLiteral:
 		str := &object.String{Value: node.Value}
 		c.emit(code.OpConstant, c.addConstant(str))
+	case *ast.BytesLiteral:
+		b := &object.Bytes{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(b))
 	case *ast.Identifier:
 		symbol, ok := c.symbolTable.Resolve(node.Value)
 		if !ok {
@@ -205,17 +234,48 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 		c.loadSymbol(symbol)
 	case *ast.LetStatement:
+		if node.Name == nil {
+			return c.compileUnpack(node)
+		}
+
 		symbol := c.symbolTable.Define(node.Name.Value)
 		err := c.Compile(node.Value)
 		if err != nil {
 			return err
 		}
 
-		if symbol.Scope == GlobalScope {
-			c.emit(code.OpSetGlobal, symbol.Index)
-		} else {
-			c.emit(code.OpSetLocal, symbol.Index)
+		c.storeSymbol(symbol)
+	case *ast.StructStatement:
+		var fields []string
+		for _, f := range node.Fields {
+			fields = append(fields, f.Value)
 		}
+
+		c.bindConstant(node.Name.Value, object.NewStructType(node.Name.Value, fields))
+	case *ast.EnumStatement:
+		var names []string
+		var fields [][]string
+		for _, v := range node.Variants {
+			names = append(names, v.Name.Value)
+
+			var fs []string
+			for _, f := range v.Fields {
+				fs = append(fs, f.Value)
+			}
+			fields = append(fields, fs)
+		}
+
+		et := object.NewEnumType(node.Name.Value, names, fields)
+		c.bindConstant(et.Name, et)
+		c.enums[et.Name] = et
+		for _, v := range et.Variants {
+			c.bindConstant(v.Name, v.Binding())
+			c.variants[v.Name] = v
+		}
+	case *ast.MatchExpression:
+		return c.compileMatch(node)
+	case *ast.FieldExpression:
+		return c.compileChain(node)
 	case *ast.ArrayLiteral:
 		for _, el := range node.Elements {
 			err := c.Compile(el)
@@ -226,13 +286,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 		c.emit(code.OpArray, len(node.Elements))
 	case *ast.HashLiteral:
-		var keys []ast.Expression
-		for k := range node.Pairs {
-			keys = append(keys, k)
-		}
-		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
-
-		for _, k := range keys {
+		for _, k := range node.Keys {
 			err := c.Compile(k)
 			if err != nil {
 				return err
@@ -243,18 +297,79 @@ func (c *Compiler) Compile(node ast.Node) error {
 			}
 		}
 		c.emit(code.OpHash, len(node.Pairs)*2)
-	case *ast.IndexExpression:
-		err := c.Compile(node.Left)
+	case *ast.TupleLiteral:
+		for _, el := range node.Elements {
+			err := c.Compile(el)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpTuple, len(node.Elements))
+	case *ast.SetLiteral:
+		for _, el := range node.Elements {
+			err := c.Compile(el)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpSet, len(node.Elements))
+	case *ast.RangeExpression:
+		err := c.Compile(node.Start)
 		if err != nil {
 			return err
 		}
 
-		err = c.Compile(node.Index)
+		err = c.Compile(node.End)
 		if err != nil {
 			return err
 		}
-		c.emit(code.OpIndex)
+
+		flags := 0
+		if node.Inclusive {
+			flags |= code.RangeInclusive
+		}
+		if node.Step != nil {
+			err = c.Compile(node.Step)
+			if err != nil {
+				return err
+			}
+			flags |= code.RangeStep
+		}
+		c.emit(code.OpRange, flags)
+	case *ast.ArrayComprehension:
+		return c.compileComprehension(code.OpArray, node.Clause, func() error {
+			err := c.Compile(node.Element)
+			if err != nil {
+				return err
+			}
+
+			c.emit(code.OpAppend)
+			return nil
+		})
+	case *ast.HashComprehension:
+		return c.compileComprehension(code.OpHash, node.Clause, func() error {
+			err := c.Compile(node.Key)
+			if err != nil {
+				return err
+			}
+
+			err = c.Compile(node.Value)
+			if err != nil {
+				return err
+			}
+
+			c.emit(code.OpInsert)
+			return nil
+		})
+	case *ast.IndexExpression:
+		return c.compileChain(node)
 	case *ast.ReturnStatement:
+		if c.comprehensions > 0 {
+			return fmt.Errorf("cannot return from inside a comprehension")
+		}
+
 		err := c.Compile(node.ReturnValue)
 		if err != nil {
 			return err
@@ -268,7 +383,10 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.symbolTable.Define(p.Value)
 		}
 
+		comprehensions := c.comprehensions
+		c.comprehensions = 0
 		err := c.Compile(node.Body)
+		c.comprehensions = comprehensions
 		if err != nil {
 			return err
 		}
@@ -292,7 +410,58 @@ func (c *Compiler) Compile(node ast.Node) error {
 		fn := &object.CompiledFunction{Instructions: inst, NumLocals: numLocals, NumParams: len(node.Parameters)}
 		c.emit(code.OpClosure, c.addConstant(fn), len(free))
 	case *ast.CallExpression:
-		err := c.Compile(node.Function)
+		return c.compileChain(node)
+	}
+
+	return nil
+}
+
+// compileChain compiles a chain of field, index and call expressions. When an optional link
+// such as `a?.b` or `a?[i]` finds null it jumps past the rest of the chain, leaving null as the
+// result.
+func (c *Compiler) compileChain(node ast.Expression) error {
+	var jumps []int
+	err := c.compileChainLink(node, &jumps)
+	if err != nil {
+		return err
+	}
+
+	after := len(c.instructions())
+	for _, pos := range jumps {
+		c.changeOperand(pos, after)
+	}
+	return nil
+}
+
+func (c *Compiler) compileChainLink(node ast.Expression, jumps *[]int) error {
+	switch node := node.(type) {
+	case *ast.FieldExpression:
+		err := c.compileChainLink(node.Left, jumps)
+		if err != nil {
+			return err
+		}
+		if node.Optional {
+			*jumps = append(*jumps, c.emit(code.OpJumpNull, 9999))
+		}
+
+		name := &object.String{Value: node.Field.Value}
+		c.emit(code.OpField, c.addConstant(name))
+	case *ast.IndexExpression:
+		err := c.compileChainLink(node.Left, jumps)
+		if err != nil {
+			return err
+		}
+		if node.Optional {
+			*jumps = append(*jumps, c.emit(code.OpJumpNull, 9999))
+		}
+
+		err = c.Compile(node.Index)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+	case *ast.CallExpression:
+		err := c.compileChainLink(node.Function, jumps)
 		if err != nil {
 			return err
 		}
@@ -305,11 +474,224 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 
 		c.emit(code.OpCall, len(node.Arguments))
+	default:
+		return c.Compile(node)
+	}
+
+	return nil
+}
+
+// compileMatch compiles each arm as a test against the subject, which stays on the stack until an
+// arm matches. Tests which fail jump to the next arm; when none match the result is null.
+func (c *Compiler) compileMatch(node *ast.MatchExpression) error {
+	err := c.Compile(node.Subject)
+	if err != nil {
+		return err
+	}
+
+	c.checkExhaustive(node)
+
+	var endJumps []int
+	wildcard := false
+	for _, arm := range node.Arms {
+		nextJump := -1
+		c.symbolTable = NewBlockSymbolTable(c.symbolTable)
+
+		switch {
+		case arm.Pattern == nil:
+			wildcard = true
+			c.emit(code.OpPop)
+		case arm.Bindings != nil:
+			err := c.Compile(arm.Pattern)
+			if err != nil {
+				return err
+			}
+
+			nextJump = c.emit(code.OpMatchVariant, len(arm.Bindings), 9999)
+			for i := len(arm.Bindings) - 1; i >= 0; i-- {
+				if arm.Bindings[i].Value == "_" {
+					c.emit(code.OpPop)
+					continue
+				}
+				c.storeSymbol(c.symbolTable.Define(arm.Bindings[i].Value))
+			}
+		default:
+			err := c.Compile(arm.Pattern)
+			if err != nil {
+				return err
+			}
+
+			nextJump = c.emit(code.OpMatchValue, 9999)
+		}
+
+		bodyPos := len(c.instructions())
+		err := c.Compile(arm.Body)
+		if err != nil {
+			return err
+		}
+		if len(c.instructions()) > bodyPos && c.lastInstIs(code.OpPop) {
+			c.removeLastPop()
+		} else {
+			c.emit(code.OpNull)
+		}
+
+		endJumps = append(endJumps, c.emit(code.OpJump, 9999))
+		c.symbolTable = c.symbolTable.Outer
+
+		if nextJump >= 0 {
+			op := code.OpCode(c.instructions()[nextJump])
+			if op == code.OpMatchVariant {
+				c.replaceInst(nextJump, code.Make(op, len(arm.Bindings), len(c.instructions())))
+			} else {
+				c.changeOperand(nextJump, len(c.instructions()))
+			}
+		}
+
+		if wildcard {
+			break
+		}
+	}
+
+	if !wildcard {
+		c.emit(code.OpPop)
+		c.emit(code.OpNull)
+	}
+
+	afterPos := len(c.instructions())
+	for _, pos := range endJumps {
+		c.changeOperand(pos, afterPos)
 	}
 
 	return nil
 }
 
+// checkExhaustive records a warning when every arm of a match names a variant of the same
+// enum, but not all of that enum's variants are covered.
+func (c *Compiler) checkExhaustive(node *ast.MatchExpression) {
+	var enum *object.EnumType
+	covered := make(map[string]bool)
+
+	for _, arm := range node.Arms {
+		if arm.Pattern == nil {
+			return
+		}
+
+		v := c.patternVariant(arm.Pattern)
+		if v == nil || (enum != nil && v.Enum != enum) {
+			return
+		}
+
+		enum = v.Enum
+		covered[v.Name] = true
+	}
+
+	if enum == nil {
+		return
+	}
+
+	var missing []string
+	for _, v := range enum.Variants {
+		if !covered[v.Name] {
+			missing = append(missing, v.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		msg := fmt.Sprintf("line %d: match on %s is not exhaustive, missing %s", node.Token.Line, enum.Name, strings.Join(missing, ", "))
+		c.warnings = append(c.warnings, msg)
+	}
+}
+
+// patternVariant returns the enum variant named by a match pattern, such as `Circle` or
+// `Shape.Circle`, if it was declared in this compilation. A bare name is the variant it was
+// last bound to, while a qualified one is looked up in its enum, as other enums may reuse
+// the name.
+func (c *Compiler) patternVariant(pattern ast.Expression) *object.Variant {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		return c.variants[pattern.Value]
+	case *ast.FieldExpression:
+		ident, ok := pattern.Left.(*ast.Identifier)
+		if !ok {
+			return nil
+		}
+		if enum, ok := c.enums[ident.Value]; ok {
+			return enum.Variant(pattern.Field.Value)
+		}
+	}
+
+	return nil
+}
+
+// compileComprehension compiles a comprehension as a closure which is called immediately, so
+// its loop variables are locals which do not leak into the surrounding scope. Inside, the
+// accumulator built by collection and the iterator sit on the stack while body adds each element.
+// A `return` in the comprehension would only leave the hidden closure, so it is rejected.
+func (c *Compiler) compileComprehension(collection code.OpCode, clause *ast.ComprehensionClause, body func() error) error {
+	c.enterScope()
+	c.comprehensions++
+	err := c.compileComprehensionLoop(collection, clause, body)
+	c.comprehensions--
+	if err != nil {
+		c.leaveScope()
+		return err
+	}
+
+	free := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.numDef
+	inst := c.leaveScope()
+
+	for _, s := range free {
+		c.loadSymbol(s)
+	}
+
+	fn := &object.CompiledFunction{Instructions: inst, NumLocals: numLocals}
+	c.emit(code.OpClosure, c.addConstant(fn), len(free))
+	c.emit(code.OpCall, 0)
+
+	return nil
+}
+
+// compileComprehensionLoop compiles the body of the closure created by compileComprehension.
+func (c *Compiler) compileComprehensionLoop(collection code.OpCode, clause *ast.ComprehensionClause, body func() error) error {
+	c.emit(collection, 0)
+
+	err := c.Compile(clause.Iterable)
+	if err != nil {
+		return err
+	}
+	c.emit(code.OpIter)
+
+	var symbols []Symbol
+	for _, v := range clause.Variables {
+		symbols = append(symbols, c.symbolTable.Define(v.Value))
+	}
+
+	loopPos := c.emit(code.OpIterNext, len(symbols), 9999)
+	for i := len(symbols) - 1; i >= 0; i-- {
+		c.emit(code.OpSetLocal, symbols[i].Index)
+	}
+
+	if clause.Condition != nil {
+		err := c.Compile(clause.Condition)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpJumpNotTrue, loopPos)
+	}
+
+	err = body()
+	if err != nil {
+		return err
+	}
+	c.emit(code.OpJump, loopPos)
+
+	c.replaceInst(loopPos, code.Make(code.OpIterNext, len(symbols), len(c.instructions())))
+	c.emit(code.OpReturnValue)
+
+	return nil
+}
+
 func (c *Compiler) emit(op code.OpCode, operands ...int) int {
 	ins := code.Make(op, operands...)
 	pos := c.addInstruction(ins)
@@ -407,6 +789,36 @@ func (c *Compiler) replacePopWithReturn() {
 	c.scopes[c.scopeInd].last.Opcode = code.OpReturnValue
 }
 
+// bindConstant defines name in the current scope with the constant obj as its value.
+func (c *Compiler) bindConstant(name string, obj object.Object) {
+	symbol := c.symbolTable.Define(name)
+	c.emit(code.OpConstant, c.addConstant(obj))
+	c.storeSymbol(symbol)
+}
+
+// compileUnpack compiles let a, b = value;. OpUnpack leaves the values on the stack with the
+// first on top, ready to be stored in order.
+func (c *Compiler) compileUnpack(node *ast.LetStatement) error {
+	err := c.Compile(node.Value)
+	if err != nil {
+		return err
+	}
+
+	c.emit(code.OpUnpack, len(node.Names))
+	for _, name := range node.Names {
+		c.storeSymbol(c.symbolTable.Define(name.Value))
+	}
+	return nil
+}
+
+func (c *Compiler) storeSymbol(s Symbol) {
+	if s.Scope == GlobalScope {
+		c.emit(code.OpSetGlobal, s.Index)
+	} else {
+		c.emit(code.OpSetLocal, s.Index)
+	}
+}
+
 func (c *Compiler) loadSymbol(s Symbol) {
 	var op code.OpCode
 	switch s.Scope {
@@ -80,7 +80,18 @@ func TestNumberArithmetic(t *testing.T) {
 		{
 			name:   "one plus two",
 			input:  "1 + 2;",
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			name:   "float plus integer",
+			input:  "1.5 + 2;",
+			consts: []interface{}{1.5, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -91,7 +102,7 @@ func TestNumberArithmetic(t *testing.T) {
 		{
 			name:   "one minus two",
 			input:  "1 - 2;",
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -102,7 +113,7 @@ func TestNumberArithmetic(t *testing.T) {
 		{
 			name:   "one times two",
 			input:  "1 * 2;",
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -113,7 +124,7 @@ func TestNumberArithmetic(t *testing.T) {
 		{
 			name:   "two divided by 1",
 			input:  "2 / 1;",
-			consts: []interface{}{2.0, 1.0},
+			consts: []interface{}{2, 1},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -124,7 +135,7 @@ func TestNumberArithmetic(t *testing.T) {
 		{
 			name:   "pop expression statement",
 			input:  "1; 2",
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpPop),
@@ -135,7 +146,7 @@ func TestNumberArithmetic(t *testing.T) {
 		{
 			name:   "negative one",
 			input:  "-1;",
-			consts: []interface{}{1.0},
+			consts: []interface{}{1},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpMinus),
@@ -170,7 +181,7 @@ func TestBooleanExpressions(t *testing.T) {
 		{
 			name:   "1 Gt 2",
 			input:  "1 > 2",
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -181,18 +192,18 @@ func TestBooleanExpressions(t *testing.T) {
 		{
 			name:   "1 Lt 2",
 			input:  "1 < 2",
-			consts: []interface{}{2.0, 1.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
-				code.Make(code.OpGreater),
+				code.Make(code.OpLess),
 				code.Make(code.OpPop),
 			},
 		},
 		{
 			name:   "1 GtEq 2",
 			input:  "1 >= 2",
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -203,18 +214,18 @@ func TestBooleanExpressions(t *testing.T) {
 		{
 			name:   "1 LtEq 2",
 			input:  "1 <= 2",
-			consts: []interface{}{2.0, 1.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
-				code.Make(code.OpGreaterEqual),
+				code.Make(code.OpLessEqual),
 				code.Make(code.OpPop),
 			},
 		},
 		{
 			name:   "1 EqEq 2",
 			input:  "1 == 2",
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -225,7 +236,7 @@ func TestBooleanExpressions(t *testing.T) {
 		{
 			name:   "1 NotEq 2",
 			input:  "1 != 2",
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -275,7 +286,7 @@ func TestConditionals(t *testing.T) {
 		{
 			name:   "if true ten",
 			input:  `if (true) { 10 }; 3333;`,
-			consts: []interface{}{10.0, 3333.0},
+			consts: []interface{}{10, 3333},
 			insts: []code.Instructions{
 				code.Make(code.OpTrue),            // 0000
 				code.Make(code.OpJumpNotTrue, 10), // 0001
@@ -290,7 +301,7 @@ func TestConditionals(t *testing.T) {
 		{
 			name:   "if true ten else twenty",
 			input:  `if (true) { 10 } else { 20 }; 3333;`,
-			consts: []interface{}{10.0, 20.0, 3333.0},
+			consts: []interface{}{10, 20, 3333},
 			insts: []code.Instructions{
 				code.Make(code.OpTrue),            // 0000
 				code.Make(code.OpJumpNotTrue, 10), // 0001
@@ -314,7 +325,7 @@ func TestGlobalLetStatements(t *testing.T) {
 			input: `let one = 1;
 let two = 2;
 `,
-			consts: []interface{}{1.0, 2.0},
+			consts: []interface{}{1, 2},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpSetGlobal, 0),
@@ -326,7 +337,7 @@ let two = 2;
 			name: "let one and retrieve",
 			input: `let one = 1;
 one;`,
-			consts: []interface{}{1.0},
+			consts: []interface{}{1},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpSetGlobal, 0),
@@ -339,7 +350,7 @@ one;`,
 			input: `let one = 1;
 let two = one;
 two;`,
-			consts: []interface{}{1.0},
+			consts: []interface{}{1},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpSetGlobal, 0),
@@ -361,7 +372,7 @@ func TestLetStatementScopes(t *testing.T) {
 			input: `let num = 55;
 					fn() { num }`,
 			consts: []interface{}{
-				55.0,
+				55,
 				[]code.Instructions{
 					code.Make(code.OpGetGlobal, 0),
 					code.Make(code.OpReturnValue),
@@ -381,7 +392,7 @@ func TestLetStatementScopes(t *testing.T) {
 						num
 					}`,
 			consts: []interface{}{
-				55.0,
+				55,
 				[]code.Instructions{
 					code.Make(code.OpConstant, 0),
 					code.Make(code.OpSetLocal, 0),
@@ -402,8 +413,8 @@ func TestLetStatementScopes(t *testing.T) {
 						a + b
 					}`,
 			consts: []interface{}{
-				55.0,
-				77.0,
+				55,
+				77,
 				[]code.Instructions{
 					code.Make(code.OpConstant, 0),
 					code.Make(code.OpSetLocal, 0),
@@ -466,7 +477,7 @@ func TestArrayLiterals(t *testing.T) {
 		{
 			name:   "simple 3 numbers",
 			input:  "[1, 2, 3]",
-			consts: []interface{}{1.0, 2.0, 3.0},
+			consts: []interface{}{1, 2, 3},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -478,7 +489,7 @@ func TestArrayLiterals(t *testing.T) {
 		{
 			name:   "three simple expressions",
 			input:  "[1 + 2, 3 - 4, 5 * 6]",
-			consts: []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0},
+			consts: []interface{}{1, 2, 3, 4, 5, 6},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -512,7 +523,7 @@ func TestHashLiterals(t *testing.T) {
 		{
 			name:   "simple hash",
 			input:  "{1: 2, 3: 4, 5: 6}",
-			consts: []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0},
+			consts: []interface{}{1, 2, 3, 4, 5, 6},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -527,7 +538,7 @@ func TestHashLiterals(t *testing.T) {
 		{
 			name:   "complex hash values",
 			input:  "{1: 2 + 3, 4: 5 * 6}",
-			consts: []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0},
+			consts: []interface{}{1, 2, 3, 4, 5, 6},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -546,12 +557,65 @@ func TestHashLiterals(t *testing.T) {
 	runCompilerTests(t, tests)
 }
 
+func TestSetLiterals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			name:   "simple set",
+			input:  "{1, 2 + 3}",
+			consts: []interface{}{1, 2, 3},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpAdd),
+				code.Make(code.OpSet, 2),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestTuples(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			name:   "tuple literal",
+			input:  "(1, 2)",
+			consts: []interface{}{1, 2},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpTuple, 2),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			name:   "unpacking let",
+			input:  "let a, b = (1, 2); b",
+			consts: []interface{}{1, 2},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpTuple, 2),
+				code.Make(code.OpUnpack, 2),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
 func TestIndexExpressions(t *testing.T) {
 	tests := []compilerTestCase{
 		{
 			name:   "array one plus one index",
 			input:  "[1, 2, 3][1 + 1]",
-			consts: []interface{}{1.0, 2.0, 3.0, 1.0, 1.0},
+			consts: []interface{}{1, 2, 3, 1, 1},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -567,7 +631,7 @@ func TestIndexExpressions(t *testing.T) {
 		{
 			name:   "hash two minus one index",
 			input:  "{1: 2}[2 - 1]",
-			consts: []interface{}{1.0, 2.0, 2.0, 1.0},
+			consts: []interface{}{1, 2, 2, 1},
 			insts: []code.Instructions{
 				code.Make(code.OpConstant, 0),
 				code.Make(code.OpConstant, 1),
@@ -584,14 +648,342 @@ func TestIndexExpressions(t *testing.T) {
 	runCompilerTests(t, tests)
 }
 
+func TestRangeExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			name:   "exclusive range",
+			input:  "1..10",
+			consts: []interface{}{1, 10},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpRange, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			name:   "inclusive range with step",
+			input:  "1..=10 step 2",
+			consts: []interface{}{1, 10, 2},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpRange, code.RangeInclusive|code.RangeStep),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			name:   "membership",
+			input:  "1 in 1..2",
+			consts: []interface{}{1, 1, 2},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpRange, 0),
+				code.Make(code.OpIn),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			name:  "array comprehension",
+			input: "[x * 2 for x in [1] if x > 0]",
+			consts: []interface{}{
+				1,
+				0,
+				2,
+				[]code.Instructions{
+					code.Make(code.OpArray, 0),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpArray, 1),
+					code.Make(code.OpIter),
+					code.Make(code.OpIterNext, 1, 35),
+					code.Make(code.OpSetLocal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpGreater),
+					code.Make(code.OpJumpNotTrue, 10),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 2),
+					code.Make(code.OpMul),
+					code.Make(code.OpAppend),
+					code.Make(code.OpJump, 10),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			insts: []code.Instructions{
+				code.Make(code.OpClosure, 3, 0),
+				code.Make(code.OpCall, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			name:  "hash comprehension over global",
+			input: "let h = {}; {v: k for k, v in h}",
+			consts: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpHash, 0),
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpIter),
+					code.Make(code.OpIterNext, 2, 23),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpSetLocal, 0),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpInsert),
+					code.Make(code.OpJump, 7),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			insts: []code.Instructions{
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpCall, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestComprehensionVariablesDoNotLeak(t *testing.T) {
+	program := parse("[x for x in [1, 2]]; x;")
+
+	compiler := New()
+	err := compiler.Compile(program)
+	if err == nil {
+		t.Fatalf("expected compiler error but had none")
+	}
+
+	if err.Error() != "undefined variable x" {
+		t.Fatalf("wrong compiler error. expected=%q, got=%q", "undefined variable x", err)
+	}
+}
+
+func TestComprehensionReturn(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"element", "[if (x > 1) { return x } else { x } for x in [1, 2]]"},
+		{"condition", "{x: x for x in [1] if if (x) { return true } else { true }}"},
+		{"nested comprehension", "[[if (y) { return y } else { y } for y in x] for x in [[1]]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiler := New()
+			global := compiler.symbolTable
+			err := compiler.Compile(parse(tt.input))
+			if err == nil {
+				t.Fatalf("expected compiler error but had none")
+			}
+
+			if err.Error() != "cannot return from inside a comprehension" {
+				t.Fatalf("wrong compiler error. expected=%q, got=%q", "cannot return from inside a comprehension", err)
+			}
+
+			if compiler.scopeInd != 0 {
+				t.Errorf("scope index wrong. expected=%d, got=%d", 0, compiler.scopeInd)
+			}
+
+			if compiler.symbolTable != global {
+				t.Errorf("compiler did not restore global symbol table")
+			}
+		})
+	}
+
+	program := parse("[fn() { return x }() for x in [1]]; fn() { return 1 }")
+	if err := New().Compile(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			name:   "declare construct and access",
+			input:  "struct Point { x, y }; Point(1, 2).x",
+			consts: []interface{}{"struct Point { x, y }", 1, 2, "x"},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpCall, 2),
+				code.Make(code.OpField, 3),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestOptionalChaining(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			name:   "optional chain and coalesce",
+			input:  "let a = 1; a?[0].x ?? 2",
+			consts: []interface{}{1, 0, "x", 2},
+			insts: []code.Instructions{
+				// 0000
+				code.Make(code.OpConstant, 0),
+				// 0003
+				code.Make(code.OpSetGlobal, 0),
+				// 0006
+				code.Make(code.OpGetGlobal, 0),
+				// 0009
+				code.Make(code.OpJumpNull, 19),
+				// 0012
+				code.Make(code.OpConstant, 1),
+				// 0015
+				code.Make(code.OpIndex),
+				// 0016
+				code.Make(code.OpField, 2),
+				// 0019
+				code.Make(code.OpJumpNotNull, 25),
+				// 0022
+				code.Make(code.OpConstant, 3),
+				// 0025
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			name:   "value and wildcard arms",
+			input:  "match (1) { 2 => 3, _ => 4 }",
+			consts: []interface{}{1, 2, 3, 4},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpMatchValue, 15),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpJump, 22),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpJump, 22),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			name:   "variant arm without wildcard",
+			input:  "let v = 1; match (v) { v(a) => a }",
+			consts: []interface{}{1},
+			insts: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpMatchVariant, 1, 25),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpJump, 27),
+				code.Make(code.OpPop),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestMatchExhaustivenessWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		warnings []string
+	}{
+		{
+			"all variants",
+			"enum Shape { Circle(r), Rect(w, h), Empty }; match (Empty) { Circle(r) => r, Rect(w, h) => w, Empty => 0 }",
+			nil,
+		},
+		{
+			"missing variants",
+			"enum Shape { Circle(r), Rect(w, h), Empty }; match (Empty) { Circle(r) => r }",
+			[]string{"line 1: match on Shape is not exhaustive, missing Rect, Empty"},
+		},
+		{
+			"qualified variants",
+			"enum Shape { Circle(r), Empty }; match (Empty) { Shape.Circle(r) => r }",
+			[]string{"line 1: match on Shape is not exhaustive, missing Empty"},
+		},
+		{
+			"wildcard",
+			"enum Shape { Circle(r), Empty }; match (Empty) { Circle(r) => r, _ => 0 }",
+			nil,
+		},
+		{
+			"shared variant name qualified",
+			"enum A { X, Y }; enum B { X, Z }; match (A.X) { A.X => 1 }",
+			[]string{"line 1: match on A is not exhaustive, missing Y"},
+		},
+		{
+			"shared variant name bare",
+			"enum A { X, Y }; enum B { X, Z }; match (X) { X => 1 }",
+			[]string{"line 1: match on B is not exhaustive, missing Z"},
+		},
+		{
+			"shared variant name covered",
+			"enum A { X, Y }; enum B { X, Z }; match (A.X) { A.X => 1, A.Y => 2 }",
+			nil,
+		},
+		{
+			"not an enum",
+			"match (1) { 1 => 2 }",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiler := New()
+			err := compiler.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			warnings := compiler.Warnings()
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("wrong number of warnings. expected=%d, got=%d (%q)", len(tt.warnings), len(warnings), warnings)
+			}
+
+			for i, w := range tt.warnings {
+				if warnings[i] != w {
+					t.Errorf("wrong warning. expected=%q, got=%q", w, warnings[i])
+				}
+			}
+		})
+	}
+}
+
 func TestFunctions(t *testing.T) {
 	tests := []compilerTestCase{
 		{
 			name:  "no args explicit return",
 			input: `fn() { return 5 + 10 }`,
 			consts: []interface{}{
-				5.0,
-				10.0,
+				5,
+				10,
 				[]code.Instructions{
 					code.Make(code.OpConstant, 0),
 					code.Make(code.OpConstant, 1),
@@ -608,8 +1000,8 @@ func TestFunctions(t *testing.T) {
 			name:  "no args implicit return",
 			input: `fn() { 5 + 10 }`,
 			consts: []interface{}{
-				5.0,
-				10.0,
+				5,
+				10,
 				[]code.Instructions{
 					code.Make(code.OpConstant, 0),
 					code.Make(code.OpConstant, 1),
@@ -626,8 +1018,8 @@ func TestFunctions(t *testing.T) {
 			name:  "no args implicit return 2",
 			input: `fn() { 1; 2 }`,
 			consts: []interface{}{
-				1.0,
-				2.0,
+				1,
+				2,
 				[]code.Instructions{
 					code.Make(code.OpConstant, 0),
 					code.Make(code.OpPop),
@@ -664,7 +1056,7 @@ func TestFunctionCalls(t *testing.T) {
 			name:  "literal no args implicit return",
 			input: `fn() { 24 }();`,
 			consts: []interface{}{
-				24.0,
+				24,
 				[]code.Instructions{
 					code.Make(code.OpConstant, 0), // the literal 24
 					code.Make(code.OpReturnValue),
@@ -681,7 +1073,7 @@ func TestFunctionCalls(t *testing.T) {
 			input: `let noArg = fn() { 24 };
 					noArg();`,
 			consts: []interface{}{
-				24.0,
+				24,
 				[]code.Instructions{
 					code.Make(code.OpConstant, 0), // the literal 24
 					code.Make(code.OpReturnValue),
@@ -704,7 +1096,7 @@ func TestFunctionCalls(t *testing.T) {
 					code.Make(code.OpGetLocal, 0),
 					code.Make(code.OpReturnValue),
 				},
-				24.0,
+				24,
 			},
 			insts: []code.Instructions{
 				code.Make(code.OpClosure, 0, 0),
@@ -728,9 +1120,9 @@ func TestFunctionCalls(t *testing.T) {
 					code.Make(code.OpGetLocal, 2),
 					code.Make(code.OpReturnValue),
 				},
-				24.0,
-				25.0,
-				26.0,
+				24,
+				25,
+				26,
 			},
 			insts: []code.Instructions{
 				code.Make(code.OpClosure, 0, 0),
@@ -753,7 +1145,7 @@ func TestBuiltins(t *testing.T) {
 		{
 			name:   "len and push",
 			input:  `len([]); push([], 1);`,
-			consts: []interface{}{1.0},
+			consts: []interface{}{1},
 			insts: []code.Instructions{
 				code.Make(code.OpGetBuiltin, 0),
 				code.Make(code.OpArray, 0),
@@ -857,7 +1249,7 @@ func TestClosures(t *testing.T) {
 						}
 					};`,
 			consts: []interface{}{
-				55.0, 66.0, 77.0, 88.0,
+				55, 66, 77, 88,
 				[]code.Instructions{
 					code.Make(code.OpConstant, 3),
 					code.Make(code.OpSetLocal, 0),
@@ -954,12 +1346,24 @@ func testConstants(t *testing.T, expected []interface{}, actual []object.Object)
 
 	for i, constant := range expected {
 		switch constant := constant.(type) {
+		case int:
+			err := testIntegerObject(int64(constant), actual[i])
+			if err != nil {
+				return fmt.Errorf("constant %d - testIntegerObject failed: %s", i, err)
+			}
 		case float64:
-			err := testNumberObject(constant, actual[i])
+			err := testFloatObject(constant, actual[i])
 			if err != nil {
-				return fmt.Errorf("constant %d - testNumberObject failed: %s", i, err)
+				return fmt.Errorf("constant %d - testFloatObject failed: %s", i, err)
 			}
 		case string:
+			if st, ok := actual[i].(*object.StructType); ok {
+				if st.Inspect() != constant {
+					return fmt.Errorf("constant %d - wrong struct. expected=%q, got=%q", i, constant, st.Inspect())
+				}
+				continue
+			}
+
 			err := testStringObject(constant, actual[i])
 			if err != nil {
 				return fmt.Errorf("constant %d - testStringObject failed: %s", i, err)
@@ -979,10 +1383,23 @@ func testConstants(t *testing.T, expected []interface{}, actual []object.Object)
 	return nil
 }
 
-func testNumberObject(expected float64, actual object.Object) error {
-	res, ok := actual.(*object.Number)
+func testIntegerObject(expected int64, actual object.Object) error {
+	res, ok := actual.(*object.Integer)
+	if !ok {
+		return fmt.Errorf("object is wrong type. expected=*object.Integer got=%T (%+[1]v)", actual)
+	}
+
+	if res.Value != expected {
+		return fmt.Errorf("object has wrong value. expected=%d, got=%d", expected, res.Value)
+	}
+
+	return nil
+}
+
+func testFloatObject(expected float64, actual object.Object) error {
+	res, ok := actual.(*object.Float)
 	if !ok {
-		return fmt.Errorf("object is wrong type. expected=*object.Number got=%T (%+[1]v)", actual)
+		return fmt.Errorf("object is wrong type. expected=*object.Float got=%T (%+[1]v)", actual)
 	}
 
 	if res.Value != expected {
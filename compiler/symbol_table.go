@@ -21,6 +21,10 @@ type SymbolTable struct {
 	store       map[string]Symbol
 	numDef      int
 	FreeSymbols []Symbol
+
+	// block tables scope names without a frame of their own; their symbols
+	// are allocated in the enclosing table's frame.
+	block bool
 }
 
 func NewSymbolTable() *SymbolTable {
@@ -31,7 +35,24 @@ func NewEnclosedSymbolTable(table *SymbolTable) *SymbolTable {
 	return &SymbolTable{Outer: table, store: make(map[string]Symbol)}
 }
 
+// NewBlockSymbolTable returns a table for names that are only visible inside a
+// block, such as the bindings of a match arm, but live in the same frame as table.
+func NewBlockSymbolTable(table *SymbolTable) *SymbolTable {
+	return &SymbolTable{Outer: table, store: make(map[string]Symbol), block: true}
+}
+
 func (st *SymbolTable) Define(name string) Symbol {
+	symbol := st.allocate(name)
+	st.store[name] = symbol
+	return symbol
+}
+
+// allocate reserves a slot for name in the frame that owns the table.
+func (st *SymbolTable) allocate(name string) Symbol {
+	if st.block {
+		return st.Outer.allocate(name)
+	}
+
 	symbol := Symbol{Name: name, Index: st.numDef}
 	if st.Outer == nil {
 		symbol.Scope = GlobalScope
@@ -39,7 +60,6 @@ func (st *SymbolTable) Define(name string) Symbol {
 		symbol.Scope = LocalScope
 	}
 
-	st.store[name] = symbol
 	st.numDef++
 	return symbol
 }
@@ -50,6 +70,10 @@ func (st *SymbolTable) Resolve(name string) (Symbol, bool) {
 		return s, ok
 	}
 
+	if st.block {
+		return st.Outer.Resolve(name)
+	}
+
 	s, ok = st.Outer.Resolve(name)
 	if !ok || (s.Scope == GlobalScope || s.Scope == BuiltinScope) {
 		return s, ok
@@ -157,6 +157,39 @@ func TestResolveNestedLocals(t *testing.T) {
 	}
 }
 
+func TestBlockSymbolTable(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	block := NewBlockSymbolTable(global)
+	shadow := block.Define("a")
+	expected := Symbol{Name: "a", Scope: GlobalScope, Index: 1}
+	if shadow != expected {
+		t.Errorf("expected a=%+v, got=%+v", expected, shadow)
+	}
+
+	if s, _ := global.Resolve("a"); s.Index != 0 {
+		t.Errorf("block definition leaked into outer table. got=%+v", s)
+	}
+
+	local := NewEnclosedSymbolTable(global)
+	localBlock := NewBlockSymbolTable(local)
+	b := localBlock.Define("b")
+	expected = Symbol{Name: "b", Scope: LocalScope, Index: 0}
+	if b != expected {
+		t.Errorf("expected b=%+v, got=%+v", expected, b)
+	}
+	if local.numDef != 1 {
+		t.Errorf("block symbol not allocated in enclosing frame. numDef=%d", local.numDef)
+	}
+
+	a, ok := localBlock.Resolve("a")
+	expected = Symbol{Name: "a", Scope: GlobalScope, Index: 0}
+	if !ok || a != expected {
+		t.Errorf("expected a=%+v, got=%+v", expected, a)
+	}
+}
+
 func TestDefineResolveBuiltins(t *testing.T) {
 	global := NewSymbolTable()
 	firstLocal := NewEnclosedSymbolTable(global)
@@ -4,11 +4,87 @@ import (
 	"github.com/butlermatt/monkey/object"
 )
 
-var builtins = map[string]*object.Builtin{
-	"len":   object.GetBuiltinByName("len"),
-	"first": object.GetBuiltinByName("first"),
-	"last":  object.GetBuiltinByName("last"),
-	"rest":  object.GetBuiltinByName("rest"),
-	"push":  object.GetBuiltinByName("push"),
-	"puts":  object.GetBuiltinByName("puts"),
+var builtins = map[string]object.Object{
+	"len":     object.GetBuiltinByName("len"),
+	"first":   object.GetBuiltinByName("first"),
+	"last":    object.GetBuiltinByName("last"),
+	"rest":    object.GetBuiltinByName("rest"),
+	"push":    object.GetBuiltinByName("push"),
+	"puts":    object.GetBuiltinByName("puts"),
+	"int":     object.GetBuiltinByName("int"),
+	"float":   object.GetBuiltinByName("float"),
+	"str":     object.GetBuiltinByName("str"),
+	"decimal": object.GetBuiltinByName("decimal"),
+	"rescale": object.GetBuiltinByName("rescale"),
+
+	"set":          object.GetBuiltinByName("set"),
+	"add":          object.GetBuiltinByName("add"),
+	"remove":       object.GetBuiltinByName("remove"),
+	"has":          object.GetBuiltinByName("has"),
+	"union":        object.GetBuiltinByName("union"),
+	"intersection": object.GetBuiltinByName("intersection"),
+	"difference":   object.GetBuiltinByName("difference"),
+	"subset":       object.GetBuiltinByName("subset"),
+
+	"bytes":     object.GetBuiltinByName("bytes"),
+	"decode":    object.GetBuiltinByName("decode"),
+	"read_int":  object.GetBuiltinByName("read_int"),
+	"read_uint": object.GetBuiltinByName("read_uint"),
+
+	"ord": object.GetBuiltinByName("ord"),
+	"chr": object.GetBuiltinByName("chr"),
+
+	"split":       object.GetBuiltinByName("split"),
+	"join":        object.GetBuiltinByName("join"),
+	"trim":        object.GetBuiltinByName("trim"),
+	"trim_left":   object.GetBuiltinByName("trim_left"),
+	"trim_right":  object.GetBuiltinByName("trim_right"),
+	"replace":     object.GetBuiltinByName("replace"),
+	"contains":    object.GetBuiltinByName("contains"),
+	"starts_with": object.GetBuiltinByName("starts_with"),
+	"ends_with":   object.GetBuiltinByName("ends_with"),
+	"index_of":    object.GetBuiltinByName("index_of"),
+	"upper":       object.GetBuiltinByName("upper"),
+	"lower":       object.GetBuiltinByName("lower"),
+	"repeat":      object.GetBuiltinByName("repeat"),
+	"pad_left":    object.GetBuiltinByName("pad_left"),
+	"pad_right":   object.GetBuiltinByName("pad_right"),
+
+	"math": object.GetBuiltinByName("math"),
+
+	"map":     object.GetBuiltinByName("map"),
+	"filter":  object.GetBuiltinByName("filter"),
+	"reduce":  object.GetBuiltinByName("reduce"),
+	"sort_by": object.GetBuiltinByName("sort_by"),
+	"each":    object.GetBuiltinByName("each"),
+
+	"keys":         object.GetBuiltinByName("keys"),
+	"values":       object.GetBuiltinByName("values"),
+	"entries":      object.GetBuiltinByName("entries"),
+	"delete":       object.GetBuiltinByName("delete"),
+	"merge":        object.GetBuiltinByName("merge"),
+	"get":          object.GetBuiltinByName("get"),
+	"from_entries": object.GetBuiltinByName("from_entries"),
+
+	"json_parse":     object.GetBuiltinByName("json_parse"),
+	"json_stringify": object.GetBuiltinByName("json_stringify"),
+
+	"read_file":   object.GetBuiltinByName("read_file"),
+	"write_file":  object.GetBuiltinByName("write_file"),
+	"append_file": object.GetBuiltinByName("append_file"),
+	"list_dir":    object.GetBuiltinByName("list_dir"),
+	"exists":      object.GetBuiltinByName("exists"),
+
+	"regex": object.GetBuiltinByName("regex"),
+
+	"now":         object.GetBuiltinByName("now"),
+	"unix":        object.GetBuiltinByName("unix"),
+	"format_time": object.GetBuiltinByName("format_time"),
+	"parse_time":  object.GetBuiltinByName("parse_time"),
+	"duration":    object.GetBuiltinByName("duration"),
+	"sleep":       object.GetBuiltinByName("sleep"),
+
+	"format": object.GetBuiltinByName("format"),
+
+	"remove_file": object.GetBuiltinByName("remove_file"),
 }
@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"github.com/butlermatt/monkey/ast"
 	"github.com/butlermatt/monkey/object"
+	"math/big"
 )
 
 var (
-	Null  = &object.Null{}
-	True  = &object.Boolean{Value: true}
-	False = &object.Boolean{Value: false}
+	Null  = object.NullValue
+	True  = object.True
+	False = object.False
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
@@ -18,10 +19,22 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalProgram(node, env)
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
-	case *ast.NumberLiteral:
-		return &object.Number{Value: node.Value}
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}
+	case *ast.BigIntLiteral:
+		return &object.BigInt{Value: node.Value}
+	case *ast.DecimalLiteral:
+		num, err := object.ParseDecimal(node.Value)
+		if err != nil {
+			return newError(node.Token.Line, "%s", err)
+		}
+		return num
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBoolean(node.Value)
+	case *ast.BytesLiteral:
+		return &object.Bytes{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.PrefixExpression:
@@ -35,11 +48,17 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(left) {
 			return left
 		}
+		if node.Operator == "??" {
+			if left.Type() != object.NullObj {
+				return left
+			}
+			return Eval(node.Right, env)
+		}
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Token.Line, node.Operator, left, right)
+		return evalInfixExpression(node.Token.Line, node.Operator, left, right, env)
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
 	case *ast.IfExpression:
@@ -55,7 +74,39 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
+		if node.Name == nil {
+			return evalUnpack(node, val, env)
+		}
 		env.Set(node.Name.Value, val)
+	case *ast.StructStatement:
+		var fields []string
+		for _, f := range node.Fields {
+			fields = append(fields, f.Value)
+		}
+		env.Set(node.Name.Value, object.NewStructType(node.Name.Value, fields))
+	case *ast.EnumStatement:
+		var names []string
+		var fields [][]string
+		for _, v := range node.Variants {
+			names = append(names, v.Name.Value)
+
+			var fs []string
+			for _, f := range v.Fields {
+				fs = append(fs, f.Value)
+			}
+			fields = append(fields, fs)
+		}
+
+		et := object.NewEnumType(node.Name.Value, names, fields)
+		env.Set(et.Name, et)
+		for _, v := range et.Variants {
+			env.Set(v.Name, v.Binding())
+		}
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.FieldExpression:
+		val, _ := evalChain(node, env)
+		return val
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
@@ -63,16 +114,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		body := node.Body
 		return &object.Function{Parameters: params, Body: body, Env: env}
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return applyFunction(node.Token.Line, function, args)
+		val, _ := evalChain(node, env)
+		return val
 	case *ast.ArrayLiteral:
 		els := evalExpressions(node.Elements, env)
 		if len(els) == 1 && isError(els[0]) {
@@ -80,18 +123,24 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return &object.Array{Elements: els}
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
-		return evalIndexExpression(node.Token.Line, left, index)
+		val, _ := evalChain(node, env)
+		return val
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
+	case *ast.SetLiteral:
+		return evalSetLiteral(node, env)
+	case *ast.TupleLiteral:
+		els := evalExpressions(node.Elements, env)
+		if len(els) == 1 && isError(els[0]) {
+			return els[0]
+		}
+		return &object.Tuple{Elements: els}
+	case *ast.RangeExpression:
+		return evalRangeExpression(node, env)
+	case *ast.ArrayComprehension:
+		return evalArrayComprehension(node, env)
+	case *ast.HashComprehension:
+		return evalHashComprehension(node, env)
 	}
 
 	return Null
@@ -138,44 +187,68 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(line int, right object.Object) object.Object {
-	if right.Type() != object.NumberObj {
-		return newError(line, "unknown operator: -%s", right.Type())
+	switch right := right.(type) {
+	case *object.Integer, *object.BigInt:
+		result, err := object.IntegerArithmetic("-", &object.Integer{Value: 0}, right)
+		if err != nil {
+			return newError(line, "%s", err)
+		}
+		return result
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	case *object.Decimal:
+		return &object.Decimal{Unscaled: new(big.Int).Neg(right.Unscaled), Scale: right.Scale}
 	}
 
-	value := right.(*object.Number).Value
-	return &object.Number{Value: -value}
+	return newError(line, "unknown operator: -%s", right.Type())
 }
 
-func evalInfixExpression(line int, operator string, left, right object.Object) object.Object {
+func evalInfixExpression(line int, operator string, left, right object.Object, env *object.Environment) object.Object {
 	switch {
+	case operator == "in":
+		return evalInExpression(line, left, right)
+	case object.IsDecimalOperation(left, right):
+		return evalDecimalInfixExpression(line, operator, left, right, env.DecimalContext())
+	case object.IsNumber(left) && object.IsNumber(right):
+		return evalNumberInfixExpression(line, operator, left, right)
+	case operator == "==":
+		return nativeBoolToBoolean(object.Equal(left, right))
+	case operator == "!=":
+		return nativeBoolToBoolean(!object.Equal(left, right))
+	case object.IsTimeOperation(left, right):
+		return evalTimeInfixExpression(line, operator, left, right)
 	case left.Type() != right.Type():
 		return newError(line, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
-	case left.Type() == object.NumberObj:
-		return evalNumberInfixExpression(line, operator, left, right)
 	case left.Type() == object.StringObj:
 		return evalStringInfixExpression(line, operator, left, right)
-	case operator == "==":
-		return nativeBoolToBoolean(left == right)
-	case operator == "!=":
-		return nativeBoolToBoolean(left != right)
+	case left.Type() == object.BytesObj:
+		return evalBytesInfixExpression(line, operator, left, right)
+	case left.Type() == object.ArrayObj || left.Type() == object.TupleObj:
+		return evalArrayInfixExpression(line, operator, left, right)
 	}
 
 	return newError(line, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 }
 
+// evalNumberInfixExpression keeps arithmetic on two integers in integers, promoting to BIGINT
+// on overflow and dividing with truncation, and promotes to float when either side is a float.
 func evalNumberInfixExpression(line int, operator string, left, right object.Object) object.Object {
-	leftVal := left.(*object.Number).Value
-	rightVal := right.(*object.Number).Value
+	if object.IsInteger(left) && object.IsInteger(right) {
+		return evalIntegerInfixExpression(line, operator, left, right)
+	}
+
+	leftVal, _ := object.ToFloat(left)
+	rightVal, _ := object.ToFloat(right)
 
 	switch operator {
 	case "+":
-		return &object.Number{Value: leftVal + rightVal}
+		return &object.Float{Value: leftVal + rightVal}
 	case "-":
-		return &object.Number{Value: leftVal - rightVal}
+		return &object.Float{Value: leftVal - rightVal}
 	case "*":
-		return &object.Number{Value: leftVal * rightVal}
+		return &object.Float{Value: leftVal * rightVal}
 	case "/":
-		return &object.Number{Value: leftVal / rightVal}
+		return &object.Float{Value: leftVal / rightVal}
 	case "<":
 		return nativeBoolToBoolean(leftVal < rightVal)
 	case ">":
@@ -193,7 +266,58 @@ func evalNumberInfixExpression(line int, operator string, left, right object.Obj
 	return newError(line, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 }
 
+func evalIntegerInfixExpression(line int, operator string, left, right object.Object) object.Object {
+	switch operator {
+	case "+", "-", "*", "/":
+		result, err := object.IntegerArithmetic(operator, left, right)
+		if err != nil {
+			return newError(line, "%s", err)
+		}
+		return result
+	}
+
+	return evalComparison(line, operator, object.CompareIntegers(left, right), left, right)
+}
+
+func evalDecimalInfixExpression(line int, operator string, left, right object.Object, ctx object.DecimalContext) object.Object {
+	switch operator {
+	case "+", "-", "*", "/":
+		result, err := ctx.Arithmetic(operator, left, right)
+		if err != nil {
+			return newError(line, "%s", err)
+		}
+		return result
+	}
+
+	return evalComparison(line, operator, object.CompareDecimals(left, right), left, right)
+}
+
+// evalComparison evaluates a comparison of left and right, for which cmp is -1, 0 or 1 as the
+// left is less than, equal to or greater than the right.
+func evalComparison(line int, operator string, cmp int, left, right object.Object) object.Object {
+	switch operator {
+	case "<":
+		return nativeBoolToBoolean(cmp < 0)
+	case ">":
+		return nativeBoolToBoolean(cmp > 0)
+	case "==":
+		return nativeBoolToBoolean(cmp == 0)
+	case "!=":
+		return nativeBoolToBoolean(cmp != 0)
+	case "<=":
+		return nativeBoolToBoolean(cmp <= 0)
+	case ">=":
+		return nativeBoolToBoolean(cmp >= 0)
+	}
+
+	return newError(line, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
 func evalStringInfixExpression(line int, operator string, left, right object.Object) object.Object {
+	if isOrderingOperator(operator) {
+		return evalOrderingExpression(line, operator, left, right)
+	}
+
 	if operator != "+" {
 		return newError(line, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
@@ -203,6 +327,155 @@ func evalStringInfixExpression(line int, operator string, left, right object.Obj
 	return &object.String{Value: leftVal + rightVal}
 }
 
+func evalBytesInfixExpression(line int, operator string, left, right object.Object) object.Object {
+	if isOrderingOperator(operator) {
+		return evalOrderingExpression(line, operator, left, right)
+	}
+
+	if operator != "+" {
+		return newError(line, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	leftVal := left.(*object.Bytes).Value
+	rightVal := right.(*object.Bytes).Value
+	return &object.Bytes{Value: append(leftVal[:len(leftVal):len(leftVal)], rightVal...)}
+}
+
+func evalTimeInfixExpression(line int, operator string, left, right object.Object) object.Object {
+	if isOrderingOperator(operator) {
+		return evalOrderingExpression(line, operator, left, right)
+	}
+
+	result, err := object.TimeArithmetic(operator, left, right)
+	if err != nil {
+		return newError(line, "%s", err)
+	}
+	return result
+}
+
+func evalArrayInfixExpression(line int, operator string, left, right object.Object) object.Object {
+	if isOrderingOperator(operator) {
+		return evalOrderingExpression(line, operator, left, right)
+	}
+
+	return newError(line, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+func evalOrderingExpression(line int, operator string, left, right object.Object) object.Object {
+	cmp, err := object.Compare(left, right)
+	if err != nil {
+		return newError(line, "%s", err)
+	}
+
+	return evalComparison(line, operator, cmp, left, right)
+}
+
+func isOrderingOperator(operator string) bool {
+	return operator == "<" || operator == ">" || operator == "<=" || operator == ">="
+}
+
+func evalInExpression(line int, item, container object.Object) object.Object {
+	found, err := object.Contains(container, item)
+	if err != nil {
+		return newError(line, "%s", err)
+	}
+
+	return nativeBoolToBoolean(found)
+}
+
+func evalRangeExpression(node *ast.RangeExpression, env *object.Environment) object.Object {
+	start := Eval(node.Start, env)
+	if isError(start) {
+		return start
+	}
+
+	end := Eval(node.End, env)
+	if isError(end) {
+		return end
+	}
+
+	var step object.Object
+	if node.Step != nil {
+		step = Eval(node.Step, env)
+		if isError(step) {
+			return step
+		}
+	}
+
+	r, err := object.NewRange(start, end, step, node.Inclusive)
+	if err != nil {
+		return newError(node.Token.Line, "%s", err)
+	}
+
+	return r
+}
+
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range node.Arms {
+		scope := object.NewEnclosedEnvironment(env)
+		matched, err := matchArm(arm, subject, scope)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		res := Eval(arm.Body, scope)
+		if res == nil {
+			return Null
+		}
+		return res
+	}
+
+	return Null
+}
+
+// matchArm tests subject against the arm's pattern, binding any variant fields in env when it
+// matches. Each arm is given its own env so bindings do not leak out of the match. A non-nil
+// error object is returned if the pattern itself is invalid.
+func matchArm(arm *ast.MatchArm, subject object.Object, env *object.Environment) (bool, object.Object) {
+	if arm.Pattern == nil {
+		return true, nil
+	}
+
+	pattern := Eval(arm.Pattern, env)
+	if isError(pattern) {
+		return false, pattern
+	}
+
+	if arm.Bindings == nil {
+		return object.Equal(subject, pattern), nil
+	}
+
+	variant, ok := pattern.(*object.Variant)
+	if !ok {
+		return false, newError(arm.Token.Line, "not an enum variant: %s", pattern.Type())
+	}
+	if len(variant.Fields) != len(arm.Bindings) {
+		return false, newError(arm.Token.Line, "wrong number of bindings for %s: expected=%d, got=%d",
+			variant.Name, len(variant.Fields), len(arm.Bindings))
+	}
+
+	value, ok := subject.(*object.EnumValue)
+	if !ok || value.Variant != variant {
+		return false, nil
+	}
+
+	for i, b := range arm.Bindings {
+		if b.Value != "_" {
+			env.Set(b.Value, value.Values[i])
+		}
+	}
+
+	return true, nil
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 
@@ -233,6 +506,55 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	return res
 }
 
+// evalChain evaluates a chain of field, index and call expressions. It reports whether an
+// optional link such as `a?.b` or `a?[i]` found null, in which case the rest of the chain is
+// skipped and the result is null.
+func evalChain(node ast.Expression, env *object.Environment) (object.Object, bool) {
+	switch node := node.(type) {
+	case *ast.FieldExpression:
+		left, skip := evalChain(node.Left, env)
+		if skip || isError(left) {
+			return left, skip
+		}
+		if node.Optional && left.Type() == object.NullObj {
+			return Null, true
+		}
+
+		val, err := object.Field(left, node.Field.Value)
+		if err != nil {
+			return newError(node.Token.Line, "%s", err), false
+		}
+		return val, false
+	case *ast.IndexExpression:
+		left, skip := evalChain(node.Left, env)
+		if skip || isError(left) {
+			return left, skip
+		}
+		if node.Optional && left.Type() == object.NullObj {
+			return Null, true
+		}
+
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index, false
+		}
+		return evalIndexExpression(node.Token.Line, left, index), false
+	case *ast.CallExpression:
+		function, skip := evalChain(node.Function, env)
+		if skip || isError(function) {
+			return function, skip
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0], false
+		}
+
+		return applyFunction(env, node.Token.Line, function, args), false
+	}
+
+	return Eval(node, env), false
+}
+
 func evalIdentifier(ident *ast.Identifier, env *object.Environment) object.Object {
 	if val, ok := env.Get(ident.Value); ok {
 		return val
@@ -261,8 +583,22 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 
 func evalIndexExpression(line int, left, index object.Object) object.Object {
 	switch {
-	case left.Type() == object.ArrayObj && index.Type() == object.NumberObj:
-		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.ArrayObj && index.Type() == object.IntegerObj:
+		return evalArrayIndexExpression(left.(*object.Array).Elements, index)
+	case left.Type() == object.TupleObj && index.Type() == object.IntegerObj:
+		return evalArrayIndexExpression(left.(*object.Tuple).Elements, index)
+	case left.Type() == object.BytesObj && index.Type() == object.IntegerObj:
+		return evalBytesIndexExpression(left, index)
+	case left.Type() == object.StringObj && index.Type() == object.IntegerObj:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.RangeObj && index.Type() == object.IntegerObj:
+		return evalRangeIndexExpression(left, index)
+	case index.Type() == object.RangeObj && left.Type() != object.HashObj:
+		slice, err := object.Slice(left, index.(*object.Range))
+		if err != nil {
+			return newError(line, "%s", err)
+		}
+		return slice
 	case left.Type() == object.HashObj:
 		return evalHashIndexExpression(line, left, index)
 	}
@@ -270,70 +606,238 @@ func evalIndexExpression(line int, left, index object.Object) object.Object {
 	return newError(line, "index operator not support: %s[%s]", left.Type(), index.Type())
 }
 
-func evalArrayIndexExpression(left, index object.Object) object.Object {
-	arr := left.(*object.Array)
-	ind := int(index.(*object.Number).Value)
+func evalArrayIndexExpression(els []object.Object, index object.Object) object.Object {
+	ind := int(index.(*object.Integer).Value)
+
+	if ind < 0 || ind >= len(els) {
+		return Null
+	}
+
+	return els[ind]
+}
+
+func evalStringIndexExpression(left, index object.Object) object.Object {
+	char, ok := left.(*object.String).At(index.(*object.Integer).Value)
+	if !ok {
+		return Null
+	}
+
+	return char
+}
+
+func evalBytesIndexExpression(left, index object.Object) object.Object {
+	b := left.(*object.Bytes).Value
+	ind := index.(*object.Integer).Value
+
+	if ind < 0 || ind >= int64(len(b)) {
+		return Null
+	}
+
+	return &object.Integer{Value: int64(b[ind])}
+}
+
+func evalRangeIndexExpression(left, index object.Object) object.Object {
+	r := left.(*object.Range)
+	ind := index.(*object.Integer).Value
 
-	if ind < 0 || ind >= len(arr.Elements) {
+	v, ok := r.At(ind)
+	if !ok {
 		return Null
 	}
 
-	return arr.Elements[ind]
+	return &object.Integer{Value: v}
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for k, v := range node.Pairs {
+	for _, k := range node.Keys {
 		key := Eval(k, env)
 		if isError(key) {
 			return key
 		}
 
-		hk, ok := key.(object.Hashable)
-		if !ok {
-			return newError(node.Token.Line, "unusable as hash key: %s", key.Type())
+		value := Eval(node.Pairs[k], env)
+		if isError(value) {
+			return value
+		}
+
+		if err := hash.Set(key, value); err != nil {
+			return newError(node.Token.Line, "%s", err)
 		}
+	}
+
+	return hash
+}
+
+func evalUnpack(node *ast.LetStatement, val object.Object, env *object.Environment) object.Object {
+	els, err := object.Unpack(val, len(node.Names))
+	if err != nil {
+		return newError(node.Token.Line, "%s", err)
+	}
+
+	for i, name := range node.Names {
+		env.Set(name.Value, els[i])
+	}
+	return Null
+}
+
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+	els := evalExpressions(node.Elements, env)
+	if len(els) == 1 && isError(els[0]) {
+		return els[0]
+	}
+
+	set := object.NewSet()
+	for _, el := range els {
+		if err := set.Add(el); err != nil {
+			return newError(node.Token.Line, "%s", err)
+		}
+	}
+
+	return set
+}
+
+func evalArrayComprehension(node *ast.ArrayComprehension, env *object.Environment) object.Object {
+	array := &object.Array{Elements: []object.Object{}}
+
+	err := evalComprehension(node.Clause, env, func(scope *object.Environment) object.Object {
+		el := evalComprehensionPart(node.Token.Line, node.Element, scope)
+		if isError(el) {
+			return el
+		}
+
+		array.Elements = append(array.Elements, el)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return array
+}
+
+func evalHashComprehension(node *ast.HashComprehension, env *object.Environment) object.Object {
+	hash := object.NewHash()
 
-		value := Eval(v, env)
+	err := evalComprehension(node.Clause, env, func(scope *object.Environment) object.Object {
+		key := evalComprehensionPart(node.Token.Line, node.Key, scope)
+		if isError(key) {
+			return key
+		}
+
+		value := evalComprehensionPart(node.Token.Line, node.Value, scope)
 		if isError(value) {
 			return value
 		}
 
-		hash := hk.HashKey()
-		pairs[hash] = object.HashPair{Key: key, Value: value}
+		if err := hash.Set(key, value); err != nil {
+			return newError(node.Token.Line, "%s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return hash
+}
+
+// evalComprehension calls body for each element of the clause's iterable which satisfies its
+// condition. Every iteration gets a fresh scope holding the loop variables so that closures
+// created in the body capture their own values. The first error encountered is returned.
+func evalComprehension(clause *ast.ComprehensionClause, env *object.Environment, body func(*object.Environment) object.Object) object.Object {
+	iterable := evalComprehensionPart(clause.Token.Line, clause.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	iter, err := object.NewIterator(iterable)
+	if err != nil {
+		return newError(clause.Token.Line, "%s", err)
+	}
+
+	for iter.Next() {
+		scope := object.NewEnclosedEnvironment(env)
+		if len(clause.Variables) == 1 {
+			scope.Set(clause.Variables[0].Value, iter.Item())
+		} else {
+			key, value := iter.Pair()
+			scope.Set(clause.Variables[0].Value, key)
+			scope.Set(clause.Variables[1].Value, value)
+		}
+
+		if clause.Condition != nil {
+			cond := evalComprehensionPart(clause.Token.Line, clause.Condition, scope)
+			if isError(cond) {
+				return cond
+			}
+			if !isTruthy(cond) {
+				continue
+			}
+		}
+
+		if res := body(scope); res != nil {
+			return res
+		}
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return nil
+}
+
+// evalComprehensionPart evaluates one expression of a comprehension. As in the compiler, a
+// `return` reaching the comprehension is an error rather than leaving the enclosing function.
+func evalComprehensionPart(line int, node ast.Expression, env *object.Environment) object.Object {
+	obj := Eval(node, env)
+	if _, ok := obj.(*object.ReturnValue); ok {
+		return newError(line, "cannot return from inside a comprehension")
+	}
+	return obj
 }
 
 func evalHashIndexExpression(line int, hash, index object.Object) object.Object {
 	hashObj := hash.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
-	if !ok {
-		return newError(line, "unusable as hash key: %s", index.Type())
+	pair, ok, err := hashObj.Get(index)
+	if err != nil {
+		return newError(line, "%s", err)
 	}
-
-	pair, ok := hashObj.Pairs[key.HashKey()]
 	if !ok {
 		return Null
 	}
 	return pair.Value
 }
 
-func applyFunction(line int, fn object.Object, args []object.Object) object.Object {
+func applyFunction(env *object.Environment, line int, fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newError(line, "wrong number of arguments: expected=%d, got=%d", len(fn.Parameters), len(args))
+		}
 		extEnv := extendFunctionEnv(fn, args)
 		evaluated := Eval(fn.Body, extEnv)
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
-		if result := fn.Fn(args...); result != nil {
+		var result object.Object
+		if fn.HostFn != nil {
+			call := func(callee object.Object, args ...object.Object) object.Object {
+				return applyFunction(env, line, callee, args)
+			}
+			result = fn.HostFn(&object.Host{Call: call, FS: env.FS(), Clock: env.Clock()}, args...)
+		} else {
+			result = fn.Fn(args...)
+		}
+		if result != nil {
 			return result
 		}
 
 		return Null
+	case object.Constructor:
+		inst, err := fn.New(args)
+		if err != nil {
+			return newError(line, "%s", err)
+		}
+		return inst
 	}
 
 	return newError(line, "not a function: %s", fn.Type())
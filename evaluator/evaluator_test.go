@@ -4,35 +4,57 @@ import (
 	"github.com/butlermatt/monkey/lexer"
 	"github.com/butlermatt/monkey/object"
 	"github.com/butlermatt/monkey/parser"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
-func TestEvalNumberExpression(t *testing.T) {
+func TestEvalIntegerExpression(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected float64
+		expected int64
 	}{
-		{"5", 5.0},
-		{"10.5", 10.5},
+		{"5", 5},
 		{"-5", -5},
-		{"-10.5", -10.5},
-		{"5 + 5.5 + 5.0 + 5 - 10.5", 10},
 		{"2 * 2 * 2 * 2 * 2", 32},
-		{"-50 + 100.5 + -50.5", 0},
 		{"5 * 2 + 10", 20},
 		{"5 + 2 * 10", 25},
-		{"21 + 2 * -10.5", 0},
 		{"50 / 2 * 2 + 10", 60},
 		{"2 * (5 + 10)", 30},
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 / 2", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+			testIntegerObject(t, evaluated, tt.expected)
+		})
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"10.5", 10.5},
+		{"-10.5", -10.5},
+		{"5 + 5.5 + 5.0 + 5 - 10.5", 10},
+		{"-50 + 100.5 + -50.5", 0},
+		{"21 + 2 * -10.5", 0},
+		{"7 / 2.0", 3.5},
+		{"2 * 2.0", 4},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
 			evaluated := testEval(tt.input)
-			testNumberObject(t, evaluated, tt.expected)
+			testFloatObject(t, evaluated, tt.expected)
 		})
 	}
 }
@@ -74,6 +96,66 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestEquality(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{"strings", `"a" == "a"`, true},
+		{"different strings", `"a" != "b"`, true},
+		{"nested arrays", "[1, [2, 3]] == [1, [2, 3]]", true},
+		{"array lengths", "[1, 2] == [1, 2, 3]", false},
+		{"array elements", "[1, 2] == [1, 3]", false},
+		{"mixed numbers", "[1, 2.0] == [1.0, 2]", true},
+		{"hashes", `{"a": [1]} == {"a": [1]}`, true},
+		{"hash values", `{"a": 1} == {"a": 2}`, false},
+		{"hash keys", `{"a": 1} != {"b": 1}`, true},
+		{"ranges", "1..4 == 1..=3", true},
+		{"empty ranges", "0..0 == 5..5", true},
+		{"different types", `1 == "1"`, false},
+		{"array and string", `[1] != "a"`, true},
+		{"same function", "let f = fn() { 1 }; f == f", true},
+		{"different functions", "fn() { 1 } == fn() { 1 }", false},
+		{"instances with arrays", "struct P { x }; P([1]) == P([1])", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testBooleanObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{"strings less", `"a" < "b"`, true},
+		{"strings differ late", `"abc" < "abd"`, true},
+		{"string prefix", `"ab" < "abc"`, true},
+		{"string first byte wins", `"b" >= "abc"`, true},
+		{"upper before lower", `"Z" < "a"`, true},
+		{"string less equal", `"a" <= "a"`, true},
+		{"string greater", `"a" > "a"`, false},
+		{"arrays", "[1, 2] < [1, 3]", true},
+		{"array prefix", "[1, 2] < [1, 2, 0]", true},
+		{"array first element wins", "[2] > [1, 9]", true},
+		{"empty arrays", "[] <= []", true},
+		{"arrays of strings", `[1, "b"] > [1, "a"]`, true},
+		{"nested arrays", "[[1, 2]] < [[1, 3]]", true},
+		{"mixed numbers", "[1.5] < [2]", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testBooleanObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
 func TestBangOperator(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -102,21 +184,21 @@ func TestIfElseExpressions(t *testing.T) {
 		input    string
 		expected interface{}
 	}{
-		{"if true", "if (true) { 10 }", 10.0},
+		{"if true", "if (true) { 10 }", 10},
 		{"if false", "if (false) { 10 }", nil},
-		{"if one", "if (1) { 10 }", 10.0},
-		{"if 1 lte 2", "if (1 <= 2) { 10 }", 10.0},
+		{"if one", "if (1) { 10 }", 10},
+		{"if 1 lte 2", "if (1 <= 2) { 10 }", 10},
 		{"if 1 gt 2", "if (1 > 2) { 10 }", nil},
-		{"if else 1 gt 2", "if (1 > 2) { 10 } else { 20 }", 20.0},
-		{"if else 1 lte 2", "if (1 <= 2) { 10 } else { 20 }", 10.0},
+		{"if else 1 gt 2", "if (1 > 2) { 10 } else { 20 }", 20},
+		{"if else 1 lte 2", "if (1 <= 2) { 10 } else { 20 }", 10},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			evaled := testEval(tt.input)
-			num, ok := tt.expected.(float64)
+			num, ok := tt.expected.(int)
 			if ok {
-				testNumberObject(t, evaled, num)
+				testIntegerObject(t, evaled, int64(num))
 			} else {
 				testNullObject(t, evaled)
 			}
@@ -128,19 +210,19 @@ func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
-		expected float64
+		expected int64
 	}{
-		{"return ten", "return 10;", 10.0},
-		{"return ten ignore", "return 10; 9;", 10.0},
-		{"return expression", "return 2 * 5; 9;", 10.0},
-		{"return expression ignore", "9; return 2 * 5; 9;", 10.0},
-		{"nested return", "if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10.0},
+		{"return ten", "return 10;", 10},
+		{"return ten ignore", "return 10; 9;", 10},
+		{"return expression", "return 2 * 5; 9;", 10},
+		{"return expression ignore", "9; return 2 * 5; 9;", 10},
+		{"nested return", "if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			evaluated := testEval(tt.input)
-			testNumberObject(t, evaluated, tt.expected)
+			testIntegerObject(t, evaluated, tt.expected)
 		})
 	}
 }
@@ -151,8 +233,8 @@ func TestErrorHandling(t *testing.T) {
 		input    string
 		expected string
 	}{
-		{"5 plus true", "5 + true;", "on line 1 - type mismatch: NUMBER + BOOLEAN"},
-		{"5 plus true ignore", "5 + true; 5;", "on line 1 - type mismatch: NUMBER + BOOLEAN"},
+		{"5 plus true", "5 + true;", "on line 1 - type mismatch: INTEGER + BOOLEAN"},
+		{"5 plus true ignore", "5 + true; 5;", "on line 1 - type mismatch: INTEGER + BOOLEAN"},
 		{"negative bool", "-true;", "on line 1 - unknown operator: -BOOLEAN"},
 		{"true plus true", "true + true;", "on line 1 - unknown operator: BOOLEAN + BOOLEAN"},
 		{"true plus true ignore", "5; true + false; 5;", "on line 1 - unknown operator: BOOLEAN + BOOLEAN"},
@@ -170,6 +252,27 @@ if (10 > 1) {
 		{"unbound variable", "foobar;", "on line 1 - identifier not found: foobar"},
 		{"minus string", `"Hello" - "World";`, "on line 1 - unknown operator: STRING - STRING"},
 		{"invalid hashkey", `{"name": "Monkey"}[fn(x){x}];`, "on line 1 - unusable as hash key: FUNCTION"},
+		{"unhashable element", "{[1, fn() {}]: 1}", "on line 1 - unusable as hash key: FUNCTION"},
+		{"division by zero", "1 / 0", "on line 1 - division by zero"},
+		{"mismatched elements", `[1] > ["a"]`, "on line 1 - cannot compare INTEGER with STRING"},
+		{"unordered elements", "[true] > [false]", "on line 1 - cannot compare BOOLEAN with BOOLEAN"},
+		{"less than keeps operand order", `[1, "a"] < [1, 2]`, "on line 1 - cannot compare STRING with INTEGER"},
+		{"less equal keeps operand order", `["a"] <= [1]`, "on line 1 - cannot compare STRING with INTEGER"},
+		{"mismatched less than", `"a" < 1`, "on line 1 - type mismatch: STRING < INTEGER"},
+		{"mismatched greater equal", `1 >= "a"`, "on line 1 - type mismatch: INTEGER >= STRING"},
+		{"unordered less equal", "true <= false", "on line 1 - unknown operator: BOOLEAN <= BOOLEAN"},
+		{"float range", "1..2.5", "on line 1 - range bounds must be INTEGER, got FLOAT"},
+		{"zero step range", "1..2 step 0", "on line 1 - range step cannot be zero"},
+		{"bad membership", "1 in 2", "on line 1 - unknown operator: INTEGER in INTEGER"},
+		{"iterate number", "[x for x in 5]", "on line 1 - cannot iterate over INTEGER"},
+		{"comprehension leak", "[x for x in [1]]; x", "on line 1 - identifier not found: x"},
+		{"comprehension return", "let f = fn(xs) { [if (x > 1) { return x } else { x } for x in xs] }; f([1, 2])", "on line 1 - cannot return from inside a comprehension"},
+		{"comprehension condition return", "{x: x for x in [1] if if (x) { return true } else { true }}", "on line 1 - cannot return from inside a comprehension"},
+		{"unknown field", "struct Point { x, y }; Point(1, 2).z", "on line 1 - struct Point has no field z"},
+		{"struct arity", "struct Point { x, y }; Point(1)", "on line 1 - wrong number of arguments: expected=2, got=1"},
+		{"field on number", "let a = 5; a.x", "on line 1 - field access not supported: INTEGER.x"},
+		{"not a variant", "let f = fn(x) { x }; match (1) { f(a) => a }", "on line 1 - not an enum variant: FUNCTION"},
+		{"wrong bindings", "enum E { A(x) }; match (A(1)) { A(x, y) => x }", "on line 1 - wrong number of bindings for A: expected=1, got=2"},
 	}
 
 	for _, tt := range tests {
@@ -191,17 +294,17 @@ func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
-		expected float64
+		expected int64
 	}{
-		{"simple assignment", "let a = 5; a;", 5.0},
-		{"expression assignment", "let a = 5 * 5; a;", 25.0},
-		{"evaluated assignment", "let a = 5; let b = a; b;", 5.0},
-		{"complex assignment", "let a = 5; let b = a; let c = a + b + 5; c;", 15.0},
+		{"simple assignment", "let a = 5; a;", 5},
+		{"expression assignment", "let a = 5 * 5; a;", 25},
+		{"evaluated assignment", "let a = 5; let b = a; b;", 5},
+		{"complex assignment", "let a = 5; let b = a; let c = a + b + 5; c;", 15},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testNumberObject(t, testEval(tt.input), tt.expected)
+			testIntegerObject(t, testEval(tt.input), tt.expected)
 		})
 	}
 }
@@ -233,19 +336,19 @@ func TestFunctionApplication(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
-		expected float64
+		expected int64
 	}{
-		{"explicit return", "let ident = fn(x) { return x; }; ident(5);", 5.0},
-		{"implicit return", "let ident = fn(x) { x; }; ident(5);", 5.0},
-		{"double", "let double = fn(x) { x * 2; }; double(5);", 10.0},
-		{"add", "let add = fn(x, y) { x + y; }; add(5, 5);", 10.0},
-		{"recursive add", "let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20.0},
+		{"explicit return", "let ident = fn(x) { return x; }; ident(5);", 5},
+		{"implicit return", "let ident = fn(x) { x; }; ident(5);", 5},
+		{"double", "let double = fn(x) { x * 2; }; double(5);", 10},
+		{"add", "let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"recursive add", "let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
 		{"anonymous", "fn(x) { x; }(5);", 5},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			testNumberObject(t, testEval(tt.input), tt.expected)
+			testIntegerObject(t, testEval(tt.input), tt.expected)
 		})
 	}
 }
@@ -259,7 +362,7 @@ let newAdder = fn(x) {
 let addTwo = newAdder(2);
 addTwo(2);`
 
-	testNumberObject(t, testEval(input), 4)
+	testIntegerObject(t, testEval(input), 4)
 }
 
 func TestStringLiteral(t *testing.T) {
@@ -296,11 +399,12 @@ func TestBuiltinFunctions(t *testing.T) {
 		input    string
 		expected interface{}
 	}{
-		{"len-empty-string", `len("");`, 0.0},
-		{"len-four", `len("four");`, 4.0},
-		{"len-hello-world", `len("Hello world");`, 11.0},
-		{"len-1", `len(1);`, "argument to `len` not supported, got NUMBER"},
+		{"len-empty-string", `len("");`, 0},
+		{"len-four", `len("four");`, 4},
+		{"len-hello-world", `len("Hello world");`, 11},
+		{"len-1", `len(1);`, "argument to `len` not supported, got INTEGER"},
 		{"len-one-two", `len("one", "two");`, "wrong number of arguments. expected=1, got=2"},
+		{"read_file-without-file-access", `read_file("a.txt");`, "`read_file` is unavailable: file access is not enabled"},
 	}
 
 	for _, tt := range tests {
@@ -308,10 +412,8 @@ func TestBuiltinFunctions(t *testing.T) {
 			evaluated := testEval(tt.input)
 
 			switch expected := tt.expected.(type) {
-			case float64:
-				testNumberObject(t, evaluated, expected)
-			case float32:
-				testNumberObject(t, evaluated, float64(expected))
+			case int:
+				testIntegerObject(t, evaluated, int64(expected))
 			case string:
 				errObj, ok := evaluated.(*object.Error)
 				if !ok {
@@ -325,6 +427,196 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"add overflow", "9223372036854775807 + 1", bigInt("9223372036854775808")},
+		{"sub overflow", "-9223372036854775807 - 2", bigInt("-9223372036854775809")},
+		{"mul overflow", "4294967296 * 4294967296", bigInt("18446744073709551616")},
+		{"literal", "123456789012345678901234567890", bigInt("123456789012345678901234567890")},
+		{"demoted", "9223372036854775808 - 1", 9223372036854775807},
+		{"negate min", "-(-9223372036854775807 - 1)", bigInt("9223372036854775808")},
+		{"divide", "100000000000000000000 / 10", bigInt("10000000000000000000")},
+		{"divide min", "(-9223372036854775807 - 1) / -1", bigInt("9223372036854775808")},
+		{"greater", "100000000000000000000 > 9223372036854775807", true},
+		{"equal", "100000000000000000000 == 10000000000000000000 * 10", true},
+		{"compare float", "100000000000000000000 > 1.5", true},
+		{"add float", "100000000000000000000 + 0.5", 1e20},
+		{"hash key", "{100000000000000000000: 1}[10000000000000000000 * 10]", 1},
+		{"int from string", `int("123456789012345678901234567890")`, bigInt("123456789012345678901234567890")},
+		{"int from float", "int(-2.9)", -2},
+		{"int from huge float", "int(1.0 * 100000000000000000000)", bigInt("100000000000000000000")},
+		{"float from big", "float(100000000000000000000)", 1e20},
+		{"float from string", `float("2.5")`, 2.5},
+		{"str from big", "str(100000000000000000000)", "100000000000000000000"},
+		{"int bad string", `int("abc")`, errorMessage(`could not parse "abc" as integer`)},
+		{"int of array", `int([])`, errorMessage("argument to `int` not supported, got ARRAY")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+
+			switch expected := tt.expected.(type) {
+			case int:
+				testIntegerObject(t, evaluated, int64(expected))
+			case float64:
+				testFloatObject(t, evaluated, expected)
+			case bool:
+				testBooleanObject(t, evaluated, expected)
+			case *big.Int:
+				result, ok := evaluated.(*object.BigInt)
+				if !ok {
+					t.Fatalf("object wrong type. expected=*object.BigInt, got=%T (%+[1]v)", evaluated)
+				}
+				if result.Value.Cmp(expected) != 0 {
+					t.Errorf("object has wrong value. expected=%s, got=%s", expected, result.Value)
+				}
+			case string:
+				result, ok := evaluated.(*object.String)
+				if !ok {
+					t.Fatalf("object wrong type. expected=*object.String, got=%T (%+[1]v)", evaluated)
+				}
+				if result.Value != expected {
+					t.Errorf("wrong string. expected=%q, got=%q", expected, result.Value)
+				}
+			case errorMessage:
+				errObj, ok := evaluated.(*object.Error)
+				if !ok {
+					t.Fatalf("object incorrect type. expected=*object.Error got=%T (%+[1]v)", evaluated)
+				}
+				if errObj.Message != string(expected) {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+			}
+		})
+	}
+}
+
+type errorMessage string
+
+func bigInt(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
+
+func TestDecimals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"sum", "0.1d + 0.2d", decimal("0.3")},
+		{"keeps scale", "1.10d", decimal("1.10")},
+		{"times integer", "1.10d * 2", decimal("2.20")},
+		{"times decimal", "1.10d * 1.10d", decimal("1.2100")},
+		{"integer minus", "5 - 5.25d", decimal("-0.25")},
+		{"negate", "-0.05d", decimal("-0.05")},
+		{"exact division", "10d / 4", decimal("2.5")},
+		{"division keeps scale", "10.00d / 4", decimal("2.50")},
+		{"repeating division", "1d / 3", decimal("0.3333333333333333")},
+		{"rounded division", "2.00d / 3", decimal("0.6666666666666667")},
+		{"equal scales differ", "1.10d == 1.1d", true},
+		{"equal integer", "1.0d == 1", true},
+		{"exact sum", "0.1d + 0.2d == 0.3d", true},
+		{"greater", "1.5d > 1", true},
+		{"less equal", "1.5d <= 1.49d", false},
+		{"hash key", "{1.10d: 1}[1.1d]", 1},
+		{"whole hash key", "{1: 5}[1.0d]", 5},
+		{"from string", `decimal("12.345")`, decimal("12.345")},
+		{"from float", "decimal(0.1)", decimal("0.1")},
+		{"rescale half even", "rescale(2.345d, 2)", decimal("2.34")},
+		{"rescale half up", `rescale(2.345d, 2, "half_up")`, decimal("2.35")},
+		{"rescale floor", `rescale(-2.341d, 2, "floor")`, decimal("-2.35")},
+		{"rescale widen", "rescale(1.5d, 3)", decimal("1.500")},
+		{"rescale largest", "len(str(rescale(1d, 1000)))", 1002},
+		{"rescale too large", "rescale(1d, 100000000)", errorMessage("scale for `rescale` out of range: 100000000")},
+		{"rescale max int32", "rescale(1.0d, 2147483647)", errorMessage("scale for `rescale` out of range: 2147483647")},
+		{"multiply largest scale", "len(str(rescale(1d, 500) * rescale(1d, 500)))", 1002},
+		{"int", "int(-2.9d)", -2},
+		{"float", "float(2.5d)", 2.5},
+		{"str", "str(1.10d)", "1.10"},
+		{"bad string", `decimal("1.")`, errorMessage(`could not parse "1." as decimal`)},
+		{"bad mode", `rescale(1d, 2, "sideways")`, errorMessage("unknown rounding mode: sideways")},
+		{"mixed with float", "1.5d + 1.5", errorMessage("on line 1 - type mismatch: DECIMAL + FLOAT")},
+		{"division by zero", "1d / 0", errorMessage("on line 1 - division by zero")},
+		{"multiplied scale too large", "rescale(1d, 600) * rescale(1d, 600)", errorMessage("on line 1 - decimal scale is larger than 1000")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+
+			switch expected := tt.expected.(type) {
+			case int:
+				testIntegerObject(t, evaluated, int64(expected))
+			case float64:
+				testFloatObject(t, evaluated, expected)
+			case bool:
+				testBooleanObject(t, evaluated, expected)
+			case decimal:
+				result, ok := evaluated.(*object.Decimal)
+				if !ok {
+					t.Fatalf("object wrong type. expected=*object.Decimal, got=%T (%+[1]v)", evaluated)
+				}
+				if result.Inspect() != string(expected) {
+					t.Errorf("object has wrong value. expected=%s, got=%s", expected, result.Inspect())
+				}
+			case string:
+				result, ok := evaluated.(*object.String)
+				if !ok {
+					t.Fatalf("object wrong type. expected=*object.String, got=%T (%+[1]v)", evaluated)
+				}
+				if result.Value != expected {
+					t.Errorf("wrong string. expected=%q, got=%q", expected, result.Value)
+				}
+			case errorMessage:
+				errObj, ok := evaluated.(*object.Error)
+				if !ok {
+					t.Fatalf("object incorrect type. expected=*object.Error got=%T (%+[1]v)", evaluated)
+				}
+				if errObj.Message != string(expected) {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+			}
+		})
+	}
+}
+
+// decimal is the expected Inspect output of a DECIMAL.
+type decimal string
+
+func TestDecimalContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      object.DecimalContext
+		input    string
+		expected decimal
+	}{
+		{"short scale", object.DecimalContext{DivisionScale: 2, Rounding: object.RoundHalfEven}, "2d / 3", "0.67"},
+		{"rounding mode", object.DecimalContext{DivisionScale: 2, Rounding: object.RoundDown}, "2d / 3", "0.66"},
+		{"operand scale wins", object.DecimalContext{DivisionScale: 1, Rounding: object.RoundHalfEven}, "2.000d / 3", "0.667"},
+		{"inside function", object.DecimalContext{DivisionScale: 2, Rounding: object.RoundUp}, "let f = fn(x) { x / 3 }; f(1d)", "0.34"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program := parser.New(lexer.New(tt.input)).ParseProgram()
+			env := object.NewEnvironment()
+			env.SetDecimalContext(tt.ctx)
+			testExpectedObject(t, Eval(program, env), tt.expected)
+
+			// Other environments keep the default context.
+			if got := object.NewEnvironment().DecimalContext(); got != object.DefaultDecimalContext() {
+				t.Errorf("default context changed. got=%+v", got)
+			}
+		})
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3];"
 
@@ -338,9 +630,9 @@ func TestArrayLiterals(t *testing.T) {
 		t.Fatalf("array has wrong number of elements. expected=%d, got=%d", 3, len(result.Elements))
 	}
 
-	testNumberObject(t, result.Elements[0], 1)
-	testNumberObject(t, result.Elements[1], 4)
-	testNumberObject(t, result.Elements[2], 6)
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
 }
 
 func TestArrayIndexExpression(t *testing.T) {
@@ -349,14 +641,14 @@ func TestArrayIndexExpression(t *testing.T) {
 		input    string
 		expected interface{}
 	}{
-		{"zero index", "[1, 2, 3][0]", 1.0},
-		{"one index", "[1, 2, 3][1]", 2.0},
-		{"two index", "[1, 2, 3][2]", 3.0},
-		{"identifier index", "let i = 0; [1, 2][i]", 1.0},
-		{"expression index", "[1, 2, 3][1 + 1]", 3.0},
-		{"identifier array", "let myArray = [1, 2, 3]; myArray[2];", 3.0},
-		{"identifier expressions", "let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6.0},
-		{"nesting identifiers", "let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i];", 2.0},
+		{"zero index", "[1, 2, 3][0]", 1},
+		{"one index", "[1, 2, 3][1]", 2},
+		{"two index", "[1, 2, 3][2]", 3},
+		{"identifier index", "let i = 0; [1, 2][i]", 1},
+		{"expression index", "[1, 2, 3][1 + 1]", 3},
+		{"identifier array", "let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"identifier expressions", "let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"nesting identifiers", "let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i];", 2},
 		{"out of bounds", "[1, 2, 3][3]", nil},
 		{"negative", "[1, 2, 3][-1]", nil},
 	}
@@ -364,11 +656,11 @@ func TestArrayIndexExpression(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			evaluated := testEval(tt.input)
-			value, ok := tt.expected.(float64)
+			value, ok := tt.expected.(int)
 			if !ok {
 				testNullObject(t, evaluated)
 			} else {
-				testNumberObject(t, evaluated, value)
+				testIntegerObject(t, evaluated, int64(value))
 			}
 		})
 	}
@@ -392,27 +684,675 @@ func TestHashLiterals(t *testing.T) {
 		t.Fatalf("eval return wrong object type. expected=*object.Hash, got=%T (%+[1]v)", evaluated)
 	}
 
-	expected := map[object.HashKey]float64{
+	expected := map[object.HashKey]int64{
 		(&object.String{Value: "one"}).HashKey():   1,
 		(&object.String{Value: "two"}).HashKey():   2,
 		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Number{Value: 4}).HashKey():       4,
+		(&object.Integer{Value: 4}).HashKey():      4,
 		True.HashKey():                             5,
 		False.HashKey():                            6,
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong number of pairs. expected=%d, got=%d", len(expected), len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong number of pairs. expected=%d, got=%d", len(expected), result.Len())
 	}
 
+	pairs := hashPairs(t, result)
 	for exKey, exVal := range expected {
-		pair, ok := result.Pairs[exKey]
+		pair, ok := pairs[exKey]
 		if !ok {
 			t.Errorf("hash has no pair for the key %d", exKey.Value)
 			continue
 		}
 
-		testNumberObject(t, pair.Value, exVal)
+		testIntegerObject(t, pair.Value, exVal)
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"literal order", `str({"b": 1, "a": 2, 3: 4})`, "{b: 1, a: 2, 3: 4}"},
+		{"repeated key keeps position", `str({"a": 1, "b": 2, "a": 3})`, "{a: 3, b: 2}"},
+		{"iteration order", `str([k for k in {"z": 1, "y": 2, "x": 3}])`, "[z, y, x]"},
+		{"comprehension order", "str({x: x * x for x in [3, 1, 2]})", "{3: 9, 1: 1, 2: 4}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Fatalf("object is wrong type. expected=*object.String, got=%T (%+[1]v)", evaluated)
+			}
+
+			if str.Value != tt.expected {
+				t.Errorf("wrong order. expected=%q, got=%q", tt.expected, str.Value)
+			}
+		})
+	}
+}
+
+func TestCompositeHashKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"array key", `{[1, 2]: "a"}[[1, 2]]`, "a"},
+		{"nested array key", "{[1, [2, 3]]: 5}[[1, [2, 3]]]", 5},
+		{"element order matters", "{[1, 2]: 1, [2, 1]: 2}[[2, 1]]", 2},
+		{"missing array key", "{[1, 2]: 1}[[1, 2, 3]]", nil},
+		{"numbers compare numerically", "{[1]: 1}[[1.0]]", 1},
+		{"hash key", `{{"a": 1, "b": 2}: 3}[{"b": 2, "a": 1}]`, 3},
+		{"repeated array key", "{[1, 2]: 1, [1, 2]: 2}[[1, 2]]", 2},
+		{"array key in hash", "[1, 2] in {[1, 2]: true}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestSets(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"literal", "str({1, 2, 2, 3})", "{1, 2, 3}"},
+		{"empty", "str(set())", "set()"},
+		{"from array", "str(set([3, 1, 3]))", "{3, 1}"},
+		{"from hash", `str(set({"a": 1, "b": 2}))`, "{a, b}"},
+		{"len", "len({1, 2, 1.0})", 2},
+		{"in", "2 in {1, 2}", true},
+		{"has", "has({1, 2}, 3)", false},
+		{"has in condition", "if (has({1}, 2)) { 1 } else { 2 }", 2},
+		{"add", "str(add({1}, 2))", "{1, 2}"},
+		{"add copies", "let s = {1}; add(s, 2); len(s)", 1},
+		{"remove", "str(remove({1, 2, 3}, 2))", "{1, 3}"},
+		{"remove one argument", "remove({1})", errorMessage("wrong number of arguments. expected=2, got=1")},
+		{"remove three arguments", "remove({1}, 1, 2)", errorMessage("wrong number of arguments. expected=2, got=3")},
+		{"remove from string", `remove("a.txt", 1)`, errorMessage("argument to `remove` must be a SET, got STRING")},
+		{"union", "str(union({1, 2}, {2, 3}))", "{1, 2, 3}"},
+		{"intersection", "str(intersection({1, 2, 3}, {3, 2}))", "{2, 3}"},
+		{"difference", "str(difference({1, 2, 3}, {2}))", "{1, 3}"},
+		{"subset", "subset({1, 2}, {2, 1, 3})", true},
+		{"not subset", "subset({1, 4}, {1, 2})", false},
+		{"equal", "{1, 2} == {2, 1}", true},
+		{"set key", `{{1, 2}: "a"}[{2, 1}]`, "a"},
+		{"array elements", "len({[1, 2], [1, 2]})", 1},
+		{"iterate", "str([x * 2 for x in {1, 2, 3}])", "[2, 4, 6]"},
+		{"add to array", "add([1], 2)", errorMessage("argument to `add` must be a SET, got ARRAY")},
+		{"union with array", "union({1}, [1])", errorMessage("second argument to `union` must be a SET, got ARRAY")},
+		{"unhashable element", "{1, fn() {}}", errorMessage("on line 1 - unusable as hash key: FUNCTION")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestTuples(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"inspect", `str((1, "a"))`, "(1, a)"},
+		{"single", "str((1,))", "(1,)"},
+		{"empty", "str(())", "()"},
+		{"grouping", "(1 + 2) * 2", 6},
+		{"index", "(1, 2)[1]", 2},
+		{"len", "len((1, 2, 3))", 3},
+		{"equal", "(1, 2) == (1, 2)", true},
+		{"not an array", "(1, 2) == [1, 2]", false},
+		{"ordering", "(1, 2) < (1, 3)", true},
+		{"hash key", `{(1, 2): "a"}[(1, 2)]`, "a"},
+		{"in", "2 in (1, 2)", true},
+		{"iterate", "str([x for x in (1, 2)])", "[1, 2]"},
+		{"return pair", "let f = fn() { return 1, 2; }; let a, b = f(); a * 10 + b", 12},
+		{"local unpack", "let f = fn(x) { let q, r = (x / 3, x - x / 3 * 3); q * 10 + r }; f(7)", 21},
+		{"unpack array", "let a, b = [3, 4]; a + b", 7},
+		{"unpack too many", "let a, b = (1, 2, 3);", errorMessage("on line 1 - wrong number of values to unpack. expected=2, got=3")},
+		{"unpack integer", "let a, b = 1;", errorMessage("on line 1 - cannot unpack INTEGER")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"literal", `b"ab\x00\xff"`, bytesValue("ab\x00\xff")},
+		{"inspect", `str(b"a\"\n\x7f")`, `b"a\"\x0a\x7f"`},
+		{"index", `b"\x00\xff"[1]`, 255},
+		{"index out of range", `b"a"[1]`, nil},
+		{"slice", `b"hello"[1..3]`, bytesValue("el")},
+		{"concat", `b"ab" + b"\x00"`, bytesValue("ab\x00")},
+		{"len", `len(b"\x00\x01\x02")`, 3},
+		{"equal", `b"ab" == bytes("ab")`, true},
+		{"not a string", `b"ab" == "ab"`, false},
+		{"ordering", `b"ab" < b"b"`, true},
+		{"hash key", `{b"k": 1}[b"k"]`, 1},
+		{"iterate", `str([x for x in b"AB"])`, "[65, 66]"},
+		{"from array", "bytes([104, 105])", bytesValue("hi")},
+		{"encode utf-8", `len(bytes("é"))`, 2},
+		{"encode latin-1", `bytes("é", "latin-1")`, bytesValue("\xe9")},
+		{"decode", `decode(b"hi\xc3\xa9")`, "hié"},
+		{"decode latin-1", `decode(b"\xe9", "latin-1")`, "é"},
+		{"read uint8", `read_uint(b"\xff", 0, 1)`, 255},
+		{"read int8", `read_int(b"\xff", 0, 1)`, -1},
+		{"read big endian", `read_uint(b"\x01\x02", 0, 2)`, 258},
+		{"read little endian", `read_uint(b"\x01\x02", 0, 2, "little")`, 513},
+		{"read int32", `read_int(b"\x00\xff\xff\xff\xfe", 1, 4)`, -2},
+		{"read uint64", `read_uint(b"\xff\xff\xff\xff\xff\xff\xff\xff", 0, 8)`, bigInt("18446744073709551615")},
+		{"invalid utf-8", `decode(b"\xff")`, errorMessage(`bytes are not valid utf-8`)},
+		{"invalid ascii", `decode(b"\xe9", "ascii")`, errorMessage(`bytes are not valid ascii`)},
+		{"unencodable", `bytes("é", "ascii")`, errorMessage(`cannot encode 'é' as ascii`)},
+		{"unknown encoding", `bytes("a", "ebcdic")`, errorMessage(`unknown encoding: ebcdic`)},
+		{"bad byte", `bytes([256])`, errorMessage(`byte values must be INTEGER between 0 and 255, got 256`)},
+		{"short read", `read_int(b"\x00", 0, 2)`, errorMessage(`cannot read 2 bytes at offset 0 from 1 bytes`)},
+		{"huge offset", `read_int(b"abcdefgh", 9223372036854775807, 8, "big")`, errorMessage(`cannot read 8 bytes at offset 9223372036854775807 from 8 bytes`)},
+		{"offset near max", `read_uint(b"abcdefgh", 9223372036854775800, 8)`, errorMessage(`cannot read 8 bytes at offset 9223372036854775800 from 8 bytes`)},
+		{"bad width", `read_int(b"\x00\x00\x00", 0, 3)`, errorMessage(`unsupported integer width: 3`)},
+		{"bad order", `read_int(b"\x00", 0, 1, "middle")`, errorMessage(`unknown byte order: middle`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+// bytesValue is the expected contents of a BYTES result.
+type bytesValue string
+
+func TestStringCharacters(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"index", `"héllo"[1]`, "é"},
+		{"index past end", `"abc"[3]`, nil},
+		{"negative index", `"abc"[-1]`, nil},
+		{"len", `len("héllo")`, 5},
+		{"slice", `"héllo"[1..3]`, "él"},
+		{"iterate", `str([c for c in "hé!"])`, "[h, é, !]"},
+		{"wide characters", `"日本"[1] == "本"`, true},
+		{"ord", `ord("é")`, 233},
+		{"chr", "chr(233)", "é"},
+		{"round trip", `chr(ord("a") + 1)`, "b"},
+		{"ord of several characters", `ord("ab")`, errorMessage("argument to `ord` must be a single character, got \"ab\"")},
+		{"ord of empty string", `ord("")`, errorMessage("argument to `ord` must be a single character, got \"\"")},
+		{"negative code point", `chr(-1)`, errorMessage(`invalid code point: -1`)},
+		{"surrogate", `chr(55296)`, errorMessage(`invalid code point: 55296`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"split", "str(split(\"a,b,,c\", \",\"))", "[a, b, , c]"},
+		{"split on whitespace", "str(split(\"  a b\tc \"))", "[a, b, c]"},
+		{"join", "join([\"a\", \"b\"], \"-\")", "a-b"},
+		{"join empty", "join([])", ""},
+		{"trim", "trim(\"  hi \n\")", "hi"},
+		{"trim characters", "trim(\"xxhixx\", \"x\")", "hi"},
+		{"trim_left", "trim_left(\"  hi \")", "hi "},
+		{"trim_right", "trim_right(\"  hi \")", "  hi"},
+		{"replace", "replace(\"aaa\", \"a\", \"b\")", "bbb"},
+		{"replace count", "replace(\"aaa\", \"a\", \"b\", 2)", "bba"},
+		{"contains", "contains(\"hello\", \"ell\")", true},
+		{"starts_with", "starts_with(\"hello\", \"he\")", true},
+		{"ends_with", "ends_with(\"hello\", \"he\")", false},
+		{"index_of", "index_of(\"héllo\", \"l\")", 2},
+		{"index_of missing", "index_of(\"hello\", \"z\")", -1},
+		{"upper", "upper(\"héllo\")", "HÉLLO"},
+		{"lower", "lower(\"ABC\")", "abc"},
+		{"repeat", "repeat(\"ab\", 3)", "ababab"},
+		{"pad_left", "pad_left(\"7\", 3, \"0\")", "007"},
+		{"pad_right", "pad_right(\"é\", 3)", "é  "},
+		{"pad shorter width", "pad_left(\"long\", 2)", "long"},
+		{"split non-string", "split(1)", errorMessage("first argument to `split` must be a STRING, got INTEGER")},
+		{"join non-string", "join([\"a\", 1])", errorMessage("`join` expects an ARRAY of STRING, found INTEGER")},
+		{"replace arguments", "replace(\"a\", \"b\")", errorMessage("wrong number of arguments. expected=3 or 4, got=2")},
+		{"negative repeat", "repeat(\"a\", -1)", errorMessage("count given to `repeat` must not be negative, got -1")},
+		{"huge repeat", "repeat(\"ab\", 9999999999999)", errorMessage("result of `repeat` is longer than 67108864 bytes")},
+		{"repeat empty", "repeat(\"\", 9999999999999)", ""},
+		{"huge pad", "pad_right(\"a\", 9999999999999, \"é\")", errorMessage("result of `pad_right` is longer than 67108864 bytes")},
+		{"long padding", "pad_left(\"a\", 3, \"ab\")", errorMessage("padding given to `pad_left` must be a single character, got \"ab\"")},
+		{"contains non-string", "contains(\"a\", 1)", errorMessage("second argument to `contains` must be a STRING, got INTEGER")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"abs", "math.abs(-5)", 5},
+		{"abs float", "math.abs(-2.5)", 2.5},
+		{"abs min integer", "math.abs(-9223372036854775807 - 1)", bigInt("9223372036854775808")},
+		{"abs decimal", "math.abs(-1.50d)", decimal("1.50")},
+		{"floor", "math.floor(2.7)", 2},
+		{"floor negative", "math.floor(-2.5)", -3},
+		{"ceil", "math.ceil(2.1)", 3},
+		{"ceil decimal", "math.ceil(-1.5d)", -1},
+		{"round half up", "math.round(2.5)", 3},
+		{"round half away from zero", "math.round(-2.5)", -3},
+		{"round digits", "math.round(3.14159, 2)", 3.14},
+		{"round decimal digits", "math.round(1.005d, 2)", decimal("1.01")},
+		{"round negative digits", "math.round(1234.5, -2)", 1200.0},
+		{"round many digits", "math.round(1.255, 400)", 1.255},
+		{"round large float", "math.round(math.pow(10.0, 300), 20) == math.pow(10.0, 300)", true},
+		{"round to nothing", "math.round(1234.5, -400)", 0.0},
+		{"round digits truncated", "math.round(1.25d, 4294967297)", errorMessage("digits for `math.round` out of range: 4294967297")},
+		{"round decimal too fine", "math.round(1d, 100000000)", errorMessage("digits for `math.round` out of range: 100000000")},
+		{"sqrt", "math.sqrt(16)", 4.0},
+		{"pow", "math.pow(2, 10)", 1024},
+		{"pow big", "math.pow(2, 100)", bigInt("1267650600228229401496703205376")},
+		{"pow decimal", "math.pow(1.5d, 2)", decimal("2.25")},
+		{"pow fraction", "math.pow(4, 0.5)", 2.0},
+		{"pow negative", "math.pow(2, -1)", 0.5},
+		{"pow largest", "math.pow(2, 1048575) > 0", true},
+		{"pow too large", "math.pow(10, 100000000)", errorMessage("exponent given to `math.pow` is too large, got 100000000")},
+		{"pow huge exponent", "math.pow(2, 100000000000000000000)", errorMessage("exponent given to `math.pow` is too large, got 100000000000000000000")},
+		{"pow of one", "math.pow(-1, 100000000001)", -1},
+		{"pow decimal too large", "math.pow(1.5d, 100000000)", errorMessage("exponent given to `math.pow` is too large, got 100000000")},
+		{"pow decimal too fine", "math.pow(0.01d, 4611686018427387904)", errorMessage("exponent given to `math.pow` is too large, got 4611686018427387904")},
+		{"min", "math.min(3, 1, 2)", 1},
+		{"max mixed", "math.max(3, 1.5, 2)", 3},
+		{"max array", "math.max([1, 7, 3])", 7},
+		{"min strings", "math.min(\"b\", \"a\")", "a"},
+		{"sin", "math.sin(0)", 0.0},
+		{"cos", "math.cos(math.pi)", -1.0},
+		{"atan2", "math.atan2(1, 1) * 4 == math.pi", true},
+		{"exp", "math.exp(0)", 1.0},
+		{"log", "math.log(math.e)", 1.0},
+		{"log base", "math.log(100, 10)", 2.0},
+		{"pi", "math.pi > 3.14", true},
+		{"is_nan", "math.is_nan(math.sqrt(-1))", true},
+		{"is_inf", "math.is_inf(math.log(0))", true},
+		{"integer is not nan", "math.is_nan(1)", false},
+		{"sqrt of string", "math.sqrt(\"a\")", errorMessage("first argument to `math.sqrt` must be a number, got STRING")},
+		{"floor of infinity", "math.floor(math.log(0))", errorMessage("cannot convert -Inf to INTEGER")},
+		{"min of nothing", "math.min()", errorMessage("`math.min` needs at least one value")},
+		{"max of mixed types", "math.max(1, \"a\")", errorMessage("cannot compare STRING with INTEGER")},
+		{"missing module member", "math.tau", errorMessage("on line 1 - module math has no member tau")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"map", "map([1, 2, 3], fn(x) { x * 2 })", []int{2, 4, 6}},
+		{"map closure", "let k = 3; map([1, 2], fn(x) { x + k })", []int{4, 5}},
+		{"map builtin", "map([\"a\", \"bc\"], len)", []int{1, 2}},
+		{"map string", "join(map(\"ab\", upper))", "AB"},
+		{"map range", "map(1..4, fn(x) { x * x })", []int{1, 4, 9}},
+		{"map hash keys", "join(map({\"a\": 1, \"b\": 2}, fn(k) { k + k }), \",\")", "aa,bb"},
+		{"map inside function", "let f = fn(xs) { let k = 2; map(xs, fn(x) { x * k }) }; f([1, 2])", []int{2, 4}},
+		{"map recursive", "let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; map([3, 4], fact)", []int{6, 24}},
+		{"nested", "map([[1, 2], [3]], fn(a) { reduce(a, fn(s, x) { s + x }, 0) })", []int{3, 3}},
+		{"filter", "filter([1, 2, 3, 4], fn(x) { x > 2 })", []int{3, 4}},
+		{"filter none", "filter([1, 2], fn(x) { false })", []int{}},
+		{"reduce", "reduce([1, 2, 3, 4], fn(acc, x) { acc + x })", 10},
+		{"reduce initial", "reduce([\"a\", \"b\"], fn(acc, x) { acc + x }, \">\")", ">ab"},
+		{"reduce empty", "reduce([], fn(acc, x) { acc + x }, 5)", 5},
+		{"sort_by", "sort_by([3, 1, 2], fn(x) { -x })", []int{3, 2, 1}},
+		{"sort_by stable", "join(sort_by([\"bb\", \"a\", \"cc\", \"d\"], len))", "adbbcc"},
+		{"each", "each([1, 2], fn(x) { x })", nil},
+		{"result used later", "let a = map([1, 2], fn(x) { x + 1 }); a[1] * 10", 30},
+		{"map non-iterable", "map(1, fn(x) { x })", errorMessage("first argument to `map` must be iterable, got INTEGER")},
+		{"filter non-function", "filter([1], 2)", errorMessage("second argument to `filter` must be a function, got INTEGER")},
+		{"reduce empty without initial", "reduce([], fn(acc, x) { acc })", errorMessage("`reduce` of an empty collection needs an initial value")},
+		{"sort_by mixed keys", "sort_by([1, \"a\"], fn(x) { x })", errorMessage("cannot compare STRING with INTEGER")},
+		{"each wrong arity", "each([1])", errorMessage("wrong number of arguments. expected=2, got=1")},
+		{"callback arity", "map([1], fn(a, b) { a })", errorMessage("on line 1 - wrong number of arguments: expected=2, got=1")},
+		{"callback error", `map([1], fn(x) { x + "a" })`, errorMessage("on line 1 - type mismatch: INTEGER + STRING")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"keys", "join(keys({\"b\": 1, \"a\": 2}))", "ba"},
+		{"keys empty", "keys({})", []int{}},
+		{"values", "values({\"b\": 2, \"a\": 1})", []int{2, 1}},
+		{"entries", "str(entries({\"a\": 1, 2: 3}))", "[(a, 1), (2, 3)]"},
+		{"entries unpack", "let k, v = entries({\"a\": 1})[0]; k + str(v)", "a1"},
+		{"has key", "has({\"a\": 1}, \"a\")", true},
+		{"has missing key", "has({\"a\": 1}, \"b\")", false},
+		{"has array key", "has({[1, 2]: 1}, [1, 2])", true},
+		{"delete", "let h = {\"a\": 1, \"b\": 2}; str(delete(h, \"a\")) + str(h)", "{b: 2}{a: 1, b: 2}"},
+		{"delete missing key", "str(delete({\"a\": 1}, \"z\"))", "{a: 1}"},
+		{"merge", "str(merge({\"a\": 1, \"b\": 2}, {\"b\": 3, \"c\": 4}))", "{a: 1, b: 3, c: 4}"},
+		{"merge one", "str(merge({\"a\": 1}))", "{a: 1}"},
+		{"get", "get({\"a\": 1}, \"a\")", 1},
+		{"get default", "get({\"a\": 1}, \"b\", 0)", 0},
+		{"get missing", "get({}, \"b\")", nil},
+		{"from_entries", "str(from_entries([(\"a\", 1), [\"b\", 2], (\"a\", 3)]))", "{a: 3, b: 2}"},
+		{"from_entries round trip", "let h = {\"a\": 1, [2]: 3}; from_entries(entries(h)) == h", true},
+		{"keys of array", "keys([1])", errorMessage("first argument to `keys` must be a HASH, got ARRAY")},
+		{"has of integer", "has(1, 2)", errorMessage("argument to `has` must be a SET or HASH, got INTEGER")},
+		{"has unhashable", "has({}, fn() {})", errorMessage("unusable as hash key: FUNCTION")},
+		{"get unhashable", "get({}, [fn() {}])", errorMessage("unusable as hash key: FUNCTION")},
+		{"delete unhashable", "delete({}, fn() {})", errorMessage("unusable as hash key: FUNCTION")},
+		{"merge non-hash", "merge({}, 1)", errorMessage("arguments to `merge` must be HASH, got INTEGER")},
+		{"merge nothing", "merge()", errorMessage("`merge` needs at least one HASH")},
+		{"from_entries unhashable", "from_entries([(fn() {}, 1)])", errorMessage("unusable as hash key: FUNCTION")},
+		{"from_entries non-pair", "from_entries([1])", errorMessage("`from_entries` expects key and value pairs: cannot unpack INTEGER")},
+		{"from_entries triple", "from_entries([(1, 2, 3)])", errorMessage("`from_entries` expects key and value pairs: wrong number of values to unpack. expected=2, got=3")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"parse array", "json_parse(\"[1, 2, 3]\")", []int{1, 2, 3}},
+		{"parse object", "let j = fn(s) { replace(s, \"'\", chr(34)) }; str(json_parse(j(\"{'b': 1, 'a': [true, null, 2.5, 'x']}\")))", "{b: 1, a: [true, null, 2.5, x]}"},
+		{"parse nested", "let j = fn(s) { replace(s, \"'\", chr(34)) }; json_parse(j(\"{'a': {'b': [0, 7]}}\"))[\"a\"][\"b\"][1]", 7},
+		{"parse null is falsy", "if (json_parse(\"[null]\")[0]) { 1 } else { 2 }", 2},
+		{"parse big integer", "json_parse(\"12345678901234567890\")", bigInt("12345678901234567890")},
+		{"parse exponent", "json_parse(\"1e2\")", 100.0},
+		{"parse escapes", "let j = fn(s) { replace(s, \"'\", chr(34)) }; json_parse(j(\"'caf\\u00e9\\n'\"))", "café\n"},
+		{"parse duplicate key", "let j = fn(s) { replace(s, \"'\", chr(34)) }; json_parse(j(\"{'a': 1, 'a': 2}\"))[\"a\"]", 2},
+		{"stringify", "json_stringify({\"b\": [1, 2.5, 1.50d], \"a\": (true, false), \"c\": {}, \"d\": []})", "{\"b\":[1,2.5,1.50],\"a\":[true,false],\"c\":{},\"d\":[]}"},
+		{"stringify string", "json_stringify(\"<a&b>\")", "\"<a&b>\""},
+		{"stringify set", "json_stringify({1, 2})", "[1,2]"},
+		{"stringify indent", "json_stringify({\"a\": [1, 2]}, 2)", "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"stringify indent string", "json_stringify([1], \"\t\")", "[\n\t1\n]"},
+		{"round trip", "let h = {\"a\": [1, {\"b\": \"c\"}], \"d\": 2.5}; json_parse(json_stringify(h)) == h", true},
+		{"parse non-string", "json_parse(1)", errorMessage("first argument to `json_parse` must be a STRING, got INTEGER")},
+		{"parse empty", "json_parse(\"\")", errorMessage("invalid JSON: unexpected end of JSON input")},
+		{"parse unterminated", "json_parse(\"[1, 2\")", errorMessage("invalid JSON: unexpected end of JSON input")},
+		{"parse trailing data", "json_parse(\"[1] 2\")", errorMessage("invalid JSON: unexpected data after value")},
+		{"parse invalid", "json_parse(\"{x}\")", errorMessage("invalid JSON: invalid character 'x' looking for beginning of value")},
+		{"stringify builtin", "json_stringify([len])", errorMessage("cannot encode BUILTIN as JSON")},
+		{"stringify integer key", "json_stringify({1: 2})", errorMessage("JSON object keys must be STRING, got INTEGER")},
+		{"stringify infinity", "json_stringify(math.log(0))", errorMessage("cannot encode -Inf as JSON")},
+		{"stringify bad indent string", "json_stringify([1], \"xx\")", errorMessage("indent for `json_stringify` must contain only spaces and tabs, got \"xx\"")},
+		{"stringify bad indent", "json_stringify(1, -1)", errorMessage("indent for `json_stringify` must be between 0 and 16, got -1")},
+		{"stringify closure", "json_stringify(fn(x) { x })", errorMessage("cannot encode FUNCTION as JSON")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"read", "read_file(\"notes.txt\")", "hello"},
+		{"read through subdirectory", "read_file(\"./sub/../notes.txt\")", "hello"},
+		{"write", "write_file(\"new.txt\", \"hi\"); read_file(\"new.txt\")", "hi"},
+		{"write returns null", "write_file(\"new.txt\", \"\")", nil},
+		{"overwrite with bytes", "write_file(\"notes.txt\", b\"\\x00bye\"); len(read_file(\"notes.txt\"))", 4},
+		{"append", "append_file(\"notes.txt\", \" world\"); read_file(\"notes.txt\")", "hello world"},
+		{"append creates", "append_file(\"log.txt\", \"a\"); append_file(\"log.txt\", \"b\"); read_file(\"log.txt\")", "ab"},
+		{"list root", "join(list_dir(), \",\")", "notes.txt,sub"},
+		{"list subdirectory", "join(list_dir(\"sub\"), \",\")", "a.txt"},
+		{"exists", "exists(\"sub/a.txt\")", true},
+		{"exists missing", "exists(\"missing.txt\")", false},
+		{"remove_file", "remove_file(\"notes.txt\"); exists(\"notes.txt\")", false},
+		{"remove from set", "has(remove({1, 2}, 1), 1)", false},
+		{"read parent", "read_file(\"../secret\")", errorMessage("path \"../secret\" is outside the root directory")},
+		{"read absolute", "read_file(\"/etc/passwd\")", errorMessage("path \"/etc/passwd\" is outside the root directory")},
+		{"exists escaping", "exists(\"sub/../../x\")", errorMessage("path \"sub/../../x\" is outside the root directory")},
+		{"remove_file parent", "remove_file(\"..\")", errorMessage("path \"..\" is outside the root directory")},
+		{"read missing", "read_file(\"missing.txt\")", errorMessage("missing.txt: no such file or directory")},
+		{"read non-string", "read_file(1)", errorMessage("first argument to `read_file` must be a STRING, got INTEGER")},
+		{"write integer", "write_file(\"a.txt\", 1)", errorMessage("second argument to `write_file` must be a STRING or BYTES, got INTEGER")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program := parser.New(lexer.New(tt.input)).ParseProgram()
+			env := object.NewEnvironment()
+			env.SetFS(testFS(t))
+
+			testExpectedObject(t, Eval(program, env), tt.expected)
+		})
+	}
+}
+
+// testFS returns a file system holding notes.txt and sub/a.txt in a fresh directory.
+func testFS(t *testing.T) object.FS {
+	t.Helper()
+
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello"), 0644)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "sub", "a.txt"), nil, 0644)
+	}
+	if err != nil {
+		t.Fatalf("could not create test files: %s", err)
+	}
+
+	fsys, err := object.DirFS(dir)
+	if err != nil {
+		t.Fatalf("could not open test directory: %s", err)
+	}
+	return fsys
+}
+
+func TestRegex(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"match", "regex(\"a+\").match(\"baaad\")[0]", "aaa"},
+		{"match groups", "regex(\"(\\w+)@(\\w+)\").match(\"mail bob@example now\")[2]", "example"},
+		{"match unused group", "regex(\"a(b)?\").match(\"a\")[1]", nil},
+		{"no match", "regex(\"x\").match(\"abc\")", nil},
+		{"match as value", "let m = regex(\"b\").match; m(\"abc\")[0]", "b"},
+		{"find_all", "join(regex(\"\\d+\").find_all(\"a1b22c333\"), \",\")", "1,22,333"},
+		{"find_all limit", "join(regex(\"\\d+\").find_all(\"a1b22c333\", 2), \",\")", "1,22"},
+		{"find_all none", "regex(\"\\d\").find_all(\"abc\")", []int{}},
+		{"find_all characters", "len(regex(\".\").find_all(\"héllo\"))", 5},
+		{"replace_all", "regex(\"(\\w)(\\w*)\").replace_all(\"hello world\", \"$2$1\")", "elloh orldw"},
+		{"replace_all named", "regex(\"(?P<y>\\d{4})-(?P<m>\\d\\d)\").replace_all(\"on 2024-05\", \"${m}/${y}\")", "on 05/2024"},
+		{"split", "join(regex(\"\\s*,\\s*\").split(\"a , b,c\"), \"|\")", "a|b|c"},
+		{"split limit", "join(regex(\",\").split(\"a,b,c\", 2), \"|\")", "a|b,c"},
+		{"pattern", "regex(\"a.b\").pattern", "a.b"},
+		{"inspect", "str(regex(\"a+\"))", "regex(a+)"},
+		{"invalid pattern", "regex(\"(a\")", errorMessage("invalid pattern for `regex`: error parsing regexp: missing closing ): `(a`")},
+		{"pattern not a string", "regex(1)", errorMessage("first argument to `regex` must be a STRING, got INTEGER")},
+		{"match non-string", "regex(\"a\").match(1)", errorMessage("first argument to `match` must be a STRING, got INTEGER")},
+		{"negative limit", "regex(\"a\").find_all(\"a\", -1)", errorMessage("second argument to `find_all` must not be negative, got -1")},
+		{"limit not an integer", "regex(\"a\").split(\"a\", \"b\")", errorMessage("second argument to `split` must be an INTEGER, got STRING")},
+		{"missing member", `regex("a").test`, errorMessage("on line 1 - regex has no member test")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"now", "str(now())", "2024-03-15T12:30:45Z"},
+		{"unix", "unix()", 1710505845},
+		{"unix of time", "unix(parse_time(\"1970-01-02T00:00:00Z\"))", 86400},
+		{"sleep advances the clock", "let start = now(); sleep(1500); (now() - start).ms", 1500},
+		{"sleep duration", "sleep(duration(\"2m\")); now().minute", 32},
+		{"format_time", "format_time(now(), \"2006-01-02 15:04\")", "2024-03-15 12:30"},
+		{"format_time default", "format_time(now())", "2024-03-15T12:30:45Z"},
+		{"parse_time layout", "let t = parse_time(\"2024-01-02\", \"2006-01-02\"); t.year * 10000 + t.month * 100 + t.day", 20240102},
+		{"parse_time offset", "parse_time(\"2024-03-15T14:30:45+02:00\") == now()", true},
+		{"weekday", "now().weekday", "Friday"},
+		{"time plus duration", "str(now() + duration(\"36h\"))", "2024-03-17T00:30:45Z"},
+		{"duration plus time", "str(duration(\"1h\") + now())", "2024-03-15T13:30:45Z"},
+		{"time minus duration", "str(now() - duration(1000))", "2024-03-15T12:30:44Z"},
+		{"add durations", "str(duration(\"1h\") + duration(90000))", "1h1m30s"},
+		{"scale duration", "str(duration(1000) * 3)", "3s"},
+		{"scale duration left", "str(2 * duration(500))", "1s"},
+		{"divide duration", "str(duration(\"1m\") / 4)", "15s"},
+		{"duration ratio", "duration(\"1m\") / duration(\"15s\")", 4.0},
+		{"duration seconds", "duration(1500).seconds", 1.5},
+		{"compare times", "now() - duration(\"1s\") < now()", true},
+		{"compare durations", "duration(1) > duration(2)", false},
+		{"duration hash key", "{duration(1000): \"a\"}[duration(\"1s\")]", "a"},
+		{"parse_time invalid", "parse_time(\"nope\")", errorMessage("invalid time for `parse_time`: parsing time \"nope\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"nope\" as \"2006\"")},
+		{"duration invalid", "duration(\"x\")", errorMessage("invalid duration for `duration`: time: invalid duration \"x\"")},
+		{"sleep negative", "sleep(-1)", errorMessage("cannot sleep for a negative duration, got -1ms")},
+		{"format_time non-time", "format_time(1)", errorMessage("first argument to `format_time` must be a TIME, got INTEGER")},
+		{"add times", "now() + now()", errorMessage("on line 1 - unknown operator: TIME + TIME")},
+		{"divide duration by zero", "duration(1) / 0", errorMessage("on line 1 - division by zero")},
+		{"missing time member", "now().century", errorMessage("on line 1 - time has no member century")},
+		{"largest duration", "duration(9223372036854).ms", 9223372036854},
+		{"smallest duration", "duration(-9223372036854).ms", -9223372036854},
+		{"duration too long", "duration(9223372036855)", errorMessage("duration of 9223372036855ms given to `duration` is out of range")},
+		{"duration too negative", "duration(-9223372036855)", errorMessage("duration of -9223372036855ms given to `duration` is out of range")},
+		{"sleep too long", "sleep(9223372036855)", errorMessage("duration of 9223372036855ms given to `sleep` is out of range")},
+		{"add durations overflow", "duration(9223372036854) + duration(9223372036854)", errorMessage("on line 1 - duration out of range")},
+		{"subtract durations overflow", "duration(-9223372036854) - duration(9223372036854)", errorMessage("on line 1 - duration out of range")},
+		{"subtract distant times", "now() - parse_time(\"0001-01-01T00:00:00Z\")", errorMessage("on line 1 - duration out of range")},
+		{"subtract smallest duration", "now() - duration(\"-2562047h47m16.854775808s\")", errorMessage("on line 1 - duration out of range")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program := parser.New(lexer.New(tt.input)).ParseProgram()
+			env := object.NewEnvironment()
+			env.SetClock(&testClock{now: time.Date(2024, 3, 15, 12, 30, 45, 0, time.UTC)})
+
+			testExpectedObject(t, Eval(program, env), tt.expected)
+		})
+	}
+}
+
+// testClock stands still until slept on.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time        { return c.now }
+func (c *testClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"plain", "format(\"no directives\")", "no directives"},
+		{"percent", "format(\"100%%\")", "100%"},
+		{"integer", "format(\"%d items\", 3)", "3 items"},
+		{"integer width", "format(\"[%5d|%-5d|%05d]\", 42, 42, -42)", "[   42|42   |-0042]"},
+		{"integer sign", "format(\"%+d % d\", 5, 5)", "+5  5"},
+		{"big integer", "format(\"%d\", 100000000000000000000)", "100000000000000000000"},
+		{"float", "format(\"%f\", 3.0)", "3.000000"},
+		{"float precision", "format(\"%.2f\", 3.14159)", "3.14"},
+		{"float width", "format(\"%8.3f|%-8.1f|\", 2.5, 2.5)", "   2.500|2.5     |"},
+		{"integer as float", "format(\"%.2f\", 3)", "3.00"},
+		{"decimal", "format(\"%f\", 1.50d)", "1.50"},
+		{"decimal precision", "format(\"%.1f|%.3f\", 2.25d, 2.25d)", "2.2|2.250"},
+		{"decimal padding", "format(\"%08.2f|%+.1f|%7.2f\", -1.5d, 1.5d, 12.345d)", "-0001.50|+1.5|  12.34"},
+		{"string", "format(\"%s and %s\", \"this\", \"that\")", "this and that"},
+		{"string width", "format(\"[%-6s|%6s]\", \"ab\", \"héllo\")", "[ab    | héllo]"},
+		{"string precision", "format(\"%.3s\", \"héllo\")", "hél"},
+		{"bytes as string", "format(\"%s\", b\"raw\")", "raw"},
+		{"quoted", "format(\"%q\", \"tab\there\")", "\"tab\\there\""},
+		{"hex integer", "format(\"%x|%#x|%04x\", 255, 255, 10)", "ff|0xff|000a"},
+		{"hex string", "format(\"%x\", \"hi\")", "6869"},
+		{"hex bytes", "format(\"%x\", b\"\\x01\\xff\")", "01ff"},
+		{"values", "format(\"%v|%v|%v\", 3.0, [1, \"a\"], (1,))", "3.0|[1, a]|(1,)"},
+		{"value width", "format(\"%-4v|\", 1)", "1   |"},
+		{"no arguments", "format()", errorMessage("wrong number of arguments. expected at least 1, got=0")},
+		{"format not a string", "format(1)", errorMessage("first argument to `format` must be a STRING, got INTEGER")},
+		{"integer verb with float", "format(\"%5d\", 1.5)", errorMessage("%5d in `format` needs an INTEGER, got FLOAT")},
+		{"float verb with string", "format(\"%f\", \"1\")", errorMessage("%f in `format` needs a number, got STRING")},
+		{"string verb with integer", "format(\"%s\", 1)", errorMessage("%s in `format` needs a STRING or BYTES, got INTEGER")},
+		{"quote verb with bytes", "format(\"%q\", b\"a\")", errorMessage("%q in `format` needs a STRING, got BYTES")},
+		{"hex verb with float", "format(\"%x\", 1.5)", errorMessage("%x in `format` needs an INTEGER, STRING or BYTES, got FLOAT")},
+		{"unknown verb", "format(\"%y\", 1)", errorMessage("unknown verb %y in `format`")},
+		{"incomplete directive", "format(\"50%\")", errorMessage("`format` ends with an incomplete directive %")},
+		{"missing argument", "format(\"%d and %d\", 1)", errorMessage("`format` is missing an argument for %d")},
+		{"extra argument", "format(\"%d\", 1, 2)", errorMessage("`format` has 2 arguments but only uses 1")},
+		{"huge width", "format(\"%5000d\", 1)", errorMessage("width in `format` is larger than 1000")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
 	}
 }
 
@@ -422,28 +1362,234 @@ func TestHashIndexExpressions(t *testing.T) {
 		input    string
 		expected interface{}
 	}{
-		{"foo-5", `{"foo": 5}["foo"]`, 5.0},
+		{"foo-5", `{"foo": 5}["foo"]`, 5},
 		{"foo-bar", `{"foo": 5}["bar"]`, nil},
-		{"ident-foo", `let key = "foo"; {"foo": 5}[key]`, 5.0},
+		{"ident-foo", `let key = "foo"; {"foo": 5}[key]`, 5},
 		{"empty-foo", `{}["foo"]`, nil},
-		{"5-5", `{5: 5}[5]`, 5.0},
-		{"true-5", `{true: 5}[true]`, 5.0},
-		{"false-5", `{false: 5}[false]`, 5.0},
+		{"5-5", `{5: 5}[5]`, 5},
+		{"true-5", `{true: 5}[true]`, 5},
+		{"false-5", `{false: 5}[false]`, 5},
+		{"float-key", `{1: 1, 1.5: 2}[1.5]`, 2},
+		{"whole-float-key", `{1: 5}[1.0]`, 5},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			evaluated := testEval(tt.input)
-			number, ok := tt.expected.(float64)
+			number, ok := tt.expected.(int)
 			if !ok {
 				testNullObject(t, evaluated)
 			} else {
-				testNumberObject(t, evaluated, number)
+				testIntegerObject(t, evaluated, int64(number))
 			}
 		})
 	}
 }
 
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"len exclusive", "len(1..10)", 9},
+		{"len inclusive", "len(1..=10)", 10},
+		{"len descending", "len(10..=0 step -5)", 3},
+		{"index", "(1..10)[3]", 4},
+		{"index out of bounds", "(1..10)[9]", nil},
+		{"array slice", "len([1, 2, 3, 4, 5][1..3])", 2},
+		{"range slice index", "(0..100 step 2)[10..20][0]", 20},
+		{"in range", "5 in 1..10", true},
+		{"not in range", "10 in 1..10", false},
+		{"in array", "2 in [1, 2, 3]", true},
+		{"in hash", `"a" in {"a": 1}`, true},
+		{"len too long", "len(-9223372036854775807..9223372036854775807)",
+			errorMessage("range -9223372036854775807..9223372036854775807 is too long")},
+		{"len inclusive too long", "len(0..=9223372036854775807)", errorMessage("range 0..=9223372036854775807 is too long")},
+		{"len largest", "len(0..9223372036854775807)", 9223372036854775807},
+		{"in extreme range", "9223372036854775807 in (-9223372036854775807 - 1)..=9223372036854775807", true},
+		{"not in extreme range", "-9223372036854775807 - 1 in -9223372036854775807..9223372036854775807", false},
+		{"last extreme range", "last((-9223372036854775807 - 1)..=9223372036854775807)", 9223372036854775807},
+		{
+			"recursive sum",
+			`let sum = fn(r) { if (len(r) == 0) { 0 } else { first(r) + sum(rest(r)) } };
+			sum(1..=100);`,
+			5050,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testExpectedObject(t, testEval(tt.input), tt.expected)
+		})
+	}
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []int64
+	}{
+		{"map", "[x * 2 for x in [1, 2, 3]]", []int64{2, 4, 6}},
+		{"filter", "[x for x in [-1, 2, -3, 4] if x > 0]", []int64{2, 4}},
+		{"range", "[x * x for x in 1..=4]", []int64{1, 4, 9, 16}},
+		{"index and element", "[i * x for i, x in [5, 5, 5]]", []int64{0, 5, 10}},
+		{"hash comprehension", `[{k: v * 10 for k, v in {"a": 1}}["a"]]`, []int64{10}},
+		{"shadowed", "let x = 5; [x for x in [1, 2]]; [x]", []int64{5}},
+		{"closures", "let fs = [fn() { x } for x in 1..3]; [fs[0](), fs[1]()]", []int64{1, 2}},
+		{"return in function", "[fn() { return x * 2 }() for x in [1, 2]]", []int64{2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Fatalf("object wrong type. expected=*object.Array, got=%T (%+[1]v)", evaluated)
+			}
+
+			if len(array.Elements) != len(tt.expected) {
+				t.Fatalf("wrong number of elements. expected=%d, got=%d", len(tt.expected), len(array.Elements))
+			}
+
+			for i, expected := range tt.expected {
+				testIntegerObject(t, array.Elements[i], expected)
+			}
+		})
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"field access", "struct Point { x, y }; let p = Point(1, 2); p.x + p.y", 3},
+		{"nested access", "struct Box { v }; Box(Box(5)).v.v", 5},
+		{"equal", "struct P { x, y }; P(1, 2) == P(1, 2)", true},
+		{"not equal", "struct P { x, y }; P(1, 2) != P(1, 3)", true},
+		{"different types", "struct A { x }; struct B { x }; A(1) == B(1)", false},
+		{"local struct", "let f = fn(a) { struct P { v }; P(a).v * 2 }; f(4)", 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+
+			switch expected := tt.expected.(type) {
+			case int:
+				testIntegerObject(t, evaluated, int64(expected))
+			case bool:
+				testBooleanObject(t, evaluated, expected)
+			}
+		})
+	}
+}
+
+func TestOptionalChaining(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"field on null", "let n = if (false) { 1 }; n?.x", nil},
+		{"skips rest of chain", "let n = if (false) { 1 }; n?.x.y[0]", nil},
+		{"skips call", "let n = if (false) { 1 }; n?.f(1)", nil},
+		{"index on null", "let n = if (false) { 1 }; n?[0]", nil},
+		{"field on value", "struct P { x }; P(3)?.x", 3},
+		{"index on value", "[1, 2]?[1]", 2},
+		{"missing index", "{\"a\": 1}?[\"b\"]?.x", nil},
+		{"coalesce null", "let n = if (false) { 1 }; n ?? 5", 5},
+		{"coalesce value", "3 ?? 5", 3},
+		{"coalesce false", "false ?? true", false},
+		{"coalesce chain", "let n = if (false) { 1 }; n?.x ?? n ?? 7", 7},
+		{"coalesce is lazy", "let n = 1; n ?? undefined", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+
+			switch expected := tt.expected.(type) {
+			case int:
+				testIntegerObject(t, evaluated, int64(expected))
+			case bool:
+				testBooleanObject(t, evaluated, expected)
+			default:
+				testNullObject(t, evaluated)
+			}
+		})
+	}
+}
+
+func TestEnumsAndMatch(t *testing.T) {
+	shapes := `enum Shape { Circle(r), Rect(w, h), Empty };
+		let area = fn(s) {
+			match (s) {
+				Circle(r) => 3 * r * r,
+				Rect(w, h) => w * h,
+				Empty => 0,
+			}
+		};`
+
+	tests := []struct {
+		name     string
+		input    string
+		expected interface{}
+	}{
+		{"circle", shapes + "area(Circle(2))", 12},
+		{"rect", shapes + "area(Rect(2, 5))", 10},
+		{"unit", shapes + "area(Empty)", 0},
+		{"qualified constructor", shapes + "area(Shape.Rect(1, 2))", 2},
+		{"equal", shapes + "Rect(1, 2) == Rect(1, 2)", true},
+		{"not equal variants", shapes + "Circle(1) != Empty", true},
+		{"no arm matches", shapes + "match (Empty) { Circle(r) => r }", nil},
+		{"value arm", `match ("b") { "a" => 1, "b" => 2 }`, 2},
+		{"return from arm", shapes + "let f = fn(s) { match (s) { Empty => { return 1; } }; 2 }; f(Empty) + f(Circle(1))", 3},
+		{"binding does not overwrite", shapes + "let r = 10; match (Circle(1)) { Circle(r) => r }; r", 10},
+		{"binding shadows in arm", shapes + "let r = 10; match (Circle(1)) { Circle(r) => r }", 1},
+		{"binding hidden from closures", "enum E { A(x) }; let x = 100; let g = fn() { x }; match (A(1)) { A(x) => g() }", 100},
+		{"local binding does not overwrite", shapes + "let f = fn() { let r = 10; match (Circle(1)) { Circle(r) => r }; r }; f()", 10},
+		{"closure captures binding", shapes + "let f = match (Circle(7)) { Circle(r) => fn() { r } }; f()", 7},
+		{"arm let does not leak", "let d = 1; match (1) { _ => { let d = 2; d } }; d", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluated := testEval(tt.input)
+
+			switch expected := tt.expected.(type) {
+			case int:
+				testIntegerObject(t, evaluated, int64(expected))
+			case bool:
+				testBooleanObject(t, evaluated, expected)
+			default:
+				testNullObject(t, evaluated)
+			}
+		})
+	}
+}
+
+func TestEnumValueInspect(t *testing.T) {
+	evaluated := testEval(`enum Shape { Rect(w, h) }; Rect(1, "a")`)
+
+	expected := "Shape.Rect(1, a)"
+	if evaluated.Inspect() != expected {
+		t.Errorf("wrong Inspect output. expected=%q, got=%q", expected, evaluated.Inspect())
+	}
+}
+
+func TestInstanceInspect(t *testing.T) {
+	evaluated := testEval(`struct Point { x, name }; Point(1, "a")`)
+
+	expected := "Point{x: 1, name: a}"
+	if evaluated.Inspect() != expected {
+		t.Errorf("wrong Inspect output. expected=%q, got=%q", expected, evaluated.Inspect())
+	}
+}
+
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -453,10 +1599,25 @@ func testEval(input string) object.Object {
 	return Eval(program, env)
 }
 
-func testNumberObject(t *testing.T, obj object.Object, expected float64) bool {
-	result, ok := obj.(*object.Number)
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
 	if !ok {
-		t.Errorf("object wrong type. expected=*object.Number, got=%T (%+[1]v)", obj)
+		t.Errorf("object wrong type. expected=*object.Integer, got=%T (%+[1]v)", obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. expected=%d, got=%d", expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object wrong type. expected=*object.Float, got=%T (%+[1]v)", obj)
 		return false
 	}
 
@@ -491,3 +1652,70 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 
 	return true
 }
+
+func hashPairs(t *testing.T, hash *object.Hash) map[object.HashKey]object.HashPair {
+	t.Helper()
+
+	pairs := make(map[object.HashKey]object.HashPair)
+	for _, pair := range hash.Ordered() {
+		key, err := object.HashKeyOf(pair.Key)
+		if err != nil {
+			t.Fatalf("hash holds unusable key: %s", err)
+		}
+		pairs[key] = pair
+	}
+	return pairs
+}
+
+// testExpectedObject checks obj against an expected int, *big.Int, float64, decimal, bool,
+// []int, string, bytesValue, errorMessage, or nil for NULL.
+func testExpectedObject(t *testing.T, obj object.Object, expected interface{}) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		testIntegerObject(t, obj, int64(expected))
+	case *big.Int:
+		result, ok := obj.(*object.BigInt)
+		if !ok || result.Value.Cmp(expected) != 0 {
+			t.Errorf("wrong value. expected=%s, got=%s", expected, obj.Inspect())
+		}
+	case float64:
+		testFloatObject(t, obj, expected)
+	case decimal:
+		result, ok := obj.(*object.Decimal)
+		if !ok || result.Inspect() != string(expected) {
+			t.Errorf("wrong value. expected=%s, got=%s", expected, obj.Inspect())
+		}
+	case bool:
+		testBooleanObject(t, obj, expected)
+	case []int:
+		array, ok := obj.(*object.Array)
+		if !ok || len(array.Elements) != len(expected) {
+			t.Errorf("wrong value. expected=%v, got=%s", expected, obj.Inspect())
+			return
+		}
+		for i, el := range expected {
+			testIntegerObject(t, array.Elements[i], int64(el))
+		}
+	case string:
+		str, ok := obj.(*object.String)
+		if !ok || str.Value != expected {
+			t.Errorf("wrong value. expected=%q, got=%s", expected, obj.Inspect())
+		}
+	case bytesValue:
+		b, ok := obj.(*object.Bytes)
+		if !ok || string(b.Value) != string(expected) {
+			t.Errorf("wrong value. expected=%q, got=%s", expected, obj.Inspect())
+		}
+	case errorMessage:
+		errObj, ok := obj.(*object.Error)
+		if !ok || errObj.Message != string(expected) {
+			t.Errorf("wrong error. expected=%q, got=%s", expected, obj.Inspect())
+		}
+	case nil:
+		testNullObject(t, obj)
+	default:
+		t.Fatalf("unsupported expectation %T", expected)
+	}
+}
@@ -46,6 +46,10 @@ func (l *Lexer) NextToken() token.Token {
 			pos := l.position
 			l.readChar()
 			tok = token.New(token.Eq, l.input[pos:l.readPos], l.line)
+		} else if l.peek() == '>' {
+			pos := l.position
+			l.readChar()
+			tok = token.New(token.FatArrow, l.input[pos:l.readPos], l.line)
 		} else {
 			tok = newToken(token.Assign, l.ch, l.line)
 		}
@@ -81,10 +85,40 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = newToken(token.Gt, l.ch, l.line)
 		}
+	case '?':
+		switch l.peek() {
+		case '.':
+			pos := l.position
+			l.readChar()
+			tok = token.New(token.OptDot, l.input[pos:l.readPos], l.line)
+		case '[':
+			pos := l.position
+			l.readChar()
+			tok = token.New(token.OptLBracket, l.input[pos:l.readPos], l.line)
+		case '?':
+			pos := l.position
+			l.readChar()
+			tok = token.New(token.Coalesce, l.input[pos:l.readPos], l.line)
+		default:
+			tok = newToken(token.Illegal, l.ch, l.line)
+		}
 	case ';':
 		tok = newToken(token.Semicolon, l.ch, l.line)
 	case ':':
 		tok = newToken(token.Colon, l.ch, l.line)
+	case '.':
+		if l.peek() == '.' {
+			pos := l.position
+			l.readChar()
+			if l.peek() == '=' {
+				l.readChar()
+				tok = token.New(token.DotDotEq, l.input[pos:l.readPos], l.line)
+			} else {
+				tok = token.New(token.DotDot, l.input[pos:l.readPos], l.line)
+			}
+		} else {
+			tok = newToken(token.Dot, l.ch, l.line)
+		}
 	case '(':
 		tok = newToken(token.LParen, l.ch, l.line)
 	case ')':
@@ -104,15 +138,17 @@ func (l *Lexer) NextToken() token.Token {
 	case 0:
 		tok = token.New(token.EOF, "", l.line)
 	default:
-		if isAlpha(l.ch) {
+		if l.ch == 'b' && l.peek() == '"' {
+			l.readChar()
+			tok = token.New(token.Bytes, l.readBytes(), l.line)
+		} else if isAlpha(l.ch) {
 			tok.Line = l.line
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isNumber(l.ch) {
 			tok.Line = l.line
-			tok.Type = token.Num
-			tok.Literal = l.readNumber()
+			tok.Literal, tok.Type = l.readNumber()
 			return tok
 		} else {
 			tok = newToken(token.Illegal, l.ch, l.line)
@@ -141,20 +177,27 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-func (l *Lexer) readNumber() string {
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
 	for isNumber(l.ch) {
 		l.readChar()
 	}
 
+	var ty token.TokenType = token.Int
 	if l.ch == '.' && isNumber(l.peek()) {
+		ty = token.Float
 		l.readChar()
 		for isNumber(l.ch) {
 			l.readChar()
 		}
 	}
 
-	return l.input[position:l.position]
+	if l.ch == 'd' && !isAlphaNumeric(l.peek()) {
+		ty = token.Dec
+		l.readChar()
+	}
+
+	return l.input[position:l.position], ty
 }
 
 func (l *Lexer) readString() string {
@@ -169,6 +212,23 @@ func (l *Lexer) readString() string {
 	return l.input[position:l.position]
 }
 
+// readBytes reads the body of a bytes literal, leaving escapes such as \" for the parser.
+func (l *Lexer) readBytes() string {
+	position := l.position + 1
+	for {
+		l.readChar()
+		if l.ch == '\\' && l.peek() != 0 {
+			l.readChar()
+			continue
+		}
+		if l.ch == '"' || l.ch == 0 {
+			break
+		}
+	}
+
+	return l.input[position:l.position]
+}
+
 func isAlphaNumeric(ch byte) bool {
 	return isAlpha(ch) || isNumber(ch)
 }
@@ -31,6 +31,11 @@ if (5 < 10.0) {
 "foo bar";
 [1, 2];
 {"foo": "bar"};
+1..10 in 0..=step;
+struct p.x;
+a?.b?[0] ?? c ?;
+1.10d 5d 5do;
+b"a\"\x00" b by"";
 `
 
 	tests := []struct {
@@ -51,13 +56,13 @@ if (5 < 10.0) {
 		{token.Let, "let", 2},
 		{token.Ident, "five", 2},
 		{token.Assign, "=", 2},
-		{token.Num, "5", 2},
+		{token.Int, "5", 2},
 		{token.Semicolon, ";", 2},
 
 		{token.Let, "let", 3},
 		{token.Ident, "ten", 3},
 		{token.Assign, "=", 3},
-		{token.Num, "10.0", 3},
+		{token.Float, "10.0", 3},
 		{token.Semicolon, ";", 3},
 
 		{token.Let, "let", 5},
@@ -94,21 +99,21 @@ if (5 < 10.0) {
 		{token.Minus, "-", 10},
 		{token.Slash, "/", 10},
 		{token.Star, "*", 10},
-		{token.Num, "5", 10},
+		{token.Int, "5", 10},
 		{token.Semicolon, ";", 10},
 
-		{token.Num, "5", 11},
+		{token.Int, "5", 11},
 		{token.Lt, "<", 11},
-		{token.Num, "10.0", 11},
+		{token.Float, "10.0", 11},
 		{token.Gt, ">", 11},
-		{token.Num, "5", 11},
+		{token.Int, "5", 11},
 		{token.Semicolon, ";", 11},
 
 		{token.If, "if", 13},
 		{token.LParen, "(", 13},
-		{token.Num, "5", 13},
+		{token.Int, "5", 13},
 		{token.Lt, "<", 13},
-		{token.Num, "10.0", 13},
+		{token.Float, "10.0", 13},
 		{token.RParen, ")", 13},
 		{token.LBrace, "{", 13},
 
@@ -126,21 +131,21 @@ if (5 < 10.0) {
 
 		{token.RBrace, "}", 17},
 
-		{token.Num, "10.0", 19},
+		{token.Float, "10.0", 19},
 		{token.Eq, "==", 19},
-		{token.Num, "10.0", 19},
+		{token.Float, "10.0", 19},
 		{token.Semicolon, ";", 19},
 
-		{token.Num, "10.0", 20},
+		{token.Float, "10.0", 20},
 		{token.NotEq, "!=", 20},
-		{token.Num, "9", 20},
+		{token.Int, "9", 20},
 		{token.Semicolon, ";", 20},
 
-		{token.Num, "9", 21},
+		{token.Int, "9", 21},
 		{token.LtEq, "<=", 21},
-		{token.Num, "10.0", 21},
+		{token.Float, "10.0", 21},
 		{token.GtEq, ">=", 21},
-		{token.Num, "5", 21},
+		{token.Int, "5", 21},
 		{token.Semicolon, ";", 21},
 
 		{token.String, "foobar", 22},
@@ -150,9 +155,9 @@ if (5 < 10.0) {
 		{token.Semicolon, ";", 23},
 
 		{token.LBracket, "[", 24},
-		{token.Num, "1", 24},
+		{token.Int, "1", 24},
 		{token.Comma, ",", 24},
-		{token.Num, "2", 24},
+		{token.Int, "2", 24},
 		{token.RBracket, "]", 24},
 		{token.Semicolon, ";", 24},
 
@@ -163,7 +168,45 @@ if (5 < 10.0) {
 		{token.RBrace, "}", 25},
 		{token.Semicolon, ";", 25},
 
-		{token.EOF, "", 26},
+		{token.Int, "1", 26},
+		{token.DotDot, "..", 26},
+		{token.Int, "10", 26},
+		{token.In, "in", 26},
+		{token.Int, "0", 26},
+		{token.DotDotEq, "..=", 26},
+		{token.Ident, "step", 26},
+		{token.Semicolon, ";", 26},
+
+		{token.Struct, "struct", 27},
+		{token.Ident, "p", 27},
+		{token.Dot, ".", 27},
+		{token.Ident, "x", 27},
+		{token.Semicolon, ";", 27},
+
+		{token.Ident, "a", 28},
+		{token.OptDot, "?.", 28},
+		{token.Ident, "b", 28},
+		{token.OptLBracket, "?[", 28},
+		{token.Int, "0", 28},
+		{token.RBracket, "]", 28},
+		{token.Coalesce, "??", 28},
+		{token.Ident, "c", 28},
+		{token.Illegal, "?", 28},
+		{token.Semicolon, ";", 28},
+
+		{token.Dec, "1.10d", 29},
+		{token.Dec, "5d", 29},
+		{token.Int, "5", 29},
+		{token.Ident, "do", 29},
+		{token.Semicolon, ";", 29},
+
+		{token.Bytes, `a\"\x00`, 30},
+		{token.Ident, "b", 30},
+		{token.Ident, "by", 30},
+		{token.String, "", 30},
+		{token.Semicolon, ";", 30},
+
+		{token.EOF, "", 31},
 	}
 
 	l := New(input)
@@ -2,11 +2,17 @@ package object
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"unicode/utf8"
 )
 
+// Builtins are the globally available functions and modules. The compiler refers to them by
+// index, so new entries are only ever appended.
 var Builtins = []struct {
 	Name    string
-	Builtin *Builtin
+	Builtin Object // a *Builtin, or a *Module of them
 }{
 	{"len", &Builtin{Fn: builtin_len}},
 	{"puts", &Builtin{Fn: builtin_puts}},
@@ -14,6 +20,69 @@ var Builtins = []struct {
 	{"last", &Builtin{Fn: builtin_last}},
 	{"rest", &Builtin{Fn: builtin_rest}},
 	{"push", &Builtin{Fn: builtin_push}},
+	{"int", &Builtin{Fn: builtin_int}},
+	{"float", &Builtin{Fn: builtin_float}},
+	{"str", &Builtin{Fn: builtin_str}},
+	{"decimal", &Builtin{Fn: builtin_decimal}},
+	{"rescale", &Builtin{Fn: builtin_rescale}},
+	{"set", &Builtin{Fn: builtin_set}},
+	{"add", &Builtin{Fn: builtin_add}},
+	{"remove", &Builtin{Fn: builtin_remove}},
+	{"has", &Builtin{Fn: builtin_has}},
+	{"union", &Builtin{Fn: builtin_union}},
+	{"intersection", &Builtin{Fn: builtin_intersection}},
+	{"difference", &Builtin{Fn: builtin_difference}},
+	{"subset", &Builtin{Fn: builtin_subset}},
+	{"bytes", &Builtin{Fn: builtin_bytes}},
+	{"decode", &Builtin{Fn: builtin_decode}},
+	{"read_int", &Builtin{Fn: builtin_read_int}},
+	{"read_uint", &Builtin{Fn: builtin_read_uint}},
+	{"ord", &Builtin{Fn: builtin_ord}},
+	{"chr", &Builtin{Fn: builtin_chr}},
+	{"split", &Builtin{Fn: builtin_split}},
+	{"join", &Builtin{Fn: builtin_join}},
+	{"trim", &Builtin{Fn: builtin_trim}},
+	{"trim_left", &Builtin{Fn: builtin_trim_left}},
+	{"trim_right", &Builtin{Fn: builtin_trim_right}},
+	{"replace", &Builtin{Fn: builtin_replace}},
+	{"contains", &Builtin{Fn: builtin_contains}},
+	{"starts_with", &Builtin{Fn: builtin_starts_with}},
+	{"ends_with", &Builtin{Fn: builtin_ends_with}},
+	{"index_of", &Builtin{Fn: builtin_index_of}},
+	{"upper", &Builtin{Fn: builtin_upper}},
+	{"lower", &Builtin{Fn: builtin_lower}},
+	{"repeat", &Builtin{Fn: builtin_repeat}},
+	{"pad_left", &Builtin{Fn: builtin_pad_left}},
+	{"pad_right", &Builtin{Fn: builtin_pad_right}},
+	{"math", mathModule},
+	{"map", &Builtin{HostFn: builtin_map}},
+	{"filter", &Builtin{HostFn: builtin_filter}},
+	{"reduce", &Builtin{HostFn: builtin_reduce}},
+	{"sort_by", &Builtin{HostFn: builtin_sort_by}},
+	{"each", &Builtin{HostFn: builtin_each}},
+	{"keys", &Builtin{Fn: builtin_keys}},
+	{"values", &Builtin{Fn: builtin_values}},
+	{"entries", &Builtin{Fn: builtin_entries}},
+	{"delete", &Builtin{Fn: builtin_delete}},
+	{"merge", &Builtin{Fn: builtin_merge}},
+	{"get", &Builtin{Fn: builtin_get}},
+	{"from_entries", &Builtin{Fn: builtin_from_entries}},
+	{"json_parse", &Builtin{Fn: builtin_json_parse}},
+	{"json_stringify", &Builtin{Fn: builtin_json_stringify}},
+	{"read_file", &Builtin{HostFn: builtin_read_file}},
+	{"write_file", &Builtin{HostFn: builtin_write_file}},
+	{"append_file", &Builtin{HostFn: builtin_append_file}},
+	{"list_dir", &Builtin{HostFn: builtin_list_dir}},
+	{"exists", &Builtin{HostFn: builtin_exists}},
+	{"regex", &Builtin{Fn: builtin_regex}},
+	{"now", &Builtin{HostFn: builtin_now}},
+	{"unix", &Builtin{HostFn: builtin_unix}},
+	{"format_time", &Builtin{Fn: builtin_format_time}},
+	{"parse_time", &Builtin{Fn: builtin_parse_time}},
+	{"duration", &Builtin{Fn: builtin_duration}},
+	{"sleep", &Builtin{HostFn: builtin_sleep}},
+	{"format", &Builtin{Fn: builtin_format}},
+	{"remove_file", &Builtin{HostFn: builtin_remove_file}},
 }
 
 func newError(format string, a ...interface{}) *Error {
@@ -27,9 +96,21 @@ func builtin_len(args ...Object) Object {
 
 	switch arg := args[0].(type) {
 	case *Array:
-		return &Number{Value: float64(len(arg.Elements))}
+		return &Integer{Value: int64(len(arg.Elements))}
 	case *String:
-		return &Number{Value: float64(len(arg.Value))}
+		return &Integer{Value: arg.Len()}
+	case *Range:
+		n, err := arg.Len()
+		if err != nil {
+			return newError("%s", err)
+		}
+		return &Integer{Value: n}
+	case *Set:
+		return &Integer{Value: int64(arg.Len())}
+	case *Tuple:
+		return &Integer{Value: int64(len(arg.Elements))}
+	case *Bytes:
+		return &Integer{Value: int64(len(arg.Value))}
 	}
 
 	return newError("argument to `len` not supported, got %s", args[0].Type())
@@ -48,6 +129,13 @@ func builtin_first(args ...Object) Object {
 		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
 	}
 
+	if r, ok := args[0].(*Range); ok {
+		if v, ok := r.At(0); ok {
+			return &Integer{Value: v}
+		}
+		return nil
+	}
+
 	if args[0].Type() != ArrayObj {
 		return newError("argument to `first` must be an ARRAY, got %s", args[0].Type())
 	}
@@ -65,6 +153,13 @@ func builtin_last(args ...Object) Object {
 		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
 	}
 
+	if r, ok := args[0].(*Range); ok {
+		if v, ok := r.Last(); ok {
+			return &Integer{Value: v}
+		}
+		return nil
+	}
+
 	if args[0].Type() != ArrayObj {
 		return newError("argument to `last` must be an ARRAY, got %s", args[0].Type())
 	}
@@ -83,6 +178,13 @@ func builtin_rest(args ...Object) Object {
 		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
 	}
 
+	if r, ok := args[0].(*Range); ok {
+		if r.has(0) {
+			return r.Rest()
+		}
+		return nil
+	}
+
 	if args[0].Type() != ArrayObj {
 		return newError("argument to `rest` must be an ARRAY, got %s", args[0].Type())
 	}
@@ -117,7 +219,134 @@ func builtin_push(args ...Object) Object {
 	return &Array{Elements: newEls}
 }
 
-func GetBuiltinByName(name string) *Builtin {
+func builtin_int(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer, *BigInt:
+		return arg
+	case *Float:
+		if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+			return newError("cannot convert %s to INTEGER", arg.Inspect())
+		}
+		v, _ := big.NewFloat(math.Trunc(arg.Value)).Int(nil)
+		return NewBigInt(v)
+	case *Decimal:
+		return NewBigInt(arg.Rescale(0, RoundDown).Unscaled)
+	case *String:
+		v, ok := new(big.Int).SetString(arg.Value, 10)
+		if !ok {
+			return newError("could not parse %q as integer", arg.Value)
+		}
+		return NewBigInt(v)
+	}
+
+	return newError("argument to `int` not supported, got %s", args[0].Type())
+}
+
+func builtin_float(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
+	}
+
+	if v, ok := ToFloat(args[0]); ok {
+		return &Float{Value: v}
+	}
+
+	switch arg := args[0].(type) {
+	case *Decimal:
+		v, _ := strconv.ParseFloat(arg.Inspect(), 64)
+		return &Float{Value: v}
+	case *String:
+		v, err := strconv.ParseFloat(arg.Value, 64)
+		if err != nil {
+			return newError("could not parse %q as float", arg.Value)
+		}
+		return &Float{Value: v}
+	}
+
+	return newError("argument to `float` not supported, got %s", args[0].Type())
+}
+
+func builtin_str(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
+	}
+
+	if s, ok := args[0].(*String); ok {
+		return s
+	}
+
+	return &String{Value: args[0].Inspect()}
+}
+
+func builtin_decimal(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
+	}
+
+	if d, ok := ToDecimal(args[0]); ok {
+		return d
+	}
+
+	var s string
+	switch arg := args[0].(type) {
+	case *String:
+		s = arg.Value
+	case *Float:
+		if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+			return newError("cannot convert %s to DECIMAL", arg.Inspect())
+		}
+		s = strconv.FormatFloat(arg.Value, 'f', -1, 64)
+	default:
+		return newError("argument to `decimal` not supported, got %s", args[0].Type())
+	}
+
+	d, err := ParseDecimal(s)
+	if err != nil {
+		return newError("%s", err)
+	}
+	return d
+}
+
+func builtin_rescale(args ...Object) Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError("wrong number of arguments. expected=2 or 3, got=%d", len(args))
+	}
+
+	d, ok := ToDecimal(args[0])
+	if !ok {
+		return newError("argument to `rescale` must be a DECIMAL, got %s", args[0].Type())
+	}
+
+	scale, ok := args[1].(*Integer)
+	if !ok {
+		return newError("scale for `rescale` must be an INTEGER, got %s", args[1].Type())
+	}
+	if scale.Value < 0 || scale.Value > maxDecimalScale {
+		return newError("scale for `rescale` out of range: %d", scale.Value)
+	}
+
+	mode := RoundHalfEven
+	if len(args) == 3 {
+		name, ok := args[2].(*String)
+		if !ok {
+			return newError("rounding mode for `rescale` must be a STRING, got %s", args[2].Type())
+		}
+
+		var err error
+		mode, err = ParseRoundingMode(name.Value)
+		if err != nil {
+			return newError("%s", err)
+		}
+	}
+
+	return d.Rescale(int32(scale.Value), mode)
+}
+
+func GetBuiltinByName(name string) Object {
 	for _, def := range Builtins {
 		if def.Name == name {
 			return def.Builtin
@@ -126,3 +355,289 @@ func GetBuiltinByName(name string) *Builtin {
 
 	return nil
 }
+
+func builtin_set(args ...Object) Object {
+	if len(args) > 1 {
+		return newError("wrong number of arguments. expected=0 or 1, got=%d", len(args))
+	}
+
+	set := NewSet()
+	if len(args) == 0 {
+		return set
+	}
+
+	it, err := NewIterator(args[0])
+	if err != nil {
+		return newError("argument to `set` not supported, got %s", args[0].Type())
+	}
+	for it.Next() {
+		if err := set.Add(it.Item()); err != nil {
+			return newError("%s", err)
+		}
+	}
+
+	return set
+}
+
+func builtin_add(args ...Object) Object {
+	return updateSet("add", args, (*Set).Add)
+}
+
+func builtin_remove(args ...Object) Object {
+	return updateSet("remove", args, (*Set).Remove)
+}
+
+// updateSet applies update to a copy of the set in args[0], with the element in args[1].
+func updateSet(name string, args []Object, update func(*Set, Object) error) Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. expected=%d, got=%d", 2, len(args))
+	}
+
+	set, ok := args[0].(*Set)
+	if !ok {
+		return newError("argument to `%s` must be a SET, got %s", name, args[0].Type())
+	}
+
+	set = set.Copy()
+	if err := update(set, args[1]); err != nil {
+		return newError("%s", err)
+	}
+	return set
+}
+
+func builtin_has(args ...Object) Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. expected=%d, got=%d", 2, len(args))
+	}
+
+	var has bool
+	var err error
+	switch arg := args[0].(type) {
+	case *Set:
+		has, err = arg.Has(args[1])
+	case *Hash:
+		_, has, err = arg.Get(args[1])
+	default:
+		return newError("argument to `has` must be a SET or HASH, got %s", args[0].Type())
+	}
+	if err != nil {
+		return newError("%s", err)
+	}
+	return NativeBool(has)
+}
+
+func builtin_union(args ...Object) Object {
+	a, b, errObj := setPair("union", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	set := a.Copy()
+	for _, el := range b.Elements() {
+		_ = set.Add(el)
+	}
+	return set
+}
+
+func builtin_intersection(args ...Object) Object {
+	a, b, errObj := setPair("intersection", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	set := NewSet()
+	for _, el := range a.Elements() {
+		if ok, _ := b.Has(el); ok {
+			_ = set.Add(el)
+		}
+	}
+	return set
+}
+
+func builtin_difference(args ...Object) Object {
+	a, b, errObj := setPair("difference", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	set := NewSet()
+	for _, el := range a.Elements() {
+		if ok, _ := b.Has(el); !ok {
+			_ = set.Add(el)
+		}
+	}
+	return set
+}
+
+func builtin_subset(args ...Object) Object {
+	a, b, errObj := setPair("subset", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	return NativeBool(a.IsSubset(b))
+}
+
+// setPair checks that args holds exactly two sets.
+func setPair(name string, args []Object) (*Set, *Set, *Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments. expected=%d, got=%d", 2, len(args))
+	}
+
+	a, ok := args[0].(*Set)
+	if !ok {
+		return nil, nil, newError("first argument to `%s` must be a SET, got %s", name, args[0].Type())
+	}
+	b, ok := args[1].(*Set)
+	if !ok {
+		return nil, nil, newError("second argument to `%s` must be a SET, got %s", name, args[1].Type())
+	}
+
+	return a, b, nil
+}
+
+func builtin_bytes(args ...Object) Object {
+	if len(args) < 1 || len(args) > 2 {
+		return newError("wrong number of arguments. expected=1 or 2, got=%d", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *Bytes:
+		return arg
+	case *String:
+		encoding, errObj := encodingArg("bytes", args)
+		if errObj != nil {
+			return errObj
+		}
+
+		b, err := Encode(arg.Value, encoding)
+		if err != nil {
+			return newError("%s", err)
+		}
+		return &Bytes{Value: b}
+	case *Array:
+		b := make([]byte, len(arg.Elements))
+		for i, el := range arg.Elements {
+			n, ok := el.(*Integer)
+			if !ok || n.Value < 0 || n.Value > 255 {
+				return newError("byte values must be INTEGER between 0 and 255, got %s", el.Inspect())
+			}
+			b[i] = byte(n.Value)
+		}
+		return &Bytes{Value: b}
+	}
+
+	return newError("argument to `bytes` not supported, got %s", args[0].Type())
+}
+
+func builtin_decode(args ...Object) Object {
+	if len(args) < 1 || len(args) > 2 {
+		return newError("wrong number of arguments. expected=1 or 2, got=%d", len(args))
+	}
+
+	b, ok := args[0].(*Bytes)
+	if !ok {
+		return newError("argument to `decode` must be BYTES, got %s", args[0].Type())
+	}
+
+	encoding, errObj := encodingArg("decode", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	s, err := Decode(b.Value, encoding)
+	if err != nil {
+		return newError("%s", err)
+	}
+	return &String{Value: s}
+}
+
+// encodingArg returns the optional encoding name in args[1], which defaults to utf-8.
+func encodingArg(name string, args []Object) (string, *Error) {
+	if len(args) < 2 {
+		return "utf-8", nil
+	}
+
+	s, ok := args[1].(*String)
+	if !ok {
+		return "", newError("encoding given to `%s` must be a STRING, got %s", name, args[1].Type())
+	}
+	return s.Value, nil
+}
+
+func builtin_read_int(args ...Object) Object {
+	return readInt("read_int", args, true)
+}
+
+func builtin_read_uint(args ...Object) Object {
+	return readInt("read_uint", args, false)
+}
+
+// readInt implements read_int(b, offset, width, order) and read_uint, where order is "big" or
+// "little" and defaults to "big".
+func readInt(name string, args []Object, signed bool) Object {
+	if len(args) < 3 || len(args) > 4 {
+		return newError("wrong number of arguments. expected=3 or 4, got=%d", len(args))
+	}
+
+	b, ok := args[0].(*Bytes)
+	if !ok {
+		return newError("first argument to `%s` must be BYTES, got %s", name, args[0].Type())
+	}
+	offset, ok := args[1].(*Integer)
+	if !ok {
+		return newError("offset given to `%s` must be an INTEGER, got %s", name, args[1].Type())
+	}
+	width, ok := args[2].(*Integer)
+	if !ok {
+		return newError("width given to `%s` must be an INTEGER, got %s", name, args[2].Type())
+	}
+
+	order := "big"
+	if len(args) == 4 {
+		s, ok := args[3].(*String)
+		if !ok {
+			return newError("byte order given to `%s` must be a STRING, got %s", name, args[3].Type())
+		}
+		order = s.Value
+	}
+
+	v, err := ReadInt(b.Value, offset.Value, width.Value, order, signed)
+	if err != nil {
+		return newError("%s", err)
+	}
+	return v
+}
+
+func builtin_ord(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
+	}
+
+	s, ok := args[0].(*String)
+	if !ok {
+		return newError("argument to `ord` must be a STRING, got %s", args[0].Type())
+	}
+
+	r, size := utf8.DecodeRuneInString(s.Value)
+	if size == 0 || size != len(s.Value) {
+		return newError("argument to `ord` must be a single character, got %q", s.Value)
+	}
+	return &Integer{Value: int64(r)}
+}
+
+func builtin_chr(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. expected=%d, got=%d", 1, len(args))
+	}
+
+	n, ok := args[0].(*Integer)
+	if !ok {
+		return newError("argument to `chr` must be an INTEGER, got %s", args[0].Type())
+	}
+
+	if n.Value < 0 || n.Value > utf8.MaxRune || !utf8.ValidRune(rune(n.Value)) {
+		return newError("invalid code point: %d", n.Value)
+	}
+	return &String{Value: string(rune(n.Value))}
+}
@@ -0,0 +1,140 @@
+package object
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"unicode/utf8"
+)
+
+// Bytes is an immutable sequence of bytes, written b"\x00abc". Indexing yields integers between
+// 0 and 255.
+type Bytes struct {
+	Value []byte
+}
+
+func (b *Bytes) Type() ObjectType { return BytesObj }
+func (b *Bytes) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString(`b"`)
+	for _, c := range b.Value {
+		switch {
+		case c == '"' || c == '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		case c >= 0x20 && c < 0x7f:
+			out.WriteByte(c)
+		default:
+			fmt.Fprintf(&out, `\x%02x`, c)
+		}
+	}
+	out.WriteByte('"')
+
+	return out.String()
+}
+func (b *Bytes) HashKey() HashKey {
+	h := fnv.New64a()
+	_, _ = h.Write(b.Value)
+
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+// Encode returns s in the named encoding: "utf-8", "ascii" or "latin-1". It fails if s holds
+// characters the encoding cannot represent.
+func Encode(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case "utf-8":
+		return []byte(s), nil
+	case "ascii", "latin-1":
+		max := rune(0x7f)
+		if encoding == "latin-1" {
+			max = 0xff
+		}
+
+		out := make([]byte, 0, len(s))
+		for _, r := range s {
+			if r > max {
+				return nil, fmt.Errorf("cannot encode %q as %s", r, encoding)
+			}
+			out = append(out, byte(r))
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("unknown encoding: %s", encoding)
+}
+
+// Decode returns the string held in b in the named encoding, failing if b is not valid in it.
+func Decode(b []byte, encoding string) (string, error) {
+	switch encoding {
+	case "utf-8":
+		if !utf8.Valid(b) {
+			return "", fmt.Errorf("bytes are not valid utf-8")
+		}
+		return string(b), nil
+	case "ascii":
+		for _, c := range b {
+			if c > 0x7f {
+				return "", fmt.Errorf("bytes are not valid ascii")
+			}
+		}
+		return string(b), nil
+	case "latin-1":
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes), nil
+	}
+
+	return "", fmt.Errorf("unknown encoding: %s", encoding)
+}
+
+// ReadInt reads an integer of width bytes (1, 2, 4 or 8) from b at offset, in "big" or "little"
+// endian order. Signed integers are read in two's complement.
+func ReadInt(b []byte, offset int64, width int64, order string, signed bool) (Object, error) {
+	var bo binary.ByteOrder
+	switch order {
+	case "big":
+		bo = binary.BigEndian
+	case "little":
+		bo = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("unknown byte order: %s", order)
+	}
+
+	switch width {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("unsupported integer width: %d", width)
+	}
+
+	if offset < 0 || offset > int64(len(b))-width {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %d bytes", width, offset, len(b))
+	}
+
+	buf := b[offset : offset+width]
+	var v uint64
+	switch width {
+	case 1:
+		v = uint64(buf[0])
+	case 2:
+		v = uint64(bo.Uint16(buf))
+	case 4:
+		v = uint64(bo.Uint32(buf))
+	case 8:
+		v = bo.Uint64(buf)
+	}
+
+	if signed {
+		shift := 64 - 8*uint(width)
+		return &Integer{Value: int64(v<<shift) >> shift}, nil
+	}
+	if v > 1<<63-1 {
+		return NewBigInt(new(big.Int).SetUint64(v)), nil
+	}
+	return &Integer{Value: int64(v)}, nil
+}
@@ -0,0 +1,295 @@
+package object
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"strings"
+)
+
+// RoundingMode selects how a Decimal is rounded when digits are dropped.
+type RoundingMode int
+
+const (
+	RoundHalfEven RoundingMode = iota
+	RoundHalfUp
+	RoundHalfDown
+	RoundUp
+	RoundDown
+	RoundCeiling
+	RoundFloor
+)
+
+var roundingModes = map[string]RoundingMode{
+	"half_even": RoundHalfEven,
+	"half_up":   RoundHalfUp,
+	"half_down": RoundHalfDown,
+	"up":        RoundUp,
+	"down":      RoundDown,
+	"ceiling":   RoundCeiling,
+	"floor":     RoundFloor,
+}
+
+// ParseRoundingMode returns the rounding mode with the given name, such as "half_up".
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	mode, ok := roundingModes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown rounding mode: %s", name)
+	}
+	return mode, nil
+}
+
+// maxDecimalScale bounds the scale, the digits after the point, that `rescale` and
+// multiplication may give a decimal.
+const maxDecimalScale = 1000
+
+// DecimalContext controls division of decimals, which is the only arithmetic operation which
+// may need to drop digits. Each engine carries its own, which hosts may change before running.
+type DecimalContext struct {
+	DivisionScale int32
+	Rounding      RoundingMode
+}
+
+// DefaultDecimalContext divides to 16 digits after the point, rounding half to even.
+func DefaultDecimalContext() DecimalContext {
+	return DecimalContext{DivisionScale: 16, Rounding: RoundHalfEven}
+}
+
+// Decimal is an exact base 10 number, Unscaled * 10^-Scale. The scale is kept through
+// arithmetic so that 1.10d prints as 1.10 and 1.10d * 2 as 2.20.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int32
+}
+
+// ParseDecimal parses a decimal such as "-12.50".
+func ParseDecimal(s string) (*Decimal, error) {
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+		if frac == "" {
+			return nil, fmt.Errorf("could not parse %q as decimal", s)
+		}
+	}
+	if strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		whole = whole[1:]
+	}
+	if whole == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return nil, fmt.Errorf("could not parse %q as decimal", s)
+	}
+
+	v, _ := new(big.Int).SetString(strings.Replace(s, ".", "", 1), 10)
+	return &Decimal{Unscaled: v, Scale: int32(len(frac))}, nil
+}
+
+// ToDecimal converts an INTEGER, BIGINT or DECIMAL to a Decimal.
+func ToDecimal(obj Object) (*Decimal, bool) {
+	switch obj := obj.(type) {
+	case *Decimal:
+		return obj, true
+	case *Integer, *BigInt:
+		v, _ := ToBig(obj)
+		return &Decimal{Unscaled: v, Scale: 0}, true
+	}
+	return nil, false
+}
+
+// IsDecimalOperation reports whether an operation on left and right should be carried out on
+// decimals: at least one must be a DECIMAL and the other a DECIMAL or an integer. Decimals do
+// not mix with floats, which would lose their exactness.
+func IsDecimalOperation(left, right Object) bool {
+	lt, rt := left.Type(), right.Type()
+	if lt != DecimalObj && rt != DecimalObj {
+		return false
+	}
+	return (lt == DecimalObj || IsInteger(left)) && (rt == DecimalObj || IsInteger(right))
+}
+
+func (d *Decimal) Type() ObjectType { return DecimalObj }
+func (d *Decimal) Inspect() string {
+	s := new(big.Int).Abs(d.Unscaled).String()
+	if d.Scale > 0 {
+		if pad := int(d.Scale) - len(s) + 1; pad > 0 {
+			s = strings.Repeat("0", pad) + s
+		}
+		s = s[:len(s)-int(d.Scale)] + "." + s[len(s)-int(d.Scale):]
+	}
+
+	if d.Unscaled.Sign() < 0 {
+		return "-" + s
+	}
+	return s
+}
+
+// HashKey hashes the value without trailing zeros, so that 1.10d and 1.1d share a key, and gives
+// whole decimals the key of the equal integer.
+func (d *Decimal) HashKey() HashKey {
+	n := d.normalize()
+	if n.Scale == 0 {
+		return NewBigInt(n.Unscaled).(Hashable).HashKey()
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(n.Inspect()))
+	return HashKey{Type: d.Type(), Value: h.Sum64()}
+}
+
+// Rescale returns d with the given scale, rounding with mode if digits are dropped.
+func (d *Decimal) Rescale(scale int32, mode RoundingMode) *Decimal {
+	if scale >= d.Scale {
+		v := new(big.Int).Mul(d.Unscaled, pow10(scale-d.Scale))
+		return &Decimal{Unscaled: v, Scale: scale}
+	}
+
+	return &Decimal{Unscaled: roundQuo(d.Unscaled, pow10(d.Scale-scale), mode), Scale: scale}
+}
+
+// normalize strips trailing zeros from the fractional part.
+func (d *Decimal) normalize() *Decimal {
+	v := new(big.Int).Set(d.Unscaled)
+	scale := d.Scale
+	return trimZeros(v, scale, 0)
+}
+
+func trimZeros(v *big.Int, scale, min int32) *Decimal {
+	ten := big.NewInt(10)
+	q, r := new(big.Int), new(big.Int)
+	for scale > min && v.Sign() != 0 {
+		q.QuoRem(v, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		v.Set(q)
+		scale--
+	}
+	if v.Sign() == 0 && scale > min {
+		scale = min
+	}
+	return &Decimal{Unscaled: v, Scale: scale}
+}
+
+// align returns the unscaled values of a and b at their common, larger scale.
+func align(a, b *Decimal) (*big.Int, *big.Int, int32) {
+	switch {
+	case a.Scale > b.Scale:
+		return a.Unscaled, new(big.Int).Mul(b.Unscaled, pow10(a.Scale-b.Scale)), a.Scale
+	case b.Scale > a.Scale:
+		return new(big.Int).Mul(a.Unscaled, pow10(b.Scale-a.Scale)), b.Unscaled, b.Scale
+	}
+	return a.Unscaled, b.Unscaled, a.Scale
+}
+
+// Arithmetic applies one of + - * / to operands accepted by IsDecimalOperation. Addition and
+// subtraction keep the larger scale and multiplication adds the scales. Division is carried out
+// to DivisionScale digits, rounded with Rounding, and then trailing zeros are dropped down to
+// the larger scale of the operands.
+func (c DecimalContext) Arithmetic(operator string, left, right Object) (Object, error) {
+	l, ok := ToDecimal(left)
+	if !ok {
+		return nil, fmt.Errorf("expected decimal, got %s", left.Type())
+	}
+	r, ok := ToDecimal(right)
+	if !ok {
+		return nil, fmt.Errorf("expected decimal, got %s", right.Type())
+	}
+
+	switch operator {
+	case "+":
+		lv, rv, scale := align(l, r)
+		return &Decimal{Unscaled: new(big.Int).Add(lv, rv), Scale: scale}, nil
+	case "-":
+		lv, rv, scale := align(l, r)
+		return &Decimal{Unscaled: new(big.Int).Sub(lv, rv), Scale: scale}, nil
+	case "*":
+		if int64(l.Scale)+int64(r.Scale) > maxDecimalScale {
+			return nil, fmt.Errorf("decimal scale is larger than %d", maxDecimalScale)
+		}
+		return &Decimal{Unscaled: new(big.Int).Mul(l.Unscaled, r.Unscaled), Scale: l.Scale + r.Scale}, nil
+	case "/":
+		if r.Unscaled.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+
+		min := l.Scale
+		if r.Scale > min {
+			min = r.Scale
+		}
+		scale := c.DivisionScale
+		if min > scale {
+			scale = min
+		}
+
+		// l / r at scale s is (l.Unscaled * 10^(s - l.Scale + r.Scale)) / r.Unscaled.
+		n := new(big.Int).Set(l.Unscaled)
+		d := new(big.Int).Set(r.Unscaled)
+		if shift := scale - l.Scale + r.Scale; shift >= 0 {
+			n.Mul(n, pow10(shift))
+		} else {
+			d.Mul(d, pow10(-shift))
+		}
+		if d.Sign() < 0 {
+			n.Neg(n)
+			d.Neg(d)
+		}
+
+		return trimZeros(roundQuo(n, d, c.Rounding), scale, min), nil
+	}
+
+	return nil, fmt.Errorf("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+// CompareDecimals returns -1, 0 or 1 as left is less than, equal to or greater than right,
+// which must be accepted by IsDecimalOperation.
+func CompareDecimals(left, right Object) int {
+	l, _ := ToDecimal(left)
+	r, _ := ToDecimal(right)
+	lv, rv, _ := align(l, r)
+	return lv.Cmp(rv)
+}
+
+// roundQuo divides n by the positive d, rounding the quotient with mode.
+func roundQuo(n, d *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	neg := n.Sign() < 0
+	var away bool
+	switch mode {
+	case RoundUp:
+		away = true
+	case RoundDown:
+		away = false
+	case RoundCeiling:
+		away = !neg
+	case RoundFloor:
+		away = neg
+	default:
+		half := new(big.Int).Abs(r)
+		half.Lsh(half, 1)
+		switch cmp := half.Cmp(d); {
+		case cmp > 0:
+			away = true
+		case cmp < 0:
+			away = false
+		case mode == RoundHalfUp:
+			away = true
+		case mode == RoundHalfDown:
+			away = false
+		default:
+			away = q.Bit(0) == 1
+		}
+	}
+
+	if away && neg {
+		q.Sub(q, big.NewInt(1))
+	} else if away {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -1,8 +1,11 @@
 package object
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	outer   *Environment
+	fs      FS
+	clock   Clock
+	decimal *DecimalContext
 }
 
 func NewEnvironment() *Environment {
@@ -29,3 +32,56 @@ func (e *Environment) Set(name string, obj Object) Object {
 	e.store[name] = obj
 	return obj
 }
+
+// SetFS gives the file builtins run in this environment, and those enclosed by it, access to
+// fsys.
+func (e *Environment) SetFS(fsys FS) {
+	e.fs = fsys
+}
+
+// FS returns the file system set on this environment or the nearest one enclosing it.
+func (e *Environment) FS() FS {
+	if e.fs == nil && e.outer != nil {
+		return e.outer.FS()
+	}
+
+	return e.fs
+}
+
+// SetClock makes the time builtins run in this environment, and those enclosed by it, use
+// clock.
+func (e *Environment) SetClock(clock Clock) {
+	e.clock = clock
+}
+
+// Clock returns the clock set on this environment or the nearest one enclosing it, or the
+// SystemClock if there is none.
+func (e *Environment) Clock() Clock {
+	if e.clock != nil {
+		return e.clock
+	}
+	if e.outer != nil {
+		return e.outer.Clock()
+	}
+
+	return SystemClock
+}
+
+// SetDecimalContext makes decimal division in this environment, and those enclosed by it, use
+// ctx.
+func (e *Environment) SetDecimalContext(ctx DecimalContext) {
+	e.decimal = &ctx
+}
+
+// DecimalContext returns the decimal context set on this environment or the nearest one
+// enclosing it, or the DefaultDecimalContext if there is none.
+func (e *Environment) DecimalContext() DecimalContext {
+	if e.decimal != nil {
+		return *e.decimal
+	}
+	if e.outer != nil {
+		return e.outer.DecimalContext()
+	}
+
+	return DefaultDecimalContext()
+}
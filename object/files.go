@@ -0,0 +1,135 @@
+package object
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+)
+
+// The file builtins. They work only inside the FS the host provides, taking slash-separated
+// paths relative to its root; a path that would lead outside it is an error.
+
+func builtin_read_file(host *Host, args ...Object) Object {
+	fsys, name, errObj := fileArgs("read_file", host, args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fileError(err)
+	}
+	return &String{Value: string(data)}
+}
+
+func builtin_write_file(host *Host, args ...Object) Object {
+	return writeFile("write_file", host, args, FS.WriteFile)
+}
+
+func builtin_append_file(host *Host, args ...Object) Object {
+	return writeFile("append_file", host, args, FS.AppendFile)
+}
+
+// writeFile passes the path and the STRING or BYTES content in args to write.
+func writeFile(name string, host *Host, args []Object, write func(FS, string, []byte) error) Object {
+	fsys, file, errObj := fileArgs(name, host, args, 2)
+	if errObj != nil {
+		return errObj
+	}
+
+	var data []byte
+	switch arg := args[1].(type) {
+	case *String:
+		data = []byte(arg.Value)
+	case *Bytes:
+		data = arg.Value
+	default:
+		return newError("second argument to `%s` must be a STRING or BYTES, got %s", name, args[1].Type())
+	}
+
+	if err := write(fsys, file, data); err != nil {
+		return fileError(err)
+	}
+	return nil
+}
+
+func builtin_list_dir(host *Host, args ...Object) Object {
+	if len(args) == 0 {
+		args = []Object{&String{Value: "."}}
+	}
+	fsys, name, errObj := fileArgs("list_dir", host, args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return fileError(err)
+	}
+
+	els := make([]Object, len(entries))
+	for i, entry := range entries {
+		els[i] = &String{Value: entry.Name()}
+	}
+	return &Array{Elements: els}
+}
+
+func builtin_exists(host *Host, args ...Object) Object {
+	fsys, name, errObj := fileArgs("exists", host, args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	_, err := fs.Stat(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return False
+	}
+	if err != nil {
+		return fileError(err)
+	}
+	return True
+}
+
+func builtin_remove_file(host *Host, args ...Object) Object {
+	fsys, name, errObj := fileArgs("remove_file", host, args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	if err := fsys.Remove(name); err != nil {
+		return fileError(err)
+	}
+	return nil
+}
+
+// fileArgs checks that the host allows file access and that args hold n arguments, the first
+// a path inside the root, which it returns cleaned.
+func fileArgs(name string, host *Host, args []Object, n int) (FS, string, *Error) {
+	if errObj := checkArgCount(args, n, n); errObj != nil {
+		return nil, "", errObj
+	}
+	if host.FS == nil {
+		return nil, "", newError("`%s` is unavailable: file access is not enabled", name)
+	}
+
+	file, errObj := stringArg(name, args, 0)
+	if errObj != nil {
+		return nil, "", errObj
+	}
+
+	cleaned := path.Clean(file)
+	if !fs.ValidPath(cleaned) {
+		return nil, "", newError("path %q is outside the root directory", file)
+	}
+	return host.FS, cleaned, nil
+}
+
+// fileError reports err without the name of the system call that failed, which varies with
+// the platform.
+func fileError(err error) *Error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return newError("%s: %s", pathErr.Path, pathErr.Err)
+	}
+	return newError("%s", err)
+}
@@ -0,0 +1,217 @@
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFormatWidth bounds the width and precision a `format` directive may ask for.
+const maxFormatWidth = 1000
+
+// formatSpec holds the flags, width and precision of one `format` directive. Width and
+// precision are -1 when absent.
+type formatSpec struct {
+	flags     string
+	width     int
+	precision int
+}
+
+// goFormat returns the equivalent fmt directive for verb.
+func (s formatSpec) goFormat(verb byte) string {
+	var b strings.Builder
+	b.WriteByte('%')
+	b.WriteString(s.flags)
+	if s.width >= 0 {
+		b.WriteString(strconv.Itoa(s.width))
+	}
+	if s.precision >= 0 {
+		b.WriteByte('.')
+		b.WriteString(strconv.Itoa(s.precision))
+	}
+	b.WriteByte(verb)
+	return b.String()
+}
+
+// builtin_format formats its arguments printf-style. Directives take the flags - + space 0 #,
+// a width and a precision, and one of the verbs:
+//
+//	%d  an INTEGER or BIGINT
+//	%f  a number, with 6 decimal places unless a precision is given or it is a DECIMAL
+//	%s  a STRING or BYTES
+//	%q  a STRING, quoted
+//	%x  an INTEGER or BIGINT in hexadecimal, or the bytes of a STRING or BYTES
+//	%v  any value, as `puts` would show it
+//
+// %% stands for a literal %.
+func builtin_format(args ...Object) Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. expected at least 1, got=0")
+	}
+	f, errObj := stringArg("format", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	var out strings.Builder
+	next := 1
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			out.WriteByte(f[i])
+			continue
+		}
+
+		start := i
+		i++
+		if i < len(f) && f[i] == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		spec := formatSpec{width: -1, precision: -1}
+		for ; i < len(f) && strings.IndexByte("-+ 0#", f[i]) >= 0; i++ {
+			spec.flags += f[i : i+1]
+		}
+		var ok bool
+		if spec.width, i, ok = readFormatNumber(f, i); !ok {
+			return newError("width in `format` is larger than %d", maxFormatWidth)
+		}
+		if i < len(f) && f[i] == '.' {
+			if spec.precision, i, ok = readFormatNumber(f, i+1); !ok {
+				return newError("precision in `format` is larger than %d", maxFormatWidth)
+			}
+			if spec.precision < 0 {
+				spec.precision = 0
+			}
+		}
+		if i >= len(f) {
+			return newError("`format` ends with an incomplete directive %s", f[start:])
+		}
+
+		_, size := utf8.DecodeRuneInString(f[i:])
+		i += size - 1
+		directive := f[start : i+1]
+		if size > 1 || strings.IndexByte("dfsqxv", f[i]) < 0 {
+			return newError("unknown verb %s in `format`", directive)
+		}
+		if next >= len(args) {
+			return newError("`format` is missing an argument for %s", directive)
+		}
+
+		s, errObj := formatValue(spec, f[i], directive, args[next])
+		if errObj != nil {
+			return errObj
+		}
+		out.WriteString(s)
+		next++
+	}
+
+	if next < len(args) {
+		return newError("`format` has %d arguments but only uses %d", len(args)-1, next-1)
+	}
+	return &String{Value: out.String()}
+}
+
+// readFormatNumber reads the decimal number starting at f[i], returning -1 if there is none,
+// and the index following it. It reports false if the number is too large.
+func readFormatNumber(f string, i int) (int, int, bool) {
+	start := i
+	for i < len(f) && f[i] >= '0' && f[i] <= '9' {
+		i++
+	}
+	if i == start {
+		return -1, i, true
+	}
+
+	n, err := strconv.Atoi(f[start:i])
+	return n, i, err == nil && n <= maxFormatWidth
+}
+
+// formatValue formats arg for one directive, whose verb has already been checked.
+func formatValue(spec formatSpec, verb byte, directive string, arg Object) (string, *Error) {
+	switch verb {
+	case 'd':
+		switch arg := arg.(type) {
+		case *Integer:
+			return fmt.Sprintf(spec.goFormat('d'), arg.Value), nil
+		case *BigInt:
+			return fmt.Sprintf(spec.goFormat('d'), arg.Value), nil
+		}
+		return "", formatMismatch(directive, "an INTEGER", arg)
+	case 'f':
+		switch arg := arg.(type) {
+		case *Float:
+			return fmt.Sprintf(spec.goFormat('f'), arg.Value), nil
+		case *Integer, *BigInt:
+			if spec.precision < 0 {
+				spec.precision = 6
+			}
+			d, _ := ToDecimal(arg)
+			return formatDecimal(spec, d), nil
+		case *Decimal:
+			return formatDecimal(spec, arg), nil
+		}
+		return "", formatMismatch(directive, "a number", arg)
+	case 's':
+		switch arg := arg.(type) {
+		case *String:
+			return fmt.Sprintf(spec.goFormat('s'), arg.Value), nil
+		case *Bytes:
+			return fmt.Sprintf(spec.goFormat('s'), arg.Value), nil
+		}
+		return "", formatMismatch(directive, "a STRING or BYTES", arg)
+	case 'q':
+		if arg, ok := arg.(*String); ok {
+			return fmt.Sprintf(spec.goFormat('q'), arg.Value), nil
+		}
+		return "", formatMismatch(directive, "a STRING", arg)
+	case 'x':
+		switch arg := arg.(type) {
+		case *Integer:
+			return fmt.Sprintf(spec.goFormat('x'), arg.Value), nil
+		case *BigInt:
+			return fmt.Sprintf(spec.goFormat('x'), arg.Value), nil
+		case *String:
+			return fmt.Sprintf(spec.goFormat('x'), arg.Value), nil
+		case *Bytes:
+			return fmt.Sprintf(spec.goFormat('x'), arg.Value), nil
+		}
+		return "", formatMismatch(directive, "an INTEGER, STRING or BYTES", arg)
+	}
+
+	return fmt.Sprintf(spec.goFormat('s'), arg.Inspect()), nil
+}
+
+func formatMismatch(directive, expected string, arg Object) *Error {
+	return newError("%s in `format` needs %s, got %s", directive, expected, arg.Type())
+}
+
+// formatDecimal writes d exactly, rounded half to even to the precision if one is given, and
+// pads it as fmt pads numbers.
+func formatDecimal(spec formatSpec, d *Decimal) string {
+	if spec.precision >= 0 {
+		d = d.Rescale(int32(spec.precision), RoundHalfEven)
+	}
+
+	s, sign := d.Inspect(), ""
+	switch {
+	case strings.HasPrefix(s, "-"):
+		s, sign = s[1:], "-"
+	case strings.Contains(spec.flags, "+"):
+		sign = "+"
+	case strings.Contains(spec.flags, " "):
+		sign = " "
+	}
+
+	pad := spec.width - len(sign) - len(s)
+	switch {
+	case pad <= 0:
+		return sign + s
+	case strings.Contains(spec.flags, "-"):
+		return sign + s + strings.Repeat(" ", pad)
+	case strings.Contains(spec.flags, "0"):
+		return sign + strings.Repeat("0", pad) + s
+	}
+	return strings.Repeat(" ", pad) + sign + s
+}
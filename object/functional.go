@@ -0,0 +1,161 @@
+package object
+
+import "sort"
+
+// The higher-order builtins. They take their collection first and the function second, and
+// call back into Monkey through the Host supplied by whichever engine is running them.
+// Collections are walked the way a for loop walks them, so a HASH yields its keys.
+
+func builtin_map(host *Host, args ...Object) Object {
+	items, fn, errObj := functionalArgs("map", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	els := make([]Object, len(items))
+	for i, item := range items {
+		result := host.Call(fn, item)
+		if isError(result) {
+			return result
+		}
+		els[i] = result
+	}
+	return &Array{Elements: els}
+}
+
+func builtin_filter(host *Host, args ...Object) Object {
+	items, fn, errObj := functionalArgs("filter", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	els := []Object{}
+	for _, item := range items {
+		result := host.Call(fn, item)
+		if isError(result) {
+			return result
+		}
+		if truthy(result) {
+			els = append(els, item)
+		}
+	}
+	return &Array{Elements: els}
+}
+
+func builtin_reduce(host *Host, args ...Object) Object {
+	if errObj := checkArgCount(args, 2, 3); errObj != nil {
+		return errObj
+	}
+	items, fn, errObj := functionalArgs("reduce", args[:2])
+	if errObj != nil {
+		return errObj
+	}
+
+	var acc Object
+	if len(args) == 3 {
+		acc = args[2]
+	} else {
+		if len(items) == 0 {
+			return newError("`reduce` of an empty collection needs an initial value")
+		}
+		acc, items = items[0], items[1:]
+	}
+
+	for _, item := range items {
+		acc = host.Call(fn, acc, item)
+		if isError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+func builtin_sort_by(host *Host, args ...Object) Object {
+	items, fn, errObj := functionalArgs("sort_by", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	keys := make([]Object, len(items))
+	for i, item := range items {
+		keys[i] = host.Call(fn, item)
+		if isError(keys[i]) {
+			return keys[i]
+		}
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	var cmpErr error
+	sort.SliceStable(order, func(i, j int) bool {
+		cmp, err := Compare(keys[order[i]], keys[order[j]])
+		if err != nil && cmpErr == nil {
+			cmpErr = err
+		}
+		return cmp < 0
+	})
+	if cmpErr != nil {
+		return newError("%s", cmpErr)
+	}
+
+	els := make([]Object, len(items))
+	for i, ind := range order {
+		els[i] = items[ind]
+	}
+	return &Array{Elements: els}
+}
+
+func builtin_each(host *Host, args ...Object) Object {
+	items, fn, errObj := functionalArgs("each", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	for _, item := range items {
+		if result := host.Call(fn, item); isError(result) {
+			return result
+		}
+	}
+	return nil
+}
+
+// functionalArgs checks the (collection, function) arguments of a higher-order builtin and
+// returns the collection's items.
+func functionalArgs(name string, args []Object) ([]Object, Object, *Error) {
+	if errObj := checkArgCount(args, 2, 2); errObj != nil {
+		return nil, nil, errObj
+	}
+
+	iter, err := NewIterator(args[0])
+	if err != nil {
+		return nil, nil, newError("first argument to `%s` must be iterable, got %s", name, args[0].Type())
+	}
+	switch args[1].(type) {
+	case *Closure, *Function, *Builtin, Constructor:
+	default:
+		return nil, nil, newError("second argument to `%s` must be a function, got %s", name, args[1].Type())
+	}
+
+	var items []Object
+	for iter.Next() {
+		items = append(items, iter.Item())
+	}
+	return items, args[1], nil
+}
+
+func isError(obj Object) bool {
+	return obj != nil && obj.Type() == ErrorObj
+}
+
+// truthy reports whether obj counts as true in a condition: anything but false and null.
+func truthy(obj Object) bool {
+	switch obj := obj.(type) {
+	case *Boolean:
+		return obj.Value
+	case *Null:
+		return false
+	}
+	return obj != nil
+}
@@ -0,0 +1,201 @@
+package object
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+)
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash maps keys to values, remembering the order in which keys were first inserted. That order
+// is used for iteration and printing. Pairs are found through their HashKey and then compared with
+// Equal, so two keys which happen to share a HashKey are still kept apart.
+type Hash struct {
+	buckets map[HashKey][]*HashPair
+	order   []*HashPair
+}
+
+func NewHash() *Hash {
+	return &Hash{buckets: make(map[HashKey][]*HashPair)}
+}
+
+// HashKeyOf returns the hash key of obj. Arrays, tuples, hashes and sets are hashed by their contents
+// and may be used as keys when everything they contain may be. As they are never modified once
+// built, their keys do not change.
+func HashKeyOf(obj Object) (HashKey, error) {
+	switch obj := obj.(type) {
+	case Hashable:
+		return obj.HashKey(), nil
+	case *Array:
+		return hashElements(ArrayObj, obj.Elements)
+	case *Tuple:
+		return hashElements(TupleObj, obj.Elements)
+	case *Hash:
+		// The pairs are combined by addition so that the key does not depend on their order,
+		// just as Equal does not.
+		var sum uint64
+		for _, pair := range obj.order {
+			k, err := HashKeyOf(pair.Key)
+			if err != nil {
+				return HashKey{}, err
+			}
+			v, err := HashKeyOf(pair.Value)
+			if err != nil {
+				return HashKey{}, err
+			}
+
+			h := fnv.New64a()
+			writeHashKey(h, k)
+			writeHashKey(h, v)
+			sum += h.Sum64()
+		}
+		return HashKey{Type: HashObj, Value: sum}, nil
+	case *Set:
+		var sum uint64
+		for _, el := range obj.Elements() {
+			key, err := HashKeyOf(el)
+			if err != nil {
+				return HashKey{}, err
+			}
+
+			h := fnv.New64a()
+			writeHashKey(h, key)
+			sum += h.Sum64()
+		}
+		return HashKey{Type: SetObj, Value: sum}, nil
+	}
+
+	return HashKey{}, fmt.Errorf("unusable as hash key: %s", obj.Type())
+}
+
+func hashElements(t ObjectType, els []Object) (HashKey, error) {
+	h := fnv.New64a()
+	for _, el := range els {
+		key, err := HashKeyOf(el)
+		if err != nil {
+			return HashKey{}, err
+		}
+		writeHashKey(h, key)
+	}
+	return HashKey{Type: t, Value: h.Sum64()}, nil
+}
+
+func writeHashKey(w io.Writer, key HashKey) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], key.Value)
+	_, _ = w.Write([]byte(key.Type))
+	_, _ = w.Write(buf[:])
+}
+
+// Get returns the pair stored under key. It fails if key cannot be used as a hash key.
+func (h *Hash) Get(key Object) (HashPair, bool, error) {
+	hk, err := HashKeyOf(key)
+	if err != nil {
+		return HashPair{}, false, err
+	}
+
+	if pair := h.find(hk, key); pair != nil {
+		return *pair, true, nil
+	}
+	return HashPair{}, false, nil
+}
+
+// Set stores value under key. Replacing the value of an existing key keeps its position.
+func (h *Hash) Set(key, value Object) error {
+	hk, err := HashKeyOf(key)
+	if err != nil {
+		return err
+	}
+
+	if pair := h.find(hk, key); pair != nil {
+		pair.Value = value
+		return nil
+	}
+
+	pair := &HashPair{Key: key, Value: value}
+	h.buckets[hk] = append(h.buckets[hk], pair)
+	h.order = append(h.order, pair)
+	return nil
+}
+
+// Delete removes the pair stored under key, if there is one.
+func (h *Hash) Delete(key Object) error {
+	hk, err := HashKeyOf(key)
+	if err != nil {
+		return err
+	}
+
+	pair := h.find(hk, key)
+	if pair == nil {
+		return nil
+	}
+
+	h.buckets[hk] = removePair(h.buckets[hk], pair)
+	if len(h.buckets[hk]) == 0 {
+		delete(h.buckets, hk)
+	}
+	h.order = removePair(h.order, pair)
+	return nil
+}
+
+func (h *Hash) find(hk HashKey, key Object) *HashPair {
+	for _, pair := range h.buckets[hk] {
+		if pair.Key == key || Equal(pair.Key, key) {
+			return pair
+		}
+	}
+	return nil
+}
+
+func removePair(pairs []*HashPair, pair *HashPair) []*HashPair {
+	for i, p := range pairs {
+		if p == pair {
+			return append(pairs[:i:i], pairs[i+1:]...)
+		}
+	}
+	return pairs
+}
+
+// Copy returns a new hash holding the same pairs in the same order.
+func (h *Hash) Copy() *Hash {
+	hash := NewHash()
+	for _, pair := range h.order {
+		_ = hash.Set(pair.Key, pair.Value)
+	}
+	return hash
+}
+
+// Len returns the number of pairs in the hash.
+func (h *Hash) Len() int { return len(h.order) }
+
+// Ordered returns the pairs in insertion order.
+func (h *Hash) Ordered() []HashPair {
+	pairs := make([]HashPair, len(h.order))
+	for i, pair := range h.order {
+		pairs[i] = *pair
+	}
+	return pairs
+}
+
+func (h *Hash) Type() ObjectType { return HashObj }
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	var pairs []string
+	for _, pair := range h.order {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+
+	out.WriteByte('{')
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteByte('}')
+
+	return out.String()
+}
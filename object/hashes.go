@@ -0,0 +1,129 @@
+package object
+
+// The hash builtins. Hashes are values, so builtins that change one return a new hash and
+// leave their argument alone. Keys come back in insertion order.
+
+func builtin_keys(args ...Object) Object {
+	return pairElements("keys", args, func(pair HashPair) Object { return pair.Key })
+}
+
+func builtin_values(args ...Object) Object {
+	return pairElements("values", args, func(pair HashPair) Object { return pair.Value })
+}
+
+func builtin_entries(args ...Object) Object {
+	return pairElements("entries", args, func(pair HashPair) Object {
+		return &Tuple{Elements: []Object{pair.Key, pair.Value}}
+	})
+}
+
+// pairElements returns an ARRAY built from each pair of the hash in args[0].
+func pairElements(name string, args []Object, element func(HashPair) Object) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+	hash, errObj := hashArg(name, args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	pairs := hash.Ordered()
+	els := make([]Object, len(pairs))
+	for i, pair := range pairs {
+		els[i] = element(pair)
+	}
+	return &Array{Elements: els}
+}
+
+func builtin_delete(args ...Object) Object {
+	if errObj := checkArgCount(args, 2, 2); errObj != nil {
+		return errObj
+	}
+	hash, errObj := hashArg("delete", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	hash = hash.Copy()
+	if err := hash.Delete(args[1]); err != nil {
+		return newError("%s", err)
+	}
+	return hash
+}
+
+func builtin_merge(args ...Object) Object {
+	if len(args) == 0 {
+		return newError("`merge` needs at least one HASH")
+	}
+
+	merged := NewHash()
+	for i := range args {
+		hash, ok := args[i].(*Hash)
+		if !ok {
+			return newError("arguments to `merge` must be HASH, got %s", args[i].Type())
+		}
+		for _, pair := range hash.Ordered() {
+			_ = merged.Set(pair.Key, pair.Value)
+		}
+	}
+	return merged
+}
+
+func builtin_get(args ...Object) Object {
+	if errObj := checkArgCount(args, 2, 3); errObj != nil {
+		return errObj
+	}
+	hash, errObj := hashArg("get", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	pair, ok, err := hash.Get(args[1])
+	if err != nil {
+		return newError("%s", err)
+	}
+	if ok {
+		return pair.Value
+	}
+	if len(args) == 3 {
+		return args[2]
+	}
+	return nil
+}
+
+func builtin_from_entries(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+
+	var entries []Object
+	switch arg := args[0].(type) {
+	case *Array:
+		entries = arg.Elements
+	case *Tuple:
+		entries = arg.Elements
+	default:
+		return newError("argument to `from_entries` must be an ARRAY or TUPLE, got %s", args[0].Type())
+	}
+
+	hash := NewHash()
+	for _, entry := range entries {
+		pair, err := Unpack(entry, 2)
+		if err != nil {
+			return newError("`from_entries` expects key and value pairs: %s", err)
+		}
+		if err := hash.Set(pair[0], pair[1]); err != nil {
+			return newError("%s", err)
+		}
+	}
+	return hash
+}
+
+// hashArg returns args[i] of the builtin name, which must be a HASH.
+func hashArg(name string, args []Object, i int) (*Hash, *Error) {
+	hash, ok := args[i].(*Hash)
+	if !ok {
+		return nil, newError("%s argument to `%s` must be a HASH, got %s", ordinals[i], name, args[i].Type())
+	}
+	return hash, nil
+}
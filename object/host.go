@@ -0,0 +1,79 @@
+package object
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Host is what a running engine offers the builtins that need more than their arguments.
+type Host struct {
+	Call  CallFunc
+	FS    FS // nil unless the embedding program allows file access
+	Clock Clock
+}
+
+// CallFunc calls the Monkey function fn with args on behalf of a builtin. Anything that goes
+// wrong inside the call comes back as an *Error.
+type CallFunc func(fn Object, args ...Object) Object
+
+// FS is the file system the file builtins work in. As with io/fs, names are slash-separated
+// paths relative to its root; the builtins never pass one that leads outside it.
+type FS interface {
+	fs.FS
+	WriteFile(name string, data []byte) error
+	AppendFile(name string, data []byte) error
+	Remove(name string) error
+}
+
+// Clock gives the time builtins the current time and lets them wait. Hosts may supply their
+// own, for instance so that tests run deterministically.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// SystemClock is the real clock, used unless the host provides another.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// DirFS returns an FS rooted at the directory dir. It is backed by os.Root, so symbolic links
+// cannot lead outside dir either.
+func DirFS(dir string) (FS, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dirFS{FS: root.FS(), root: root}, nil
+}
+
+type dirFS struct {
+	fs.FS
+	root *os.Root
+}
+
+func (d *dirFS) WriteFile(name string, data []byte) error {
+	return d.root.WriteFile(name, data, 0644)
+}
+
+func (d *dirFS) AppendFile(name string, data []byte) error {
+	f, err := d.root.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (d *dirFS) Remove(name string) error {
+	return d.root.Remove(name)
+}
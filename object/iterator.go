@@ -0,0 +1,94 @@
+package object
+
+import "fmt"
+
+// Iterator steps through the elements of an ARRAY, TUPLE, STRING, BYTES, RANGE, SET or HASH. It is used to
+// drive comprehensions and is never exposed to Monkey code directly.
+type Iterator struct {
+	length int
+	index  int
+	keyed  bool
+	at     func(i int) (Object, Object)
+
+	key   Object
+	value Object
+}
+
+func NewIterator(obj Object) (*Iterator, error) {
+	switch obj := obj.(type) {
+	case *Array:
+		at := func(i int) (Object, Object) {
+			return &Integer{Value: int64(i)}, obj.Elements[i]
+		}
+		return &Iterator{length: len(obj.Elements), at: at}, nil
+	case *Tuple:
+		at := func(i int) (Object, Object) {
+			return &Integer{Value: int64(i)}, obj.Elements[i]
+		}
+		return &Iterator{length: len(obj.Elements), at: at}, nil
+	case *String:
+		chars := obj.Chars()
+		at := func(i int) (Object, Object) {
+			return &Integer{Value: int64(i)}, chars[i]
+		}
+		return &Iterator{length: len(chars), at: at}, nil
+	case *Bytes:
+		at := func(i int) (Object, Object) {
+			return &Integer{Value: int64(i)}, &Integer{Value: int64(obj.Value[i])}
+		}
+		return &Iterator{length: len(obj.Value), at: at}, nil
+	case *Range:
+		at := func(i int) (Object, Object) {
+			return &Integer{Value: int64(i)}, &Integer{Value: obj.Start + int64(i)*obj.Step}
+		}
+		n, err := obj.Len()
+		if err != nil {
+			return nil, err
+		}
+		return &Iterator{length: int(n), at: at}, nil
+	case *Set:
+		els := obj.Elements()
+		at := func(i int) (Object, Object) {
+			return &Integer{Value: int64(i)}, els[i]
+		}
+		return &Iterator{length: len(els), at: at}, nil
+	case *Hash:
+		pairs := obj.Ordered()
+		at := func(i int) (Object, Object) {
+			return pairs[i].Key, pairs[i].Value
+		}
+		return &Iterator{length: len(pairs), keyed: true, at: at}, nil
+	}
+
+	return nil, fmt.Errorf("cannot iterate over %s", obj.Type())
+}
+
+func (it *Iterator) Type() ObjectType { return IteratorObj }
+func (it *Iterator) Inspect() string  { return fmt.Sprintf("Iterator[%p]", it) }
+
+// Next advances to the next element, returning false once the iterator is exhausted.
+func (it *Iterator) Next() bool {
+	if it.index >= it.length {
+		return false
+	}
+
+	it.key, it.value = it.at(it.index)
+	it.index++
+	return true
+}
+
+// Pair returns the current element as a key and value: the index and element of an ARRAY,
+// TUPLE, STRING, BYTES, RANGE or SET, or the key and value of a HASH.
+func (it *Iterator) Pair() (Object, Object) {
+	return it.key, it.value
+}
+
+// Item returns the current element when bound to a single variable: the key of a HASH, or
+// the element of an ARRAY, TUPLE, STRING, BYTES, RANGE or SET.
+func (it *Iterator) Item() Object {
+	if it.keyed {
+		return it.key
+	}
+
+	return it.value
+}
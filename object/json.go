@@ -0,0 +1,206 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The JSON builtins. Objects decode to hashes with STRING keys in document order, and hashes
+// encode in insertion order, so a document survives a round trip unchanged.
+
+func builtin_json_parse(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("json_parse", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	val, err := decodeJSON(dec)
+	if err == io.EOF {
+		return newError("invalid JSON: unexpected end of JSON input")
+	}
+	if err != nil {
+		return newError("invalid JSON: %s", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return newError("invalid JSON: unexpected data after value")
+	}
+	return val
+}
+
+// decodeJSON reads the next complete value from dec.
+func decodeJSON(dec *json.Decoder) (Object, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			els := []Object{}
+			for dec.More() {
+				el, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				els = append(els, el)
+			}
+			_, err := dec.Token()
+			return &Array{Elements: els}, err
+		}
+
+		hash := NewHash()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			_ = hash.Set(&String{Value: key.(string)}, val)
+		}
+		_, err := dec.Token()
+		return hash, err
+	case json.Number:
+		return decodeJSONNumber(string(tok))
+	case string:
+		return &String{Value: tok}, nil
+	case bool:
+		return NativeBool(tok), nil
+	}
+
+	return NullValue, nil
+}
+
+// decodeJSONNumber gives whole numbers as INTEGER, or BIGINT when they do not fit, and
+// anything with a fraction or exponent as FLOAT.
+func decodeJSONNumber(s string) (Object, error) {
+	if !strings.ContainsAny(s, ".eE") {
+		n, _ := new(big.Int).SetString(s, 10)
+		return NewBigInt(n), nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("number %s is out of range", s)
+	}
+	return &Float{Value: f}, nil
+}
+
+func builtin_json_stringify(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return errObj
+	}
+
+	indent := ""
+	if len(args) == 2 {
+		switch arg := args[1].(type) {
+		case *Integer:
+			if arg.Value < 0 || arg.Value > 16 {
+				return newError("indent for `json_stringify` must be between 0 and 16, got %d", arg.Value)
+			}
+			indent = strings.Repeat(" ", int(arg.Value))
+		case *String:
+			if strings.Trim(arg.Value, " \t") != "" {
+				return newError("indent for `json_stringify` must contain only spaces and tabs, got %q", arg.Value)
+			}
+			indent = arg.Value
+		default:
+			return newError("second argument to `json_stringify` must be an INTEGER or STRING, got %s", args[1].Type())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, args[0]); err != nil {
+		return newError("%s", err)
+	}
+	if indent == "" {
+		return &String{Value: buf.String()}
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", indent); err != nil {
+		return newError("%s", err)
+	}
+	return &String{Value: out.String()}
+}
+
+// encodeJSON writes obj to buf as compact JSON. Sets and tuples become arrays.
+func encodeJSON(buf *bytes.Buffer, obj Object) error {
+	switch obj := obj.(type) {
+	case *Null:
+		buf.WriteString("null")
+	case *Boolean, *Integer, *BigInt, *Decimal:
+		buf.WriteString(obj.Inspect())
+	case *Float:
+		if math.IsInf(obj.Value, 0) || math.IsNaN(obj.Value) {
+			return fmt.Errorf("cannot encode %s as JSON", obj.Inspect())
+		}
+		buf.WriteString(obj.Inspect())
+	case *String:
+		encodeJSONString(buf, obj.Value)
+	case *Array:
+		return encodeJSONArray(buf, obj.Elements)
+	case *Tuple:
+		return encodeJSONArray(buf, obj.Elements)
+	case *Set:
+		return encodeJSONArray(buf, obj.Elements())
+	case *Hash:
+		buf.WriteByte('{')
+		for i, pair := range obj.Ordered() {
+			key, ok := pair.Key.(*String)
+			if !ok {
+				return fmt.Errorf("JSON object keys must be STRING, got %s", pair.Key.Type())
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodeJSONString(buf, key.Value)
+			buf.WriteByte(':')
+			if err := encodeJSON(buf, pair.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot encode %s as JSON", obj.Type())
+	}
+
+	return nil
+}
+
+func encodeJSONArray(buf *bytes.Buffer, els []Object) error {
+	buf.WriteByte('[')
+	for i, el := range els {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeJSON(buf, el); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// encodeJSONString writes s as a JSON string, leaving HTML characters unescaped.
+func encodeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1) // Encode ends each value with a newline
+}
@@ -0,0 +1,313 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// maxPowBits bounds the size of an exact result of `math.pow`, about 315,000 decimal digits,
+// so that a large exponent is an error rather than a hang.
+const maxPowBits = 1 << 20
+
+var mathModule = &Module{
+	Name: "math",
+	Members: map[string]Object{
+		"pi": &Float{Value: math.Pi},
+		"e":  &Float{Value: math.E},
+
+		"abs":   &Builtin{Fn: math_abs},
+		"floor": &Builtin{Fn: math_floor},
+		"ceil":  &Builtin{Fn: math_ceil},
+		"round": &Builtin{Fn: math_round},
+		"sqrt":  &Builtin{Fn: floatFunc("sqrt", math.Sqrt)},
+		"pow":   &Builtin{Fn: math_pow},
+		"min":   &Builtin{Fn: extreme("min", -1)},
+		"max":   &Builtin{Fn: extreme("max", 1)},
+
+		"sin":   &Builtin{Fn: floatFunc("sin", math.Sin)},
+		"cos":   &Builtin{Fn: floatFunc("cos", math.Cos)},
+		"tan":   &Builtin{Fn: floatFunc("tan", math.Tan)},
+		"asin":  &Builtin{Fn: floatFunc("asin", math.Asin)},
+		"acos":  &Builtin{Fn: floatFunc("acos", math.Acos)},
+		"atan":  &Builtin{Fn: floatFunc("atan", math.Atan)},
+		"atan2": &Builtin{Fn: math_atan2},
+		"exp":   &Builtin{Fn: floatFunc("exp", math.Exp)},
+		"log":   &Builtin{Fn: math_log},
+
+		"is_nan": &Builtin{Fn: math_is_nan},
+		"is_inf": &Builtin{Fn: math_is_inf},
+	},
+}
+
+// numberToFloat converts any number, including a DECIMAL, to a float64.
+func numberToFloat(obj Object) (float64, bool) {
+	if v, ok := ToFloat(obj); ok {
+		return v, true
+	}
+	if d, ok := obj.(*Decimal); ok {
+		v, _ := strconv.ParseFloat(d.Inspect(), 64)
+		return v, true
+	}
+	return 0, false
+}
+
+func isAnyNumber(obj Object) bool {
+	return IsNumber(obj) || obj.Type() == DecimalObj
+}
+
+// numberArg returns args[i] of the math function name, which must be a number.
+func numberArg(name string, args []Object, i int) (float64, *Error) {
+	v, ok := numberToFloat(args[i])
+	if !ok {
+		return 0, newError("%s argument to `math.%s` must be a number, got %s", ordinals[i], name, args[i].Type())
+	}
+	return v, nil
+}
+
+// floatFunc makes a math function of one number which always returns a FLOAT.
+func floatFunc(name string, fn func(float64) float64) BuiltinFunction {
+	return func(args ...Object) Object {
+		if errObj := checkArgCount(args, 1, 1); errObj != nil {
+			return errObj
+		}
+
+		v, errObj := numberArg(name, args, 0)
+		if errObj != nil {
+			return errObj
+		}
+		return &Float{Value: fn(v)}
+	}
+}
+
+func math_abs(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer:
+		if arg.Value >= 0 {
+			return arg
+		}
+		v, _ := IntegerArithmetic("-", &Integer{Value: 0}, arg)
+		return v
+	case *BigInt:
+		return NewBigInt(new(big.Int).Abs(arg.Value))
+	case *Float:
+		return &Float{Value: math.Abs(arg.Value)}
+	case *Decimal:
+		return &Decimal{Unscaled: new(big.Int).Abs(arg.Unscaled), Scale: arg.Scale}
+	}
+
+	return newError("first argument to `math.abs` must be a number, got %s", args[0].Type())
+}
+
+func math_floor(args ...Object) Object {
+	return toWhole("floor", args, math.Floor, RoundFloor)
+}
+
+func math_ceil(args ...Object) Object {
+	return toWhole("ceil", args, math.Ceil, RoundCeiling)
+}
+
+// toWhole rounds a number to an INTEGER, with fn for floats and mode for decimals.
+func toWhole(name string, args []Object, fn func(float64) float64, mode RoundingMode) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer, *BigInt:
+		return arg
+	case *Float:
+		return floatToInteger(fn(arg.Value))
+	case *Decimal:
+		return NewBigInt(arg.Rescale(0, mode).Unscaled)
+	}
+
+	return newError("first argument to `math.%s` must be a number, got %s", name, args[0].Type())
+}
+
+func floatToInteger(v float64) Object {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return newError("cannot convert %s to INTEGER", (&Float{Value: v}).Inspect())
+	}
+
+	i, _ := big.NewFloat(v).Int(nil)
+	return NewBigInt(i)
+}
+
+// math_round rounds half away from zero. With no digits it returns an INTEGER; otherwise it
+// keeps the type of the number, rounded to that many digits after the point.
+func math_round(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return errObj
+	}
+
+	if len(args) == 1 {
+		return toWhole("round", args, math.Round, RoundHalfUp)
+	}
+
+	digits, ok := args[1].(*Integer)
+	if !ok {
+		return newError("second argument to `math.round` must be an INTEGER, got %s", args[1].Type())
+	}
+	if digits.Value < -maxDecimalScale || digits.Value > maxDecimalScale {
+		return newError("digits for `math.round` out of range: %d", digits.Value)
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer, *BigInt:
+		return arg
+	case *Float:
+		scale := math.Pow(10, float64(digits.Value))
+		if scale == 0 {
+			return &Float{Value: math.Copysign(0, arg.Value)}
+		}
+		v := arg.Value * scale
+		if math.IsInf(v, 0) {
+			// A float has no digits that far past the point to round away.
+			return arg
+		}
+		return &Float{Value: math.Round(v) / scale}
+	case *Decimal:
+		return arg.Rescale(int32(digits.Value), RoundHalfUp)
+	}
+
+	return newError("first argument to `math.round` must be a number, got %s", args[0].Type())
+}
+
+// math_pow is exact for an integer or decimal raised to a non-negative integer, and otherwise
+// returns a FLOAT.
+func math_pow(args ...Object) Object {
+	if errObj := checkArgCount(args, 2, 2); errObj != nil {
+		return errObj
+	}
+
+	if IsInteger(args[1]) && CompareIntegers(args[1], &Integer{Value: 0}) >= 0 {
+		n, _ := ToBig(args[1])
+		switch base := args[0].(type) {
+		case *Integer, *BigInt:
+			b, _ := ToBig(base)
+			if powTooLarge(b, n) {
+				return newError("exponent given to `math.pow` is too large, got %s", n)
+			}
+			return NewBigInt(new(big.Int).Exp(b, n, nil))
+		case *Decimal:
+			tooFine := base.Scale > 0 && (!n.IsInt64() || n.Int64() > maxDecimalScale/int64(base.Scale))
+			if tooFine || powTooLarge(base.Unscaled, n) {
+				return newError("exponent given to `math.pow` is too large, got %s", n)
+			}
+			return &Decimal{Unscaled: new(big.Int).Exp(base.Unscaled, n, nil), Scale: base.Scale * int32(n.Int64())}
+		}
+	}
+
+	x, errObj := numberArg("pow", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	y, errObj := numberArg("pow", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	return &Float{Value: math.Pow(x, y)}
+}
+
+// powTooLarge reports whether b to the power n, for n not negative, would take more than about
+// maxPowBits bits. It is at least 2^((bits-1)*n), so bases of 0, 1 and -1 never grow.
+func powTooLarge(b, n *big.Int) bool {
+	if !n.IsInt64() {
+		return b.BitLen() > 1
+	}
+	if grow := int64(b.BitLen() - 1); grow > 0 {
+		return n.Int64() > maxPowBits/grow
+	}
+	return false
+}
+
+// extreme makes min or max, which take any number of comparable values, or a single array of
+// them, and return the value for which Compare has the given sign against every other.
+func extreme(name string, sign int) BuiltinFunction {
+	return func(args ...Object) Object {
+		if len(args) == 1 {
+			if arr, ok := args[0].(*Array); ok {
+				args = arr.Elements
+			}
+		}
+		if len(args) == 0 {
+			return newError("`math.%s` needs at least one value", name)
+		}
+
+		best := args[0]
+		for _, arg := range args[1:] {
+			cmp, err := Compare(arg, best)
+			if err != nil {
+				return newError("%s", err)
+			}
+			if cmp == sign {
+				best = arg
+			}
+		}
+		return best
+	}
+}
+
+func math_atan2(args ...Object) Object {
+	if errObj := checkArgCount(args, 2, 2); errObj != nil {
+		return errObj
+	}
+
+	y, errObj := numberArg("atan2", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	x, errObj := numberArg("atan2", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	return &Float{Value: math.Atan2(y, x)}
+}
+
+// math_log returns the natural logarithm, or the logarithm in the base given as a second argument.
+func math_log(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return errObj
+	}
+
+	x, errObj := numberArg("log", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	if len(args) == 1 {
+		return &Float{Value: math.Log(x)}
+	}
+
+	base, errObj := numberArg("log", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	return &Float{Value: math.Log(x) / math.Log(base)}
+}
+
+func math_is_nan(args ...Object) Object {
+	return floatTest("is_nan", args, math.IsNaN)
+}
+
+func math_is_inf(args ...Object) Object {
+	return floatTest("is_inf", args, func(v float64) bool { return math.IsInf(v, 0) })
+}
+
+// floatTest reports whether a number is a FLOAT passing test; other numbers never do.
+func floatTest(name string, args []Object, test func(float64) bool) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+
+	if !isAnyNumber(args[0]) {
+		return newError("first argument to `math.%s` must be a number, got %s", name, args[0].Type())
+	}
+
+	f, ok := args[0].(*Float)
+	return NativeBool(ok && test(f.Value))
+}
@@ -0,0 +1,22 @@
+package object
+
+import "fmt"
+
+// Module is a named group of builtins, such as math, whose members are reached with the `.`
+// operator.
+type Module struct {
+	Name    string
+	Members map[string]Object
+}
+
+func (m *Module) Type() ObjectType { return ModuleObj }
+func (m *Module) Inspect() string  { return fmt.Sprintf("<module %s>", m.Name) }
+
+// Get returns the named member of the module.
+func (m *Module) Get(name string) (Object, error) {
+	member, ok := m.Members[name]
+	if !ok {
+		return nil, fmt.Errorf("module %s has no member %s", m.Name, name)
+	}
+	return member, nil
+}
@@ -6,26 +6,51 @@ import (
 	"github.com/butlermatt/monkey/ast"
 	"github.com/butlermatt/monkey/code"
 	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type BuiltinFunction func(args ...Object) Object
 
+// HostFunction is a builtin that needs the engine running it, such as one calling back into
+// Monkey functions.
+type HostFunction func(host *Host, args ...Object) Object
+
 type ObjectType string
 
 const (
-	NumberObj           ObjectType = "NUMBER"
+	IntegerObj          ObjectType = "INTEGER"
+	FloatObj            ObjectType = "FLOAT"
+	BigIntObj           ObjectType = "BIGINT"
+	DecimalObj          ObjectType = "DECIMAL"
 	BooleanObj          ObjectType = "BOOLEAN"
 	NullObj             ObjectType = "NULL"
 	StringObj           ObjectType = "STRING"
 	ArrayObj            ObjectType = "ARRAY"
 	HashObj             ObjectType = "HASH"
+	SetObj              ObjectType = "SET"
+	TupleObj            ObjectType = "TUPLE"
+	BytesObj            ObjectType = "BYTES"
+	ModuleObj           ObjectType = "MODULE"
+	RegexObj            ObjectType = "REGEX"
+	TimeObj             ObjectType = "TIME"
+	DurationObj         ObjectType = "DURATION"
+	RangeObj            ObjectType = "RANGE"
 	FunctionObj         ObjectType = "FUNCTION"
 	ReturnObj           ObjectType = "RETURN_VALUE"
 	ErrorObj            ObjectType = "ERROR"
 	BuiltinObj          ObjectType = "BUILTIN"
 	CompiledFunctionObj ObjectType = "COMPILED_FUNCTION"
 	ClosureObj          ObjectType = "CLOSURE"
+	IteratorObj         ObjectType = "ITERATOR"
+	StructObj           ObjectType = "STRUCT"
+	InstanceObj         ObjectType = "INSTANCE"
+	EnumObj             ObjectType = "ENUM"
+	VariantObj          ObjectType = "VARIANT"
+	EnumValueObj        ObjectType = "ENUM_VALUE"
 )
 
 type Object interface {
@@ -37,23 +62,135 @@ type Hashable interface {
 	HashKey() HashKey
 }
 
+// Constructor is implemented by types which are called to build new values, such as structs.
+type Constructor interface {
+	Object
+	New(args []Object) (Object, error)
+}
+
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
 }
 
-type Number struct {
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Inspect() string  { return strconv.FormatInt(i.Value, 10) }
+func (i *Integer) Type() ObjectType { return IntegerObj }
+func (i *Integer) HashKey() HashKey { return HashKey{Type: i.Type(), Value: uint64(i.Value)} }
+
+// BigInt holds integers which do not fit in an int64. Arithmetic produces one only when the
+// result overflows, and demotes back to an Integer when it fits again; see NewBigInt.
+type BigInt struct {
+	Value *big.Int
+}
+
+// NewBigInt returns v as an Integer if it fits in an int64 and as a BigInt otherwise.
+func NewBigInt(v *big.Int) Object {
+	if v.IsInt64() {
+		return &Integer{Value: v.Int64()}
+	}
+	return &BigInt{Value: v}
+}
+
+func (b *BigInt) Inspect() string  { return b.Value.String() }
+func (b *BigInt) Type() ObjectType { return BigIntObj }
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	if b.Value.Sign() < 0 {
+		_, _ = h.Write([]byte{'-'})
+	}
+	_, _ = h.Write(b.Value.Bytes())
+
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+type Float struct {
 	Value float64
 }
 
-func (n *Number) Inspect() string  { return fmt.Sprintf("%f", n.Value) }
-func (n *Number) Type() ObjectType { return NumberObj }
-func (n *Number) HashKey() HashKey { return HashKey{Type: n.Type(), Value: uint64(n.Value)} }
+func (f *Float) Type() ObjectType { return FloatObj }
+
+// Inspect always includes a decimal point so that a whole float is not mistaken for an integer.
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'f', -1, 64)
+	if math.IsInf(f.Value, 0) || math.IsNaN(f.Value) || strings.Contains(s, ".") {
+		return s
+	}
+	return s + ".0"
+}
+
+// HashKey gives a whole float the same key as the equal integer, so that `h[1]` and `h[1.0]`
+// find the same entry, as `1 == 1.0` would suggest.
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && math.Abs(f.Value) < math.MaxInt64 {
+		return HashKey{Type: IntegerObj, Value: uint64(int64(f.Value))}
+	}
+	if f.Value == math.Trunc(f.Value) && !math.IsInf(f.Value, 0) {
+		v, _ := big.NewFloat(f.Value).Int(nil)
+		return NewBigInt(v).(Hashable).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+// IsInteger reports whether obj is an INTEGER or BIGINT.
+func IsInteger(obj Object) bool {
+	t := obj.Type()
+	return t == IntegerObj || t == BigIntObj
+}
+
+// IsNumber reports whether obj is an INTEGER, BIGINT or FLOAT.
+func IsNumber(obj Object) bool {
+	return IsInteger(obj) || obj.Type() == FloatObj
+}
+
+// ToFloat returns the value of an INTEGER, BIGINT or FLOAT as a float64.
+func ToFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+	case *BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f, true
+	case *Float:
+		return obj.Value, true
+	}
+	return 0, false
+}
+
+// ToBig returns the value of an INTEGER or BIGINT as a *big.Int.
+func ToBig(obj Object) (*big.Int, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value), true
+	case *BigInt:
+		return obj.Value, true
+	}
+	return nil, false
+}
 
 type Boolean struct {
 	Value bool
 }
 
+// True, False and NullValue are shared by builtins and both engines, so they may be compared
+// by identity.
+var (
+	True      = &Boolean{Value: true}
+	False     = &Boolean{Value: false}
+	NullValue = &Null{}
+)
+
+// NativeBool returns the shared Boolean for b.
+func NativeBool(b bool) *Boolean {
+	if b {
+		return True
+	}
+	return False
+}
+
 func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
 func (b *Boolean) Type() ObjectType { return BooleanObj }
 func (b *Boolean) HashKey() HashKey {
@@ -86,6 +223,36 @@ func (s *String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+// Strings are indexed, sliced, iterated and measured by character (rune) rather than by byte.
+// A character is a string holding a single rune.
+
+// Len returns the number of characters in s.
+func (s *String) Len() int64 { return int64(utf8.RuneCountInString(s.Value)) }
+
+// At returns the character at index i.
+func (s *String) At(i int64) (*String, bool) {
+	if i < 0 {
+		return nil, false
+	}
+
+	for _, r := range s.Value {
+		if i == 0 {
+			return &String{Value: string(r)}, true
+		}
+		i--
+	}
+	return nil, false
+}
+
+// Chars returns the characters of s.
+func (s *String) Chars() []*String {
+	chars := make([]*String, 0, len(s.Value))
+	for _, r := range s.Value {
+		chars = append(chars, &String{Value: string(r)})
+	}
+	return chars
+}
+
 type Array struct {
 	Elements []Object
 }
@@ -106,31 +273,404 @@ func (ao *Array) Inspect() string {
 	return out.String()
 }
 
-type HashPair struct {
-	Key   Object
-	Value Object
+// Range is a lazy sequence of integers from Start towards End, advancing by Step.
+// Elements are computed on demand and never materialized.
+type Range struct {
+	Start     int64
+	End       int64
+	Step      int64
+	Inclusive bool
+}
+
+func NewRange(start, end, step Object, inclusive bool) (*Range, error) {
+	r := &Range{Step: 1, Inclusive: inclusive}
+
+	var err error
+	if r.Start, err = rangeBound(start); err != nil {
+		return nil, err
+	}
+	if r.End, err = rangeBound(end); err != nil {
+		return nil, err
+	}
+	if step != nil {
+		if r.Step, err = rangeBound(step); err != nil {
+			return nil, err
+		}
+		if r.Step == 0 {
+			return nil, fmt.Errorf("range step cannot be zero")
+		}
+	}
+
+	return r, nil
+}
+
+func rangeBound(obj Object) (int64, error) {
+	num, ok := obj.(*Integer)
+	if !ok {
+		return 0, fmt.Errorf("range bounds must be INTEGER, got %s", obj.Type())
+	}
+
+	return num.Value, nil
+}
+
+func (r *Range) Type() ObjectType { return RangeObj }
+func (r *Range) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString(strconv.FormatInt(r.Start, 10))
+	if r.Inclusive {
+		out.WriteString("..=")
+	} else {
+		out.WriteString("..")
+	}
+	out.WriteString(strconv.FormatInt(r.End, 10))
+
+	if r.Step != 1 {
+		out.WriteString(" step ")
+		out.WriteString(strconv.FormatInt(r.Step, 10))
+	}
+
+	return out.String()
+}
+
+// Len returns the number of elements in the range, or an error if there are
+// too many to count in an int64.
+func (r *Range) Len() (int64, error) {
+	n, all := r.count()
+	if all || n > math.MaxInt64 {
+		return 0, fmt.Errorf("range %s is too long", r.Inspect())
+	}
+
+	return int64(n), nil
+}
+
+// count returns the number of elements in the range. The bounds are compared as
+// distances in uint64, which cannot overflow. The one range that has more
+// elements than a uint64 can hold, every int64 in order, reports all instead.
+func (r *Range) count() (n uint64, all bool) {
+	dist, ok := r.distance(r.End)
+	if !ok {
+		return 0, false
+	}
+
+	step := r.absStep()
+	if r.Inclusive {
+		if dist == math.MaxUint64 && step == 1 {
+			return 0, true
+		}
+		return dist/step + 1, false
+	}
+
+	if dist == 0 {
+		return 0, false
+	}
+	return (dist-1)/step + 1, false
+}
+
+// distance returns how far v lies from the start in the direction of the step,
+// or false if it lies behind the start.
+func (r *Range) distance(v int64) (uint64, bool) {
+	if r.Step > 0 {
+		return uint64(v) - uint64(r.Start), v >= r.Start
+	}
+
+	return uint64(r.Start) - uint64(v), v <= r.Start
+}
+
+func (r *Range) absStep() uint64 {
+	if r.Step > 0 {
+		return uint64(r.Step)
+	}
+
+	return -uint64(r.Step)
+}
+
+// has reports whether index i, already known not to be negative, is within the range.
+func (r *Range) has(i uint64) bool {
+	n, all := r.count()
+	return all || i < n
+}
+
+// At returns the element at index i, or false if i is out of bounds.
+func (r *Range) At(i int64) (int64, bool) {
+	if i < 0 || !r.has(uint64(i)) {
+		return 0, false
+	}
+
+	// Elements in range all fit in an int64, so wrapping arithmetic gives the exact value.
+	return int64(uint64(r.Start) + uint64(i)*uint64(r.Step)), true
+}
+
+// Last returns the final element of the range, or false if the range is empty.
+func (r *Range) Last() (int64, bool) {
+	n, all := r.count()
+	if all {
+		return r.End, true
+	}
+	if n == 0 {
+		return 0, false
+	}
+
+	return int64(uint64(r.Start) + (n-1)*uint64(r.Step)), true
+}
+
+// Contains reports whether v is one of the elements of the range.
+func (r *Range) Contains(v int64) bool {
+	dist, ok := r.distance(v)
+	if !ok {
+		return false
+	}
+
+	step := r.absStep()
+	return dist%step == 0 && r.has(dist/step)
+}
+
+// Rest returns the range without its first element.
+func (r *Range) Rest() *Range {
+	if !r.has(1) {
+		return &Range{Start: r.Start, End: r.Start, Step: r.Step}
+	}
+
+	return &Range{Start: r.Start + r.Step, End: r.End, Step: r.Step, Inclusive: r.Inclusive}
+}
+
+// bounds clamps the range, used as a slice, to a sequence of the given length.
+// It returns the first index selected, the step between indexes and the number selected.
+func (r *Range) bounds(length int64) (start, step, count int64, err error) {
+	if r.Step < 0 {
+		return 0, 0, 0, fmt.Errorf("slice step must be positive, got %d", r.Step)
+	}
+
+	end := r.End
+	if r.Inclusive && end < length {
+		end++
+	}
+
+	start = clamp(r.Start, 0, length)
+	end = clamp(end, start, length)
+	if end == start {
+		return start, r.Step, 0, nil
+	}
+
+	return start, r.Step, (end-start-1)/r.Step + 1, nil
+}
+
+func clamp(v, min, max int64) int64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
 }
 
-type Hash struct {
-	Pairs map[HashKey]HashPair
+// StructType is a user-defined record type. Calling it constructs an Instance.
+type StructType struct {
+	Name   string
+	Fields []string
+
+	index map[string]int
+}
+
+func NewStructType(name string, fields []string) *StructType {
+	index := make(map[string]int, len(fields))
+	for i, f := range fields {
+		index[f] = i
+	}
+
+	return &StructType{Name: name, Fields: fields, index: index}
+}
+
+func (st *StructType) Type() ObjectType { return StructObj }
+func (st *StructType) Inspect() string {
+	return fmt.Sprintf("struct %s { %s }", st.Name, strings.Join(st.Fields, ", "))
+}
+
+// New constructs an Instance from positional arguments, one per field.
+func (st *StructType) New(args []Object) (Object, error) {
+	if len(args) != len(st.Fields) {
+		return nil, fmt.Errorf("wrong number of arguments: expected=%d, got=%d", len(st.Fields), len(args))
+	}
+
+	values := make([]Object, len(args))
+	copy(values, args)
+
+	return &Instance{Struct: st, Values: values}, nil
 }
 
-func (h *Hash) Type() ObjectType { return HashObj }
-func (h *Hash) Inspect() string {
+// Instance is a value of a StructType. Its fields are fixed by the type.
+type Instance struct {
+	Struct *StructType
+	Values []Object
+}
+
+func (i *Instance) Type() ObjectType { return InstanceObj }
+func (i *Instance) Inspect() string {
 	var out bytes.Buffer
 
-	var pairs []string
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	var fields []string
+	for ind, f := range i.Struct.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", f, i.Values[ind].Inspect()))
 	}
 
+	out.WriteString(i.Struct.Name)
 	out.WriteByte('{')
-	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString(strings.Join(fields, ", "))
 	out.WriteByte('}')
 
 	return out.String()
 }
 
+// Get returns the value of the named field.
+func (i *Instance) Get(field string) (Object, error) {
+	ind, ok := i.Struct.index[field]
+	if !ok {
+		return nil, fmt.Errorf("struct %s has no field %s", i.Struct.Name, field)
+	}
+
+	return i.Values[ind], nil
+}
+
+// Equals reports whether both instances are of the same struct type with equal field values.
+func (i *Instance) Equals(other *Instance) bool {
+	return Equal(i, other)
+}
+
+// EnumType is a user-defined tagged union made up of a fixed set of variants.
+type EnumType struct {
+	Name     string
+	Variants []*Variant
+}
+
+// NewEnumType creates an enum with one variant per name, each having the given fields.
+func NewEnumType(name string, variants []string, fields [][]string) *EnumType {
+	et := &EnumType{Name: name}
+
+	for i, v := range variants {
+		variant := &Variant{Enum: et, Name: v, Fields: fields[i]}
+		if len(variant.Fields) == 0 {
+			variant.unit = &EnumValue{Variant: variant}
+		}
+		et.Variants = append(et.Variants, variant)
+	}
+
+	return et
+}
+
+func (et *EnumType) Type() ObjectType { return EnumObj }
+func (et *EnumType) Inspect() string {
+	var variants []string
+	for _, v := range et.Variants {
+		variants = append(variants, v.Inspect())
+	}
+
+	return fmt.Sprintf("enum %s { %s }", et.Name, strings.Join(variants, ", "))
+}
+
+// Get returns the binding of the named variant, as accessed by `Enum.Variant`.
+func (et *EnumType) Get(name string) (Object, error) {
+	if v := et.Variant(name); v != nil {
+		return v.Binding(), nil
+	}
+
+	return nil, fmt.Errorf("enum %s has no variant %s", et.Name, name)
+}
+
+// Variant returns the named variant, or nil if the enum has none by that name.
+func (et *EnumType) Variant(name string) *Variant {
+	for _, v := range et.Variants {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// Variant is one case of an EnumType. Variants with fields are called to construct an EnumValue.
+type Variant struct {
+	Enum   *EnumType
+	Name   string
+	Fields []string
+
+	unit *EnumValue
+}
+
+func (v *Variant) Type() ObjectType { return VariantObj }
+func (v *Variant) Inspect() string {
+	if len(v.Fields) == 0 {
+		return v.Name
+	}
+
+	return fmt.Sprintf("%s(%s)", v.Name, strings.Join(v.Fields, ", "))
+}
+
+// Binding returns the object a variant's name is bound to: the variant itself, which constructs
+// values, or for a variant without fields its only value.
+func (v *Variant) Binding() Object {
+	if v.unit != nil {
+		return v.unit
+	}
+
+	return v
+}
+
+// New constructs an EnumValue from positional arguments, one per field.
+func (v *Variant) New(args []Object) (Object, error) {
+	if len(args) != len(v.Fields) {
+		return nil, fmt.Errorf("wrong number of arguments: expected=%d, got=%d", len(v.Fields), len(args))
+	}
+
+	values := make([]Object, len(args))
+	copy(values, args)
+
+	return &EnumValue{Variant: v, Values: values}, nil
+}
+
+type EnumValue struct {
+	Variant *Variant
+	Values  []Object
+}
+
+func (ev *EnumValue) Type() ObjectType { return EnumValueObj }
+func (ev *EnumValue) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString(ev.Variant.Enum.Name)
+	out.WriteByte('.')
+	out.WriteString(ev.Variant.Name)
+
+	if len(ev.Values) > 0 {
+		var values []string
+		for _, v := range ev.Values {
+			values = append(values, v.Inspect())
+		}
+
+		out.WriteByte('(')
+		out.WriteString(strings.Join(values, ", "))
+		out.WriteByte(')')
+	}
+
+	return out.String()
+}
+
+// Get returns the value of the named field.
+func (ev *EnumValue) Get(field string) (Object, error) {
+	for i, f := range ev.Variant.Fields {
+		if f == field {
+			return ev.Values[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("variant %s has no field %s", ev.Variant.Name, field)
+}
+
+// Equals reports whether both values are the same variant with equal field values.
+func (ev *EnumValue) Equals(other *EnumValue) bool {
+	return Equal(ev, other)
+}
+
 type ReturnValue struct {
 	Value Object
 }
@@ -172,7 +712,8 @@ func (f *Function) Inspect() string {
 }
 
 type Builtin struct {
-	Fn BuiltinFunction
+	Fn     BuiltinFunction
+	HostFn HostFunction // set instead of Fn by builtins that need the Host
 }
 
 func (b *Builtin) Type() ObjectType { return BuiltinObj }
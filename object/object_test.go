@@ -1,6 +1,12 @@
 package object
 
-import "testing"
+import (
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -17,3 +23,273 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys.")
 	}
 }
+
+func TestNumberHashKey(t *testing.T) {
+	one := &Integer{Value: 1}
+	oneAndAHalf := &Float{Value: 1.5}
+	oneFloat := &Float{Value: 1.0}
+
+	if one.HashKey() == oneAndAHalf.HashKey() {
+		t.Errorf("1 and 1.5 have the same hash key.")
+	}
+
+	if one.HashKey() != oneFloat.HashKey() {
+		t.Errorf("1 and 1.0 have different hash keys.")
+	}
+}
+
+func TestNumberInspect(t *testing.T) {
+	tests := []struct {
+		obj      Object
+		expected string
+	}{
+		{&Integer{Value: 5}, "5"},
+		{&Integer{Value: -9007199254740993}, "-9007199254740993"},
+		{&Float{Value: 5}, "5.0"},
+		{&Float{Value: 2.5}, "2.5"},
+		{&Float{Value: 0.1}, "0.1"},
+		{&Float{Value: math.Inf(-1)}, "-Inf"},
+	}
+
+	for _, tt := range tests {
+		if s := tt.obj.Inspect(); s != tt.expected {
+			t.Errorf("wrong Inspect output. expected=%q, got=%q", tt.expected, s)
+		}
+	}
+}
+
+func TestRangeLen(t *testing.T) {
+	tests := []struct {
+		name     string
+		rng      *Range
+		expected int64
+	}{
+		{"exclusive", &Range{Start: 0, End: 10, Step: 1}, 10},
+		{"inclusive", &Range{Start: 0, End: 10, Step: 1, Inclusive: true}, 11},
+		{"step", &Range{Start: 0, End: 10, Step: 3}, 4},
+		{"inclusive step", &Range{Start: 0, End: 9, Step: 3, Inclusive: true}, 4},
+		{"descending", &Range{Start: 10, End: 0, Step: -2}, 5},
+		{"descending inclusive", &Range{Start: 10, End: 0, Step: -2, Inclusive: true}, 6},
+		{"empty", &Range{Start: 10, End: 0, Step: 1}, 0},
+		{"empty descending", &Range{Start: 0, End: 10, Step: -1}, 0},
+		{"max end", &Range{Start: math.MaxInt64 - 2, End: math.MaxInt64, Step: 1, Inclusive: true}, 3},
+		{"min end", &Range{Start: math.MinInt64 + 2, End: math.MinInt64, Step: -1, Inclusive: true}, 3},
+		{"huge step", &Range{Start: math.MinInt64, End: math.MaxInt64, Step: math.MaxInt64}, 3},
+		{"largest", &Range{Start: 0, End: math.MaxInt64, Step: 1}, math.MaxInt64},
+		{"full width stepped", &Range{Start: math.MinInt64, End: math.MaxInt64, Step: 3, Inclusive: true}, 6148914691236517206},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := tt.rng.Len()
+			if err != nil {
+				t.Fatalf("range %s length failed: %s", tt.rng.Inspect(), err)
+			}
+			if l != tt.expected {
+				t.Errorf("range %s has wrong length. expected=%d, got=%d", tt.rng.Inspect(), tt.expected, l)
+			}
+		})
+	}
+}
+
+func TestRangeLenTooLong(t *testing.T) {
+	tests := []*Range{
+		{Start: -math.MaxInt64, End: math.MaxInt64, Step: 1},
+		{Start: 0, End: math.MaxInt64, Step: 1, Inclusive: true},
+		{Start: math.MinInt64, End: math.MaxInt64, Step: 1, Inclusive: true},
+		{Start: math.MaxInt64, End: math.MinInt64, Step: -1, Inclusive: true},
+		{Start: math.MinInt64, End: math.MaxInt64, Step: 2, Inclusive: true},
+	}
+
+	for _, rng := range tests {
+		if l, err := rng.Len(); err == nil {
+			t.Errorf("range %s expected too long error, got length %d", rng.Inspect(), l)
+		}
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	full := &Range{Start: math.MinInt64, End: math.MaxInt64, Step: 1, Inclusive: true}
+	tests := []struct {
+		rng      *Range
+		value    int64
+		expected bool
+	}{
+		{full, math.MinInt64, true},
+		{full, math.MaxInt64, true},
+		{full, 0, true},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Step: 1}, math.MaxInt64, false},
+		{&Range{Start: -1, End: math.MaxInt64, Step: 2}, math.MinInt64 + 1, false},
+		{&Range{Start: math.MaxInt64, End: math.MinInt64, Step: -1, Inclusive: true}, math.MinInt64, true},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Step: math.MaxInt64}, math.MaxInt64 - 1, true},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Step: math.MaxInt64}, math.MaxInt64, false},
+		{&Range{Start: 0, End: 10, Step: 3}, 9, true},
+		{&Range{Start: 0, End: 10, Step: 3}, -3, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rng.Contains(tt.value); got != tt.expected {
+			t.Errorf("range %s contains %d wrong. expected=%t, got=%t", tt.rng.Inspect(), tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestDecimalRescale(t *testing.T) {
+	tests := []struct {
+		input    string
+		mode     RoundingMode
+		expected string
+	}{
+		{"2.5", RoundHalfEven, "2"},
+		{"3.5", RoundHalfEven, "4"},
+		{"-2.5", RoundHalfEven, "-2"},
+		{"2.5", RoundHalfUp, "3"},
+		{"-2.5", RoundHalfUp, "-3"},
+		{"2.5", RoundHalfDown, "2"},
+		{"2.6", RoundHalfDown, "3"},
+		{"2.1", RoundUp, "3"},
+		{"-2.1", RoundUp, "-3"},
+		{"2.9", RoundDown, "2"},
+		{"-2.9", RoundDown, "-2"},
+		{"-2.1", RoundCeiling, "-2"},
+		{"2.1", RoundCeiling, "3"},
+		{"2.9", RoundFloor, "2"},
+		{"-2.1", RoundFloor, "-3"},
+		{"0.05", RoundHalfEven, "0"},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.input)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q) failed: %s", tt.input, err)
+		}
+
+		if s := d.Rescale(0, tt.mode).Inspect(); s != tt.expected {
+			t.Errorf("rescale %s with mode %d wrong. expected=%s, got=%s", tt.input, tt.mode, tt.expected, s)
+		}
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	h := NewHash()
+	for _, s := range []string{"c", "a", "b", "a"} {
+		key := &String{Value: s}
+		_ = h.Set(key, key)
+	}
+
+	if got := h.Inspect(); got != "{c: c, a: a, b: b}" {
+		t.Errorf("wrong order after set. got=%q", got)
+	}
+
+	_ = h.Delete(&String{Value: "a"})
+	_ = h.Delete(&String{Value: "missing"})
+	key := &String{Value: "a"}
+	_ = h.Set(key, key)
+
+	if got := h.Inspect(); got != "{c: c, b: b, a: a}" {
+		t.Errorf("wrong order after delete. got=%q", got)
+	}
+}
+
+// collidingKey always has the same hash key, so that a hash has to tell keys apart by equality.
+type collidingKey struct{ name string }
+
+func (c *collidingKey) Type() ObjectType { return "COLLIDING" }
+func (c *collidingKey) Inspect() string  { return c.name }
+func (c *collidingKey) HashKey() HashKey { return HashKey{Type: "COLLIDING", Value: 1} }
+
+func TestHashCollisions(t *testing.T) {
+	a, b := &collidingKey{name: "a"}, &collidingKey{name: "b"}
+
+	h := NewHash()
+	_ = h.Set(a, &Integer{Value: 1})
+	_ = h.Set(b, &Integer{Value: 2})
+
+	if h.Len() != 2 {
+		t.Fatalf("colliding keys share a pair. got=%s", h.Inspect())
+	}
+
+	for key, expected := range map[Object]int64{a: 1, b: 2} {
+		pair, ok, err := h.Get(key)
+		if err != nil || !ok {
+			t.Fatalf("no pair for key %s", key.Inspect())
+		}
+		if pair.Value.(*Integer).Value != expected {
+			t.Errorf("wrong value for key %s. expected=%d, got=%s", key.Inspect(), expected, pair.Value.Inspect())
+		}
+	}
+
+	_ = h.Delete(a)
+	if _, ok, _ := h.Get(b); !ok || h.Len() != 1 {
+		t.Errorf("deleting a colliding key removed the other. got=%s", h.Inspect())
+	}
+}
+
+func TestEqualCycles(t *testing.T) {
+	a := &Array{}
+	a.Elements = []Object{a, &Integer{Value: 1}}
+	b := &Array{}
+	b.Elements = []Object{b, &Integer{Value: 1}}
+	c := &Array{}
+	c.Elements = []Object{c, &Integer{Value: 2}}
+
+	if !Equal(a, b) {
+		t.Errorf("equal cyclic arrays compared unequal.")
+	}
+
+	if Equal(a, c) {
+		t.Errorf("different cyclic arrays compared equal.")
+	}
+
+	h := NewHash()
+	key := &String{Value: "self"}
+	_ = h.Set(key, h)
+
+	if !Equal(h, h) {
+		t.Errorf("cyclic hash not equal to itself.")
+	}
+}
+
+func TestDirFSSymlinkEscape(t *testing.T) {
+	outside := t.TempDir()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0644); err != nil {
+		t.Fatalf("could not create test file: %s", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "out")); err != nil {
+		t.Skipf("symbolic links unavailable: %s", err)
+	}
+
+	fsys, err := DirFS(root)
+	if err != nil {
+		t.Fatalf("could not open root: %s", err)
+	}
+
+	if _, err := fs.ReadFile(fsys, "out/secret"); err == nil {
+		t.Errorf("read through a symbolic link out of the root.")
+	}
+	if err := fsys.WriteFile("out/new", nil); err == nil {
+		t.Errorf("wrote through a symbolic link out of the root.")
+	}
+	if err := fsys.AppendFile("out/secret", []byte("y")); err == nil {
+		t.Errorf("appended through a symbolic link out of the root.")
+	}
+	if err := fsys.Remove("out/secret"); err == nil {
+		t.Errorf("removed through a symbolic link out of the root.")
+	}
+}
+
+func TestCompileRegexCache(t *testing.T) {
+	a, err := CompileRegex(`\d+`)
+	if err != nil {
+		t.Fatalf("could not compile pattern: %s", err)
+	}
+	b, _ := CompileRegex(`\d+`)
+	if a != b {
+		t.Errorf("compiling the same pattern twice did not reuse the regex.")
+	}
+
+	if _, err := CompileRegex("(a"); err == nil {
+		t.Errorf("expected an error for an invalid pattern.")
+	}
+}
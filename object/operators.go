@@ -0,0 +1,455 @@
+package object
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Slice returns the elements of an ARRAY, RANGE, STRING or BYTES selected by the indexes in r.
+func Slice(left Object, r *Range) (Object, error) {
+	switch left := left.(type) {
+	case *Array:
+		start, step, count, err := r.bounds(int64(len(left.Elements)))
+		if err != nil {
+			return nil, err
+		}
+
+		els := make([]Object, count)
+		for i := range els {
+			els[i] = left.Elements[start+int64(i)*step]
+		}
+
+		return &Array{Elements: els}, nil
+	case *Range:
+		length := int64(math.MaxInt64)
+		if n, all := left.count(); !all && n < math.MaxInt64 {
+			length = int64(n)
+		}
+
+		start, stride, count, err := r.bounds(length)
+		if err != nil {
+			return nil, err
+		}
+
+		step, ok := checkedArithmetic("*", stride, left.Step)
+		if !ok {
+			if count > 1 {
+				return nil, fmt.Errorf("range slice step is too large")
+			}
+			step = left.Step
+		}
+
+		if count == 0 {
+			first := left.Start + start*left.Step
+			return &Range{Start: first, End: first, Step: step}, nil
+		}
+		first, _ := left.At(start)
+		last, _ := left.At(start + (count-1)*stride)
+
+		// Prefer an exclusive end, as written by hand, unless it would overflow.
+		if end, ok := checkedArithmetic("+", last, step); ok {
+			return &Range{Start: first, End: end, Step: step}, nil
+		}
+		return &Range{Start: first, End: last, Step: step, Inclusive: true}, nil
+	case *String:
+		runes := []rune(left.Value)
+		start, step, count, err := r.bounds(int64(len(runes)))
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]rune, count)
+		for i := range out {
+			out[i] = runes[start+int64(i)*step]
+		}
+
+		return &String{Value: string(out)}, nil
+	case *Bytes:
+		start, step, count, err := r.bounds(int64(len(left.Value)))
+		if err != nil {
+			return nil, err
+		}
+
+		b := make([]byte, count)
+		for i := range b {
+			b[i] = left.Value[start+int64(i)*step]
+		}
+
+		return &Bytes{Value: b}, nil
+	}
+
+	return nil, fmt.Errorf("index operator not supported: %s[%s]", left.Type(), r.Type())
+}
+
+// Field returns the named field of obj, as accessed with the `.` operator.
+func Field(obj Object, name string) (Object, error) {
+	switch obj := obj.(type) {
+	case *Instance:
+		return obj.Get(name)
+	case *EnumValue:
+		return obj.Get(name)
+	case *EnumType:
+		return obj.Get(name)
+	case *Module:
+		return obj.Get(name)
+	case *Regex:
+		return obj.Get(name)
+	case *Time:
+		return obj.Get(name)
+	case *Duration:
+		return obj.Get(name)
+	}
+
+	return nil, fmt.Errorf("field access not supported: %s.%s", obj.Type(), name)
+}
+
+// Contains reports whether item is a member of container, as tested by the `in` operator.
+func Contains(container, item Object) (bool, error) {
+	switch container := container.(type) {
+	case *Range:
+		switch num := item.(type) {
+		case *Integer:
+			return container.Contains(num.Value), nil
+		case *Float:
+			if num.Value == math.Trunc(num.Value) {
+				return container.Contains(int64(num.Value)), nil
+			}
+		}
+
+		return false, nil
+	case *Array:
+		return containsEqual(container.Elements, item), nil
+	case *Tuple:
+		return containsEqual(container.Elements, item), nil
+	case *String:
+		sub, ok := item.(*String)
+		if !ok {
+			break
+		}
+
+		return strings.Contains(container.Value, sub.Value), nil
+	case *Hash:
+		_, ok, err := container.Get(item)
+		return ok, err
+	case *Set:
+		return container.Has(item)
+	}
+
+	return false, fmt.Errorf("unknown operator: %s in %s", item.Type(), container.Type())
+}
+
+func containsEqual(els []Object, item Object) bool {
+	for _, el := range els {
+		if Equal(el, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal compares two objects by value, as the == operator does. Numbers compare numerically
+// across their types; arrays, hashes, sets, struct instances and enum values compare element
+// by element; functions and other objects compare by identity. Values which contain themselves
+// are handled: a comparison which comes back around to a pair already being compared is taken
+// as equal, since any difference will be found along another path.
+func Equal(a, b Object) bool {
+	return equal(a, b, nil)
+}
+
+type objectPair struct {
+	a, b Object
+}
+
+func equal(a, b Object, seen map[objectPair]bool) bool {
+	switch a := a.(type) {
+	case *Integer, *BigInt:
+		if b.Type() == DecimalObj {
+			return CompareDecimals(a, b) == 0
+		}
+		if b, ok := b.(*Integer); ok {
+			if a, ok := a.(*Integer); ok {
+				return a.Value == b.Value
+			}
+		}
+		if bb, ok := ToBig(b); ok {
+			ab, _ := ToBig(a)
+			return ab.Cmp(bb) == 0
+		}
+		av, _ := ToFloat(a)
+		bv, ok := ToFloat(b)
+		return ok && av == bv
+	case *Float:
+		bv, ok := ToFloat(b)
+		return ok && a.Value == bv
+	case *Decimal:
+		return IsDecimalOperation(a, b) && CompareDecimals(a, b) == 0
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Time:
+		b, ok := b.(*Time)
+		return ok && a.Value.Equal(b.Value)
+	case *Duration:
+		b, ok := b.(*Duration)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Range:
+		b, ok := b.(*Range)
+		if !ok {
+			return false
+		}
+		n, all := a.count()
+		if bn, ball := b.count(); n != bn || all != ball {
+			return false
+		}
+		// Ranges are equal when they produce the same numbers, however they were written.
+		return n == 0 && !all || a.Start == b.Start && (n == 1 || a.Step == b.Step)
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		if seen, ok = enter(seen, a, b); !ok {
+			return true
+		}
+		return allEqual(a.Elements, b.Elements, seen)
+	case *Tuple:
+		b, ok := b.(*Tuple)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		if seen, ok = enter(seen, a, b); !ok {
+			return true
+		}
+		return allEqual(a.Elements, b.Elements, seen)
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		if seen, ok = enter(seen, a, b); !ok {
+			return true
+		}
+		for _, pair := range a.order {
+			other, ok, _ := b.Get(pair.Key)
+			if !ok || !equal(pair.Value, other.Value, seen) {
+				return false
+			}
+		}
+		return true
+	case *Bytes:
+		b, ok := b.(*Bytes)
+		return ok && bytes.Equal(a.Value, b.Value)
+	case *Set:
+		b, ok := b.(*Set)
+		return ok && a.Len() == b.Len() && a.IsSubset(b)
+	case *Instance:
+		b, ok := b.(*Instance)
+		if !ok || a.Struct != b.Struct {
+			return false
+		}
+		if seen, ok = enter(seen, a, b); !ok {
+			return true
+		}
+		return allEqual(a.Values, b.Values, seen)
+	case *EnumValue:
+		b, ok := b.(*EnumValue)
+		if !ok || a.Variant != b.Variant {
+			return false
+		}
+		if seen, ok = enter(seen, a, b); !ok {
+			return true
+		}
+		return allEqual(a.Values, b.Values, seen)
+	}
+
+	return a == b
+}
+
+// Compare returns -1, 0 or 1 as a is less than, equal to or greater than b, as used by the
+// ordering operators. Numbers compare numerically, strings and bytes lexicographically, times and
+// durations chronologically, and arrays and tuples element by element, with a shorter sequence
+// ordered before any longer one it is a prefix of.
+func Compare(a, b Object) (int, error) {
+	switch {
+	case IsDecimalOperation(a, b):
+		return CompareDecimals(a, b), nil
+	case IsInteger(a) && IsInteger(b):
+		return CompareIntegers(a, b), nil
+	case IsNumber(a) && IsNumber(b):
+		av, _ := ToFloat(a)
+		bv, _ := ToFloat(b)
+		switch {
+		case av < bv:
+			return -1, nil
+		case av > bv:
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	switch a := a.(type) {
+	case *String:
+		if b, ok := b.(*String); ok {
+			return strings.Compare(a.Value, b.Value), nil
+		}
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			return compareElements(a.Elements, b.Elements)
+		}
+	case *Tuple:
+		if b, ok := b.(*Tuple); ok {
+			return compareElements(a.Elements, b.Elements)
+		}
+	case *Bytes:
+		if b, ok := b.(*Bytes); ok {
+			return bytes.Compare(a.Value, b.Value), nil
+		}
+	case *Time:
+		if b, ok := b.(*Time); ok {
+			return a.Value.Compare(b.Value), nil
+		}
+	case *Duration:
+		if b, ok := b.(*Duration); ok {
+			return cmp.Compare(a.Value, b.Value), nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot compare %s with %s", a.Type(), b.Type())
+}
+
+// compareElements orders two sequences element by element.
+func compareElements(a, b []Object) (int, error) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		cmp, err := Compare(a[i], b[i])
+		if err != nil || cmp != 0 {
+			return cmp, err
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1, nil
+	case len(a) > len(b):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func allEqual(a, b []Object, seen map[objectPair]bool) bool {
+	for i := range a {
+		if !equal(a[i], b[i], seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// enter records that a and b are being compared, returning false if they already were.
+func enter(seen map[objectPair]bool, a, b Object) (map[objectPair]bool, bool) {
+	if seen == nil {
+		seen = make(map[objectPair]bool)
+	}
+
+	pair := objectPair{a, b}
+	if seen[pair] {
+		return seen, false
+	}
+	seen[pair] = true
+	return seen, true
+}
+
+// IntegerArithmetic applies one of + - * / to two INTEGER or BIGINT operands. Results which
+// overflow an int64 are promoted to a BIGINT, and BIGINT results which fit are demoted again,
+// so a value always has the same representation. Division truncates toward zero.
+func IntegerArithmetic(operator string, left, right Object) (Object, error) {
+	l, lok := left.(*Integer)
+	r, rok := right.(*Integer)
+	if lok && rok {
+		if v, ok := checkedArithmetic(operator, l.Value, r.Value); ok {
+			return &Integer{Value: v}, nil
+		}
+	}
+
+	lb, ok := ToBig(left)
+	if !ok {
+		return nil, fmt.Errorf("expected integer, got %s", left.Type())
+	}
+	rb, ok := ToBig(right)
+	if !ok {
+		return nil, fmt.Errorf("expected integer, got %s", right.Type())
+	}
+
+	result := new(big.Int)
+	switch operator {
+	case "+":
+		result.Add(lb, rb)
+	case "-":
+		result.Sub(lb, rb)
+	case "*":
+		result.Mul(lb, rb)
+	case "/":
+		if rb.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		result.Quo(lb, rb)
+	default:
+		return nil, fmt.Errorf("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	return NewBigInt(result), nil
+}
+
+// checkedArithmetic reports false when the int64 result would overflow, or on division by zero.
+func checkedArithmetic(operator string, l, r int64) (int64, bool) {
+	switch operator {
+	case "+":
+		v := l + r
+		return v, (v > l) == (r > 0)
+	case "-":
+		v := l - r
+		return v, (v < l) == (r > 0)
+	case "*":
+		if l == 0 || r == 0 {
+			return 0, true
+		}
+		v := l * r
+		return v, v/r == l && !(l == -1 && r == math.MinInt64) && !(r == -1 && l == math.MinInt64)
+	case "/":
+		if r == 0 || (l == math.MinInt64 && r == -1) {
+			return 0, false
+		}
+		return l / r, true
+	}
+
+	return 0, false
+}
+
+// CompareIntegers returns -1, 0 or 1 as left is less than, equal to or greater than right, both
+// of which must be an INTEGER or BIGINT.
+func CompareIntegers(left, right Object) int {
+	l, lok := left.(*Integer)
+	r, rok := right.(*Integer)
+	if lok && rok {
+		switch {
+		case l.Value < r.Value:
+			return -1
+		case l.Value > r.Value:
+			return 1
+		}
+		return 0
+	}
+
+	lb, _ := ToBig(left)
+	rb, _ := ToBig(right)
+	return lb.Cmp(rb)
+}
@@ -0,0 +1,174 @@
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Regex is a compiled regular expression, using Go's RE2 syntax. Its operations are methods
+// reached with the `.` operator, as in `regex("a+").match(s)`.
+type Regex struct {
+	re *regexp.Regexp
+}
+
+func (r *Regex) Type() ObjectType { return RegexObj }
+func (r *Regex) Inspect() string  { return fmt.Sprintf("regex(%s)", r.re.String()) }
+
+// regexCacheSize bounds the number of compiled patterns kept by CompileRegex.
+const regexCacheSize = 256
+
+var regexCache = struct {
+	sync.Mutex
+	patterns map[string]*Regex
+}{patterns: make(map[string]*Regex)}
+
+// CompileRegex compiles pattern, reusing the Regex from an earlier call with the same pattern.
+func CompileRegex(pattern string) (*Regex, error) {
+	regexCache.Lock()
+	defer regexCache.Unlock()
+
+	if r, ok := regexCache.patterns[pattern]; ok {
+		return r, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regexCache.patterns) >= regexCacheSize {
+		regexCache.patterns = make(map[string]*Regex)
+	}
+	r := &Regex{re: re}
+	regexCache.patterns[pattern] = r
+	return r, nil
+}
+
+// Get returns the named method of the regex, or its pattern.
+func (r *Regex) Get(name string) (Object, error) {
+	switch name {
+	case "pattern":
+		return &String{Value: r.re.String()}, nil
+	case "match":
+		return &Builtin{Fn: r.match}, nil
+	case "find_all":
+		return &Builtin{Fn: r.findAll}, nil
+	case "replace_all":
+		return &Builtin{Fn: r.replaceAll}, nil
+	case "split":
+		return &Builtin{Fn: r.split}, nil
+	}
+
+	return nil, fmt.Errorf("regex has no member %s", name)
+}
+
+// match returns the first match in a string with its capture groups, as an ARRAY whose first
+// element is the whole match. Groups that did not take part are null. Without a match it
+// returns null.
+func (r *Regex) match(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("match", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	loc := r.re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return nil
+	}
+
+	els := make([]Object, len(loc)/2)
+	for i := range els {
+		if loc[2*i] < 0 {
+			els[i] = NullValue
+		} else {
+			els[i] = &String{Value: s[loc[2*i]:loc[2*i+1]]}
+		}
+	}
+	return &Array{Elements: els}
+}
+
+// findAll returns every match in a string, or at most the number given.
+func (r *Regex) findAll(args ...Object) Object {
+	s, n, errObj := regexLimitArgs("find_all", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	return stringArray(r.re.FindAllString(s, n))
+}
+
+// replaceAll replaces every match in a string. The replacement may refer to capture groups
+// as $1 or ${name}; $$ stands for a literal $.
+func (r *Regex) replaceAll(args ...Object) Object {
+	if errObj := checkArgCount(args, 2, 2); errObj != nil {
+		return errObj
+	}
+	strs, errObj := stringArgs("replace_all", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	return &String{Value: r.re.ReplaceAllString(strs[0], strs[1])}
+}
+
+// split cuts a string around each match, into at most the number of pieces given.
+func (r *Regex) split(args ...Object) Object {
+	s, n, errObj := regexLimitArgs("split", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	return stringArray(r.re.Split(s, n))
+}
+
+// regexLimitArgs checks the (string, limit?) arguments of a regex method. The limit is -1,
+// meaning none, when it is left out.
+func regexLimitArgs(name string, args []Object) (string, int, *Error) {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return "", 0, errObj
+	}
+	s, errObj := stringArg(name, args, 0)
+	if errObj != nil {
+		return "", 0, errObj
+	}
+
+	if len(args) == 1 {
+		return s, -1, nil
+	}
+	n, ok := args[1].(*Integer)
+	if !ok {
+		return "", 0, newError("second argument to `%s` must be an INTEGER, got %s", name, args[1].Type())
+	}
+	if n.Value < 0 {
+		return "", 0, newError("second argument to `%s` must not be negative, got %d", name, n.Value)
+	}
+	return s, int(n.Value), nil
+}
+
+func stringArray(strs []string) *Array {
+	els := make([]Object, len(strs))
+	for i, s := range strs {
+		els[i] = &String{Value: s}
+	}
+	return &Array{Elements: els}
+}
+
+func builtin_regex(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+	pattern, errObj := stringArg("regex", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	r, err := CompileRegex(pattern)
+	if err != nil {
+		return newError("invalid pattern for `regex`: %s", err)
+	}
+	return r
+}
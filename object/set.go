@@ -0,0 +1,83 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Set is a collection of distinct hashable values, kept in the order they were first added.
+// Like arrays, sets are not modified once built; the set builtins return new sets.
+type Set struct {
+	elements *Hash
+}
+
+func NewSet() *Set {
+	return &Set{elements: NewHash()}
+}
+
+// Add puts obj in the set. It fails if obj cannot be used as a hash key.
+func (s *Set) Add(obj Object) error {
+	return s.elements.Set(obj, obj)
+}
+
+// Remove takes obj out of the set, if it is there.
+func (s *Set) Remove(obj Object) error {
+	return s.elements.Delete(obj)
+}
+
+// Has reports whether obj is in the set.
+func (s *Set) Has(obj Object) (bool, error) {
+	_, ok, err := s.elements.Get(obj)
+	return ok, err
+}
+
+// Len returns the number of elements in the set.
+func (s *Set) Len() int { return s.elements.Len() }
+
+// Elements returns the elements in the order they were added.
+func (s *Set) Elements() []Object {
+	els := make([]Object, len(s.elements.order))
+	for i, pair := range s.elements.order {
+		els[i] = pair.Key
+	}
+	return els
+}
+
+// IsSubset reports whether every element of s is also in other.
+func (s *Set) IsSubset(other *Set) bool {
+	for _, el := range s.Elements() {
+		if ok, _ := other.Has(el); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy returns a new set holding the same elements.
+func (s *Set) Copy() *Set {
+	c := NewSet()
+	for _, el := range s.Elements() {
+		_ = c.Add(el)
+	}
+	return c
+}
+
+func (s *Set) Type() ObjectType { return SetObj }
+func (s *Set) Inspect() string {
+	if s.Len() == 0 {
+		return "set()"
+	}
+
+	var out bytes.Buffer
+
+	var els []string
+	for _, el := range s.Elements() {
+		els = append(els, el.Inspect())
+	}
+
+	out.WriteByte('{')
+	out.WriteString(strings.Join(els, ", "))
+	out.WriteByte('}')
+
+	return out.String()
+}
@@ -0,0 +1,300 @@
+package object
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The string builtins. Positions and widths count characters, as string indexing does.
+
+// maxStringLength bounds, in bytes, the strings `repeat` and the padding builtins may build.
+const maxStringLength = 64 << 20
+
+func builtin_split(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("split", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	var parts []string
+	if len(args) == 1 {
+		parts = strings.Fields(s)
+	} else {
+		sep, errObj := stringArg("split", args, 1)
+		if errObj != nil {
+			return errObj
+		}
+		parts = strings.Split(s, sep)
+	}
+
+	els := make([]Object, len(parts))
+	for i, part := range parts {
+		els[i] = &String{Value: part}
+	}
+	return &Array{Elements: els}
+}
+
+func builtin_join(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return errObj
+	}
+
+	arr, ok := args[0].(*Array)
+	if !ok {
+		return newError("first argument to `join` must be an ARRAY, got %s", args[0].Type())
+	}
+
+	parts := make([]string, len(arr.Elements))
+	for i, el := range arr.Elements {
+		s, ok := el.(*String)
+		if !ok {
+			return newError("`join` expects an ARRAY of STRING, found %s", el.Type())
+		}
+		parts[i] = s.Value
+	}
+
+	sep := ""
+	if len(args) == 2 {
+		var errObj *Error
+		if sep, errObj = stringArg("join", args, 1); errObj != nil {
+			return errObj
+		}
+	}
+
+	return &String{Value: strings.Join(parts, sep)}
+}
+
+func builtin_trim(args ...Object) Object {
+	return trim("trim", args, strings.TrimSpace, strings.Trim)
+}
+
+func builtin_trim_left(args ...Object) Object {
+	return trim("trim_left", args, func(s string) string {
+		return strings.TrimLeftFunc(s, unicode.IsSpace)
+	}, strings.TrimLeft)
+}
+
+func builtin_trim_right(args ...Object) Object {
+	return trim("trim_right", args, func(s string) string {
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	}, strings.TrimRight)
+}
+
+// trim removes whitespace with space, or the characters of the optional second argument with
+// cutset.
+func trim(name string, args []Object, space func(string) string, cutset func(string, string) string) Object {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg(name, args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	if len(args) == 1 {
+		return &String{Value: space(s)}
+	}
+
+	chars, errObj := stringArg(name, args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	return &String{Value: cutset(s, chars)}
+}
+
+func builtin_replace(args ...Object) Object {
+	if errObj := checkArgCount(args, 3, 4); errObj != nil {
+		return errObj
+	}
+
+	strs, errObj := stringArgs("replace", args[:3])
+	if errObj != nil {
+		return errObj
+	}
+
+	n := int64(-1)
+	if len(args) == 4 {
+		count, ok := args[3].(*Integer)
+		if !ok {
+			return newError("fourth argument to `replace` must be an INTEGER, got %s", args[3].Type())
+		}
+		n = count.Value
+	}
+
+	return &String{Value: strings.Replace(strs[0], strs[1], strs[2], int(n))}
+}
+
+func builtin_contains(args ...Object) Object {
+	return stringPredicate("contains", args, strings.Contains)
+}
+
+func builtin_starts_with(args ...Object) Object {
+	return stringPredicate("starts_with", args, strings.HasPrefix)
+}
+
+func builtin_ends_with(args ...Object) Object {
+	return stringPredicate("ends_with", args, strings.HasSuffix)
+}
+
+func stringPredicate(name string, args []Object, fn func(string, string) bool) Object {
+	if errObj := checkArgCount(args, 2, 2); errObj != nil {
+		return errObj
+	}
+
+	strs, errObj := stringArgs(name, args)
+	if errObj != nil {
+		return errObj
+	}
+	return NativeBool(fn(strs[0], strs[1]))
+}
+
+func builtin_index_of(args ...Object) Object {
+	if errObj := checkArgCount(args, 2, 2); errObj != nil {
+		return errObj
+	}
+
+	strs, errObj := stringArgs("index_of", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	i := strings.Index(strs[0], strs[1])
+	if i < 0 {
+		return &Integer{Value: -1}
+	}
+	return &Integer{Value: int64(utf8.RuneCountInString(strs[0][:i]))}
+}
+
+func builtin_upper(args ...Object) Object {
+	return mapString("upper", args, strings.ToUpper)
+}
+
+func builtin_lower(args ...Object) Object {
+	return mapString("lower", args, strings.ToLower)
+}
+
+func mapString(name string, args []Object, fn func(string) string) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+
+	s, errObj := stringArg(name, args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	return &String{Value: fn(s)}
+}
+
+func builtin_repeat(args ...Object) Object {
+	if errObj := checkArgCount(args, 2, 2); errObj != nil {
+		return errObj
+	}
+
+	s, errObj := stringArg("repeat", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	n, ok := args[1].(*Integer)
+	if !ok {
+		return newError("second argument to `repeat` must be an INTEGER, got %s", args[1].Type())
+	}
+	if n.Value < 0 {
+		return newError("count given to `repeat` must not be negative, got %d", n.Value)
+	}
+	if len(s) > 0 && n.Value > maxStringLength/int64(len(s)) {
+		return newError("result of `repeat` is longer than %d bytes", maxStringLength)
+	}
+
+	return &String{Value: strings.Repeat(s, int(n.Value))}
+}
+
+func builtin_pad_left(args ...Object) Object {
+	return pad("pad_left", args, true)
+}
+
+func builtin_pad_right(args ...Object) Object {
+	return pad("pad_right", args, false)
+}
+
+// pad implements pad_left(s, width, char) and pad_right, which extend s to width characters with
+// char, a space by default.
+func pad(name string, args []Object, left bool) Object {
+	if errObj := checkArgCount(args, 2, 3); errObj != nil {
+		return errObj
+	}
+
+	s, errObj := stringArg(name, args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	width, ok := args[1].(*Integer)
+	if !ok {
+		return newError("second argument to `%s` must be an INTEGER, got %s", name, args[1].Type())
+	}
+
+	char := " "
+	if len(args) == 3 {
+		if char, errObj = stringArg(name, args, 2); errObj != nil {
+			return errObj
+		}
+		if utf8.RuneCountInString(char) != 1 {
+			return newError("padding given to `%s` must be a single character, got %q", name, char)
+		}
+	}
+
+	n := width.Value - int64(utf8.RuneCountInString(s))
+	if n <= 0 {
+		return &String{Value: s}
+	}
+	if n > (maxStringLength-int64(len(s)))/int64(len(char)) {
+		return newError("result of `%s` is longer than %d bytes", name, maxStringLength)
+	}
+
+	padding := strings.Repeat(char, int(n))
+	if left {
+		return &String{Value: padding + s}
+	}
+	return &String{Value: s + padding}
+}
+
+var ordinals = []string{"first", "second", "third", "fourth"}
+
+// checkArgCount checks that there are between min and max arguments.
+func checkArgCount(args []Object, min, max int) *Error {
+	if len(args) >= min && len(args) <= max {
+		return nil
+	}
+
+	if min == max {
+		return newError("wrong number of arguments. expected=%d, got=%d", min, len(args))
+	}
+	if max == min+1 {
+		return newError("wrong number of arguments. expected=%d or %d, got=%d", min, max, len(args))
+	}
+	return newError("wrong number of arguments. expected=%d to %d, got=%d", min, max, len(args))
+}
+
+// stringArg returns args[i] of the builtin name, which must be a STRING.
+func stringArg(name string, args []Object, i int) (string, *Error) {
+	s, ok := args[i].(*String)
+	if !ok {
+		return "", newError("%s argument to `%s` must be a STRING, got %s", ordinals[i], name, args[i].Type())
+	}
+	return s.Value, nil
+}
+
+// stringArgs returns args of the builtin name, which must all be STRINGs.
+func stringArgs(name string, args []Object) ([]string, *Error) {
+	strs := make([]string, len(args))
+	for i := range args {
+		var errObj *Error
+		if strs[i], errObj = stringArg(name, args, i); errObj != nil {
+			return nil, errObj
+		}
+	}
+	return strs, nil
+}
@@ -0,0 +1,287 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Time is an instant, as returned by `now()` and `parse_time`.
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Type() ObjectType { return TimeObj }
+func (t *Time) Inspect() string  { return t.Value.Format(time.RFC3339Nano) }
+func (t *Time) HashKey() HashKey {
+	return HashKey{Type: t.Type(), Value: uint64(t.Value.UnixNano())}
+}
+
+// Get returns the named part of the time, in its own time zone.
+func (t *Time) Get(name string) (Object, error) {
+	var v int
+	switch name {
+	case "year":
+		v = t.Value.Year()
+	case "month":
+		v = int(t.Value.Month())
+	case "day":
+		v = t.Value.Day()
+	case "hour":
+		v = t.Value.Hour()
+	case "minute":
+		v = t.Value.Minute()
+	case "second":
+		v = t.Value.Second()
+	case "weekday":
+		return &String{Value: t.Value.Weekday().String()}, nil
+	case "unix":
+		return &Integer{Value: t.Value.Unix()}, nil
+	default:
+		return nil, fmt.Errorf("time has no member %s", name)
+	}
+	return &Integer{Value: int64(v)}, nil
+}
+
+// Duration is the time between two instants, as given by subtracting one TIME from another.
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Type() ObjectType { return DurationObj }
+func (d *Duration) Inspect() string  { return d.Value.String() }
+func (d *Duration) HashKey() HashKey {
+	return HashKey{Type: d.Type(), Value: uint64(d.Value)}
+}
+
+// Get returns the duration in whole milliseconds or in seconds.
+func (d *Duration) Get(name string) (Object, error) {
+	switch name {
+	case "ms":
+		return &Integer{Value: d.Value.Milliseconds()}, nil
+	case "seconds":
+		return &Float{Value: d.Value.Seconds()}, nil
+	}
+	return nil, fmt.Errorf("duration has no member %s", name)
+}
+
+// IsTimeOperation reports whether either operand is a TIME or a DURATION, in which case
+// TimeArithmetic applies.
+func IsTimeOperation(left, right Object) bool {
+	switch left.(type) {
+	case *Time, *Duration:
+		return true
+	}
+	switch right.(type) {
+	case *Time, *Duration:
+		return true
+	}
+	return false
+}
+
+// TimeArithmetic applies one of + - * / to times and durations. Subtracting two times gives
+// a duration, a duration moves a time either way, and durations add to and subtract from each
+// other, scale by a number and divide by a number or by another duration, giving a FLOAT.
+func TimeArithmetic(operator string, left, right Object) (Object, error) {
+	switch l := left.(type) {
+	case *Time:
+		switch r := right.(type) {
+		case *Time:
+			if operator == "-" {
+				// Sub saturates rather than overflowing, so check the result leads back.
+				d := l.Value.Sub(r.Value)
+				if !r.Value.Add(d).Equal(l.Value) {
+					return nil, fmt.Errorf("duration out of range")
+				}
+				return &Duration{Value: d}, nil
+			}
+		case *Duration:
+			switch operator {
+			case "+":
+				return &Time{Value: l.Value.Add(r.Value)}, nil
+			case "-":
+				if r.Value == math.MinInt64 {
+					return nil, fmt.Errorf("duration out of range")
+				}
+				return &Time{Value: l.Value.Add(-r.Value)}, nil
+			}
+		}
+	case *Duration:
+		switch r := right.(type) {
+		case *Time:
+			if operator == "+" {
+				return &Time{Value: r.Value.Add(l.Value)}, nil
+			}
+		case *Duration:
+			switch operator {
+			case "+", "-":
+				v, ok := checkedArithmetic(operator, int64(l.Value), int64(r.Value))
+				if !ok {
+					return nil, fmt.Errorf("duration out of range")
+				}
+				return &Duration{Value: time.Duration(v)}, nil
+			case "/":
+				if r.Value == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				return &Float{Value: float64(l.Value) / float64(r.Value)}, nil
+			}
+		default:
+			if f, ok := ToFloat(right); ok && (operator == "*" || operator == "/") {
+				return scaleDuration(operator, l.Value, f)
+			}
+		}
+	default:
+		if d, ok := right.(*Duration); ok && operator == "*" {
+			if f, ok := ToFloat(left); ok {
+				return scaleDuration(operator, d.Value, f)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+func scaleDuration(operator string, d time.Duration, f float64) (Object, error) {
+	if operator == "/" {
+		if f == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		f = 1 / f
+	}
+
+	v := math.Round(float64(d) * f)
+	if v >= math.MaxInt64 || v < math.MinInt64 || math.IsNaN(v) {
+		return nil, fmt.Errorf("duration out of range")
+	}
+	return &Duration{Value: time.Duration(v)}, nil
+}
+
+// milliseconds converts a count of milliseconds to a Duration, or reports false if it does
+// not fit in one.
+func milliseconds(ms int64) (time.Duration, bool) {
+	const unit = int64(time.Millisecond)
+	if ms > math.MaxInt64/unit || ms < math.MinInt64/unit {
+		return 0, false
+	}
+	return time.Duration(ms * unit), true
+}
+
+// The time builtins. They read the time from, and wait on, the clock of the Host.
+
+func builtin_now(host *Host, args ...Object) Object {
+	if errObj := checkArgCount(args, 0, 0); errObj != nil {
+		return errObj
+	}
+
+	return &Time{Value: host.Clock.Now()}
+}
+
+func builtin_unix(host *Host, args ...Object) Object {
+	if errObj := checkArgCount(args, 0, 1); errObj != nil {
+		return errObj
+	}
+
+	if len(args) == 0 {
+		return &Integer{Value: host.Clock.Now().Unix()}
+	}
+	t, ok := args[0].(*Time)
+	if !ok {
+		return newError("argument to `unix` must be a TIME, got %s", args[0].Type())
+	}
+	return &Integer{Value: t.Value.Unix()}
+}
+
+func builtin_format_time(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return errObj
+	}
+
+	t, ok := args[0].(*Time)
+	if !ok {
+		return newError("first argument to `format_time` must be a TIME, got %s", args[0].Type())
+	}
+	layout, errObj := timeLayout("format_time", args)
+	if errObj != nil {
+		return errObj
+	}
+	return &String{Value: t.Value.Format(layout)}
+}
+
+func builtin_parse_time(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 2); errObj != nil {
+		return errObj
+	}
+
+	s, errObj := stringArg("parse_time", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	layout, errObj := timeLayout("parse_time", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return newError("invalid time for `parse_time`: %s", err)
+	}
+	return &Time{Value: t}
+}
+
+// timeLayout returns the layout in the optional second argument, written as Go writes
+// layouts, in terms of 2006-01-02 15:04:05. It defaults to RFC 3339.
+func timeLayout(name string, args []Object) (string, *Error) {
+	if len(args) < 2 {
+		return time.RFC3339, nil
+	}
+	return stringArg(name, args, 1)
+}
+
+func builtin_duration(args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer:
+		d, ok := milliseconds(arg.Value)
+		if !ok {
+			return newError("duration of %dms given to `duration` is out of range", arg.Value)
+		}
+		return &Duration{Value: d}
+	case *String:
+		d, err := time.ParseDuration(arg.Value)
+		if err != nil {
+			return newError("invalid duration for `duration`: %s", err)
+		}
+		return &Duration{Value: d}
+	}
+
+	return newError("argument to `duration` must be an INTEGER of milliseconds or a STRING, got %s", args[0].Type())
+}
+
+func builtin_sleep(host *Host, args ...Object) Object {
+	if errObj := checkArgCount(args, 1, 1); errObj != nil {
+		return errObj
+	}
+
+	var d time.Duration
+	switch arg := args[0].(type) {
+	case *Integer:
+		var ok bool
+		if d, ok = milliseconds(arg.Value); !ok {
+			return newError("duration of %dms given to `sleep` is out of range", arg.Value)
+		}
+	case *Duration:
+		d = arg.Value
+	default:
+		return newError("argument to `sleep` must be an INTEGER of milliseconds or a DURATION, got %s", args[0].Type())
+	}
+	if d < 0 {
+		return newError("cannot sleep for a negative duration, got %s", d)
+	}
+
+	host.Clock.Sleep(d)
+	return nil
+}
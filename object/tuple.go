@@ -0,0 +1,50 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Tuple is a fixed, immutable sequence of values, written (a, b). Tuples may be used as hash
+// keys when their elements may, and are unpacked by let a, b = t;.
+type Tuple struct {
+	Elements []Object
+}
+
+func (t *Tuple) Type() ObjectType { return TupleObj }
+func (t *Tuple) Inspect() string {
+	var out bytes.Buffer
+
+	var els []string
+	for _, el := range t.Elements {
+		els = append(els, el.Inspect())
+	}
+
+	out.WriteByte('(')
+	out.WriteString(strings.Join(els, ", "))
+	if len(els) == 1 {
+		out.WriteByte(',')
+	}
+	out.WriteByte(')')
+
+	return out.String()
+}
+
+// Unpack returns the n elements of a TUPLE or ARRAY, for assignment to n names.
+func Unpack(obj Object, n int) ([]Object, error) {
+	var els []Object
+	switch obj := obj.(type) {
+	case *Tuple:
+		els = obj.Elements
+	case *Array:
+		els = obj.Elements
+	default:
+		return nil, fmt.Errorf("cannot unpack %s", obj.Type())
+	}
+
+	if len(els) != n {
+		return nil, fmt.Errorf("wrong number of values to unpack. expected=%d, got=%d", n, len(els))
+	}
+	return els, nil
+}
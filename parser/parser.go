@@ -5,14 +5,18 @@ import (
 	"github.com/butlermatt/monkey/ast"
 	"github.com/butlermatt/monkey/lexer"
 	"github.com/butlermatt/monkey/token"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 const (
 	_ int = iota
 	Lowest
+	Coalesce    // ??
 	Equals      // ==
 	LessGreater // > or <
+	Range       // 1..10
 	Sum         // + or -
 	Product     // * or /
 	Prefix      // -X or !X
@@ -21,18 +25,25 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
-	token.Eq:       Equals,
-	token.NotEq:    Equals,
-	token.Lt:       LessGreater,
-	token.Gt:       LessGreater,
-	token.LtEq:     LessGreater,
-	token.GtEq:     LessGreater,
-	token.Plus:     Sum,
-	token.Minus:    Sum,
-	token.Slash:    Product,
-	token.Star:     Product,
-	token.LParen:   Call,
-	token.LBracket: Index,
+	token.Eq:          Equals,
+	token.NotEq:       Equals,
+	token.Lt:          LessGreater,
+	token.Gt:          LessGreater,
+	token.LtEq:        LessGreater,
+	token.GtEq:        LessGreater,
+	token.In:          LessGreater,
+	token.DotDot:      Range,
+	token.DotDotEq:    Range,
+	token.Plus:        Sum,
+	token.Minus:       Sum,
+	token.Slash:       Product,
+	token.Star:        Product,
+	token.LParen:      Call,
+	token.LBracket:    Index,
+	token.Dot:         Index,
+	token.OptDot:      Index,
+	token.OptLBracket: Index,
+	token.Coalesce:    Coalesce,
 }
 
 type (
@@ -56,7 +67,9 @@ func New(l *lexer.Lexer) *Parser {
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.Ident, p.parseIdentifier)
-	p.registerPrefix(token.Num, p.parseNumberLiteral)
+	p.registerPrefix(token.Int, p.parseIntegerLiteral)
+	p.registerPrefix(token.Float, p.parseFloatLiteral)
+	p.registerPrefix(token.Dec, p.parseDecimalLiteral)
 	p.registerPrefix(token.Bang, p.parsePrefixExpression)
 	p.registerPrefix(token.Minus, p.parsePrefixExpression)
 	p.registerPrefix(token.True, p.parseBoolean)
@@ -65,8 +78,10 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.If, p.parseIfExpression)
 	p.registerPrefix(token.Function, p.parseFunctionLiteral)
 	p.registerPrefix(token.String, p.parseStringLiteral)
+	p.registerPrefix(token.Bytes, p.parseBytesLiteral)
 	p.registerPrefix(token.LBracket, p.parseArrayLiteral)
 	p.registerPrefix(token.LBrace, p.parseHashLiteral)
+	p.registerPrefix(token.Match, p.parseMatchExpression)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.Plus, p.parseInfixExpressions)
@@ -79,8 +94,15 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.LtEq, p.parseInfixExpressions)
 	p.registerInfix(token.Gt, p.parseInfixExpressions)
 	p.registerInfix(token.GtEq, p.parseInfixExpressions)
+	p.registerInfix(token.In, p.parseInfixExpressions)
+	p.registerInfix(token.DotDot, p.parseRangeExpression)
+	p.registerInfix(token.DotDotEq, p.parseRangeExpression)
 	p.registerInfix(token.LParen, p.parseCallExpression)
 	p.registerInfix(token.LBracket, p.parseIndexExpression)
+	p.registerInfix(token.Dot, p.parseFieldExpression)
+	p.registerInfix(token.OptDot, p.parseFieldExpression)
+	p.registerInfix(token.OptLBracket, p.parseIndexExpression)
+	p.registerInfix(token.Coalesce, p.parseInfixExpressions)
 
 	// Read twice to set the current and peek tokens
 	p.nextToken()
@@ -147,6 +169,10 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.Return:
 		return p.parseReturnStatement()
+	case token.Struct:
+		return p.parseStructStatement()
+	case token.Enum:
+		return p.parseEnumStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -180,6 +206,19 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
+	if p.peekTokenIs(token.Comma) {
+		stmt.Names = []*ast.Identifier{stmt.Name}
+		stmt.Name = nil
+
+		for p.peekTokenIs(token.Comma) {
+			p.nextToken()
+			if !p.expectPeek(token.Ident) {
+				return nil
+			}
+			stmt.Names = append(stmt.Names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		}
+	}
+
 	if !p.expectPeek(token.Assign) {
 		return nil
 	}
@@ -194,12 +233,111 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+func (p *Parser) parseStructStatement() ast.Statement {
+	stmt := &ast.StructStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for !p.peekTokenIs(token.RBrace) {
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+
+		field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if seen[field.Value] {
+			msg := fmt.Sprintf("duplicate field %s in struct %s", field.Value, stmt.Name.Value)
+			p.errors = append(p.errors, msg)
+		}
+		seen[field.Value] = true
+		stmt.Fields = append(stmt.Fields, field)
+
+		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBrace) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseEnumStatement() ast.Statement {
+	stmt := &ast.EnumStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for !p.peekTokenIs(token.RBrace) {
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+
+		variant := &ast.EnumVariant{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		if seen[variant.Name.Value] {
+			msg := fmt.Sprintf("duplicate variant %s in enum %s", variant.Name.Value, stmt.Name.Value)
+			p.errors = append(p.errors, msg)
+		}
+		seen[variant.Name.Value] = true
+
+		if p.peekTokenIs(token.LParen) {
+			p.nextToken()
+			variant.Fields = p.parseFunctionParameters()
+		}
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBrace) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
 	p.nextToken()
 
 	stmt.ReturnValue = p.parseExpression(Lowest)
+	if p.peekTokenIs(token.Comma) {
+		tuple := &ast.TupleLiteral{Token: p.peekToken, Elements: []ast.Expression{stmt.ReturnValue}}
+		for p.peekTokenIs(token.Comma) {
+			p.nextToken()
+			p.nextToken()
+			tuple.Elements = append(tuple.Elements, p.parseExpression(Lowest))
+		}
+		stmt.ReturnValue = tuple
+	}
+
 	if p.peekTokenIs(token.Semicolon) {
 		p.nextToken()
 	}
@@ -255,6 +393,28 @@ func (p *Parser) parseInfixExpressions(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parseRangeExpression parses `start..end` and `start..=end`, with an optional trailing `step n`.
+// `step` is only treated as a keyword in this position so it remains usable as an identifier.
+func (p *Parser) parseRangeExpression(start ast.Expression) ast.Expression {
+	expression := &ast.RangeExpression{
+		Token:     p.curToken,
+		Start:     start,
+		Inclusive: p.curTokenIs(token.DotDotEq),
+	}
+
+	prec := p.curPrecedence()
+	p.nextToken()
+	expression.End = p.parseExpression(prec)
+
+	if p.peekTokenIs(token.Ident) && p.peekToken.Literal == "step" {
+		p.nextToken()
+		p.nextToken()
+		expression.Step = p.parseExpression(prec)
+	}
+
+	return expression
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -278,6 +438,11 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	p.nextToken()
 	list = append(list, p.parseExpression(Lowest))
 
+	return p.parseExpressionListRest(list, end)
+}
+
+// parseExpressionListRest continues parsing a list whose first element has already been parsed.
+func (p *Parser) parseExpressionListRest(list []ast.Expression, end token.TokenType) []ast.Expression {
 	for p.peekTokenIs(token.Comma) {
 		p.nextToken()
 		p.nextToken()
@@ -299,12 +464,30 @@ func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
 
-func (p *Parser) parseNumberLiteral() ast.Expression {
-	lit := &ast.NumberLiteral{Token: p.curToken}
+func (p *Parser) parseIntegerLiteral() ast.Expression {
+	lit := &ast.IntegerLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
+	if err != nil {
+		if v, ok := new(big.Int).SetString(p.curToken.Literal, 10); ok {
+			return &ast.BigIntLiteral{Token: p.curToken, Value: v}
+		}
+
+		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as number", p.curToken.Literal)
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
@@ -313,15 +496,44 @@ func (p *Parser) parseNumberLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseDecimalLiteral() ast.Expression {
+	lit := p.curToken.Literal
+	return &ast.DecimalLiteral{Token: p.curToken, Value: strings.TrimSuffix(lit, "d")}
+}
+
+// parseGroupedExpression parses a parenthesized expression, or a tuple when the parentheses are
+// empty or hold a comma: (), (a,) and (a, b).
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	tuple := &ast.TupleLiteral{Token: p.curToken}
+	if p.peekTokenIs(token.RParen) {
+		p.nextToken()
+		return tuple
+	}
+
 	p.nextToken()
 	exp := p.parseExpression(Lowest)
 
+	if !p.peekTokenIs(token.Comma) {
+		if !p.expectPeek(token.RParen) {
+			return nil
+		}
+		return exp
+	}
+
+	tuple.Elements = append(tuple.Elements, exp)
+	for p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		if p.peekTokenIs(token.RParen) {
+			break
+		}
+		p.nextToken()
+		tuple.Elements = append(tuple.Elements, p.parseExpression(Lowest))
+	}
+
 	if !p.expectPeek(token.RParen) {
 		return nil
 	}
-
-	return exp
+	return tuple
 }
 
 func (p *Parser) parseIfExpression() ast.Expression {
@@ -357,6 +569,88 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LParen) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(Lowest)
+
+	if !p.expectPeek(token.RParen) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBrace) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBrace) {
+		p.nextToken()
+
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expression.Arms = append(expression.Arms, arm)
+
+		if p.peekTokenIs(token.Comma) {
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.RBrace) {
+		return nil
+	}
+
+	return expression
+}
+
+// parseMatchArm parses `pattern => body`, where body is either a block or a single expression.
+// A call whose arguments are all identifiers, like `Circle(r)`, is a variant pattern binding r.
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Token: p.curToken}
+
+	if !(p.curTokenIs(token.Ident) && p.curToken.Literal == "_") {
+		arm.Pattern = p.parseExpression(Lowest)
+
+		if call, ok := arm.Pattern.(*ast.CallExpression); ok && len(call.Arguments) > 0 {
+			var binds []*ast.Identifier
+			for _, a := range call.Arguments {
+				ident, ok := a.(*ast.Identifier)
+				if !ok {
+					binds = nil
+					break
+				}
+				binds = append(binds, ident)
+			}
+
+			if binds != nil {
+				arm.Pattern = call.Function
+				arm.Bindings = binds
+			}
+		}
+	}
+
+	if !p.expectPeek(token.FatArrow) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.LBrace) {
+		p.nextToken()
+		arm.Body = p.parseBlockStatement()
+		return arm
+	}
+
+	p.nextToken()
+	stmt := &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseExpression(Lowest)}
+	arm.Body = &ast.BlockStatement{Token: arm.Token, Statements: []ast.Statement{stmt}}
+
+	return arm
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	lit := &ast.FunctionLiteral{Token: p.curToken}
 
@@ -402,7 +696,7 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 }
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left, Optional: p.curTokenIs(token.OptLBracket)}
 
 	p.nextToken()
 	exp.Index = p.parseExpression(Lowest)
@@ -414,6 +708,20 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseFieldExpression(left ast.Expression) ast.Expression {
+	exp := &ast.FieldExpression{Token: p.curToken, Left: left, Optional: p.curTokenIs(token.OptDot)}
+
+	// Field names may be keywords, as in `re.match(s)`.
+	if token.LookupIdent(p.peekToken.Literal) != p.peekToken.Type {
+		p.peekError(token.Ident)
+		return nil
+	}
+	p.nextToken()
+	exp.Field = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
 	exp.Arguments = p.parseExpressionList(token.RParen)
@@ -424,12 +732,100 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseBytesLiteral() ast.Expression {
+	value, err := strconv.Unquote(`"` + p.curToken.Literal + `"`)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as bytes", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return &ast.BytesLiteral{Token: p.curToken, Value: []byte(value)}
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
-	array.Elements = p.parseExpressionList(token.RBracket)
+
+	if p.peekTokenIs(token.RBracket) {
+		p.nextToken()
+		return array
+	}
+
+	p.nextToken()
+	first := p.parseExpression(Lowest)
+
+	if p.peekTokenIs(token.For) {
+		return p.parseArrayComprehension(array.Token, first)
+	}
+
+	array.Elements = p.parseExpressionListRest([]ast.Expression{first}, token.RBracket)
 	return array
 }
 
+func (p *Parser) parseArrayComprehension(tok token.Token, element ast.Expression) ast.Expression {
+	comp := &ast.ArrayComprehension{Token: tok, Element: element}
+
+	p.nextToken()
+	comp.Clause = p.parseComprehensionClause()
+	if comp.Clause == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.RBracket) {
+		return nil
+	}
+
+	return comp
+}
+
+func (p *Parser) parseHashComprehension(tok token.Token, key, value ast.Expression) ast.Expression {
+	comp := &ast.HashComprehension{Token: tok, Key: key, Value: value}
+
+	p.nextToken()
+	comp.Clause = p.parseComprehensionClause()
+	if comp.Clause == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.RBrace) {
+		return nil
+	}
+
+	return comp
+}
+
+func (p *Parser) parseComprehensionClause() *ast.ComprehensionClause {
+	clause := &ast.ComprehensionClause{Token: p.curToken}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+	clause.Variables = append(clause.Variables, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+	if p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+		clause.Variables = append(clause.Variables, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.In) {
+		return nil
+	}
+
+	p.nextToken()
+	clause.Iterable = p.parseExpression(Lowest)
+
+	if p.peekTokenIs(token.If) {
+		p.nextToken()
+		p.nextToken()
+		clause.Condition = p.parseExpression(Lowest)
+	}
+
+	return clause
+}
+
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken, Pairs: make(map[ast.Expression]ast.Expression)}
 
@@ -437,13 +833,26 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		key := p.parseExpression(Lowest)
 
+		// A brace holding values without keys, such as {1, 2}, is a set.
+		if len(hash.Pairs) == 0 && (p.peekTokenIs(token.Comma) || p.peekTokenIs(token.RBrace)) {
+			set := &ast.SetLiteral{Token: hash.Token}
+			set.Elements = p.parseExpressionListRest([]ast.Expression{key}, token.RBrace)
+			return set
+		}
+
 		if !p.expectPeek(token.Colon) {
 			return nil
 		}
 
 		p.nextToken()
 		value := p.parseExpression(Lowest)
+
+		if len(hash.Pairs) == 0 && p.peekTokenIs(token.For) {
+			return p.parseHashComprehension(hash.Token, key, value)
+		}
+
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBrace) && !p.expectPeek(token.Comma) {
 			return nil
@@ -13,8 +13,11 @@ func TestParser_Errors(t *testing.T) {
 		input string
 		error string
 	}{
-		{"let ident", "let 5 = 5;", `expected next token to be "IDENT", got "NUM" instead`},
-		{"let equals", "let x 5;", `expected next token to be "=", got "NUM" instead`},
+		{"let ident", "let 5 = 5;", `expected next token to be "IDENT", got "INT" instead`},
+		{"let equals", "let x 5;", `expected next token to be "=", got "INT" instead`},
+		{"struct duplicate field", "struct P { x, x }", "duplicate field x in struct P"},
+		{"field name", "p.5", `expected next token to be "IDENT", got "INT" instead`},
+		{"enum duplicate variant", "enum E { A, A }", "duplicate variant A in enum E"},
 	}
 
 	for _, tt := range tests {
@@ -42,7 +45,7 @@ func TestLetStatements(t *testing.T) {
 		ident string
 		value interface{}
 	}{
-		{"five", "let x = 5;", "x", 5.0},
+		{"five", "let x = 5;", "x", 5},
 		{"true", "let y = true;", "y", true},
 		{"ident", "let foobar = y;", "foobar", "y"},
 	}
@@ -95,7 +98,7 @@ func TestReturnStatements(t *testing.T) {
 		input    string
 		expected interface{}
 	}{
-		{"five", "return 5;", 5.0},
+		{"five", "return 5;", 5},
 		{"true", "return true;", true},
 		{"ident", "return foobar;", "foobar"},
 	}
@@ -205,13 +208,13 @@ func TestIntegerLiteralExpression(t *testing.T) {
 		t.Fatalf("program.Statement[0] wrong type. expected=*ast.ExpressionStatement, got=%T", program.Statements[0])
 	}
 
-	literal, ok := stmt.Expression.(*ast.NumberLiteral)
+	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
 	if !ok {
-		t.Fatalf("expression wrong type. expected=*ast.NumberLiteral, got=%T", stmt.Expression)
+		t.Fatalf("expression wrong type. expected=*ast.IntegerLiteral, got=%T", stmt.Expression)
 	}
 
-	if literal.Value != 5.0 {
-		t.Errorf("literal value is incorrect. expected=%f, got=%f", 5.0, literal.Value)
+	if literal.Value != 5 {
+		t.Errorf("literal value is incorrect. expected=%d, got=%d", 5, literal.Value)
 	}
 
 	if literal.TokenLiteral() != "5" {
@@ -219,6 +222,29 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestBigIntLiteralExpression(t *testing.T) {
+	input := "123456789012345678901234567890;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statement[0] wrong type. expected=*ast.ExpressionStatement, got=%T", program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.BigIntLiteral)
+	if !ok {
+		t.Fatalf("expression wrong type. expected=*ast.BigIntLiteral, got=%T", stmt.Expression)
+	}
+
+	if literal.Value.String() != "123456789012345678901234567890" {
+		t.Errorf("literal value is incorrect. expected=%s, got=%s", "123456789012345678901234567890", literal.Value)
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -226,7 +252,7 @@ func TestParsingPrefixExpressions(t *testing.T) {
 		operator string
 		value    interface{}
 	}{
-		{"bang", "!5;", "!", 5.0},
+		{"bang", "!5;", "!", 5},
 		{"minus", "-15.3;", "-", 15.3},
 		{"not true", "!true", "!", true},
 		{"not false", "!false", "!", false},
@@ -270,16 +296,16 @@ func TestParsingInfixExpressions(t *testing.T) {
 		operator   string
 		rightValue interface{}
 	}{
-		{"plus", "5 + 10;", 5.0, "+", 10.0},
+		{"plus", "5 + 10;", 5, "+", 10},
 		{"minus", "10.5 - 5.5;", 10.5, "-", 5.5},
-		{"times", "5.0 * 10;", 5.0, "*", 10.0},
-		{"divide", "10 / 5.0;", 10.0, "/", 5.0},
-		{"greater than", "10 > 5;", 10.0, ">", 5.0},
-		{"less than", "5 < 10;", 5.0, "<", 10.0},
-		{"greater equal", "10 >= 5;", 10.0, ">=", 5.0},
-		{"less equal", "5 <= 10;", 5.0, "<=", 10.0},
-		{"equality", "5 == 5;", 5.0, "==", 5.0},
-		{"not equal", "10 != 5;", 10.0, "!=", 5.0},
+		{"times", "5.0 * 10;", 5.0, "*", 10},
+		{"divide", "10 / 5.0;", 10, "/", 5.0},
+		{"greater than", "10 > 5;", 10, ">", 5},
+		{"less than", "5 < 10;", 5, "<", 10},
+		{"greater equal", "10 >= 5;", 10, ">=", 5},
+		{"less equal", "5 <= 10;", 5, "<=", 10},
+		{"equality", "5 == 5;", 5, "==", 5},
+		{"not equal", "10 != 5;", 10, "!=", 5},
 		{"true", "true == true;", true, "==", true},
 		{"not true", "true != false", true, "!=", false},
 		{"false", "false == false;", false, "==", false},
@@ -335,6 +361,34 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{"!!true", "(!(!true))"},
 		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
 		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"1..n + 1", "(1..(n + 1))"},
+		{"a..=b step c * 2", "(a..=b step (c * 2))"},
+		{"x in 1..10 == true", "((x in (1..10)) == true)"},
+		{"a[1..2]", "(a[(1..2)])"},
+		{"0..10 step -1", "(0..10 step (-1))"},
+		{"[x * 2 for x in xs if x > 0]", "[(x * 2) for x in xs if (x > 0)]"},
+		{"{k: v + 1 for k, v in h}", "{k:(v + 1) for k, v in h}"},
+		{"[[y for y in x] for x in 1..3]", "[[y for y in x] for x in (1..3)]"},
+		{"a.b.c + d.e * 2", "(((a.b).c) + ((d.e) * 2))"},
+		{"-p.x", "(-(p.x))"},
+		{"f(a).b[0]", "((f(a).b)[0])"},
+		{"struct Point { x, y } Point(1, 2).x", "struct Point { x, y }(Point(1, 2).x)"},
+		{"enum Shape { Circle(r), Rect(w, h), Empty }", "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{"match (s) { Circle(r) => r * r, Empty => 0, _ => { 1 } }", "match s { Circle(r) => (r * r), Empty => 0, _ => 1 }"},
+		{"match (x) { 1 + 1 => a, Shape.Rect(w, _) => w }", "match x { (1 + 1) => a, (Shape.Rect)(w, _) => w }"},
+		{"a?.b.c", "((a?.b).c)"},
+		{"re.match(s)", "(re.match)(s)"},
+		{"a?[1]?.b", "((a?[1])?.b)"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a?.b ?? c == d", "((a?.b) ?? (c == d))"},
+		{"a ?? b + c", "(a ?? (b + c))"},
+		{"(a, b + 1)", "(a, (b + 1))"},
+		{"(a,)", "(a,)"},
+		{"()", "()"},
+		{"(a, b)[0]", "((a, b)[0])"},
+		{"return a, b * 2;", "return (a, (b * 2));"},
+		{"let a, b = f();", "let a, b = f();"},
+		{`b"a\x00" + b`, `(b"a\x00" + b)`},
 	}
 
 	for i, tt := range tests {
@@ -350,6 +404,197 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 	}
 }
 
+func TestParsingRangeExpressions(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		inclusive bool
+		step      interface{}
+	}{
+		{"exclusive", "1..10", false, nil},
+		{"inclusive", "1..=10", true, nil},
+		{"with step", "1..10 step 2", false, 2},
+		{"inclusive with step", "1..=10 step step", true, "step"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lexer.New(tt.input)
+			p := New(l)
+			program := p.ParseProgram()
+			checkParseErrors(t, p)
+
+			stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+			if !ok {
+				t.Fatalf("statement wrong type. expected=*ast.ExpressionStatement, got=%T", program.Statements[0])
+			}
+
+			rng, ok := stmt.Expression.(*ast.RangeExpression)
+			if !ok {
+				t.Fatalf("expression wrong type. expected=*ast.RangeExpression, got=%T", stmt.Expression)
+			}
+
+			testIntegerLiteral(t, rng.Start, 1)
+			testIntegerLiteral(t, rng.End, 10)
+
+			if rng.Inclusive != tt.inclusive {
+				t.Errorf("range inclusive incorrect. expected=%t, got=%t", tt.inclusive, rng.Inclusive)
+			}
+
+			if tt.step == nil {
+				if rng.Step != nil {
+					t.Errorf("range step should be nil. got=%s", rng.Step)
+				}
+			} else {
+				testLiteralExpression(t, rng.Step, tt.step)
+			}
+		})
+	}
+}
+
+func TestParsingArrayComprehension(t *testing.T) {
+	input := "[x * 2 for i, x in xs if i > 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("statement wrong type. expected=*ast.ExpressionStatement, got=%T", program.Statements[0])
+	}
+
+	comp, ok := stmt.Expression.(*ast.ArrayComprehension)
+	if !ok {
+		t.Fatalf("expression wrong type. expected=*ast.ArrayComprehension, got=%T", stmt.Expression)
+	}
+
+	testInfixExpression(t, comp.Element, "x", "*", 2)
+
+	if len(comp.Clause.Variables) != 2 {
+		t.Fatalf("wrong number of variables. expected=%d, got=%d", 2, len(comp.Clause.Variables))
+	}
+	testIdentifier(t, comp.Clause.Variables[0], "i")
+	testIdentifier(t, comp.Clause.Variables[1], "x")
+	testIdentifier(t, comp.Clause.Iterable, "xs")
+	testInfixExpression(t, comp.Clause.Condition, "i", ">", 1)
+}
+
+func TestParsingHashComprehension(t *testing.T) {
+	input := "{k: v for k, v in h}"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("statement wrong type. expected=*ast.ExpressionStatement, got=%T", program.Statements[0])
+	}
+
+	comp, ok := stmt.Expression.(*ast.HashComprehension)
+	if !ok {
+		t.Fatalf("expression wrong type. expected=*ast.HashComprehension, got=%T", stmt.Expression)
+	}
+
+	testIdentifier(t, comp.Key, "k")
+	testIdentifier(t, comp.Value, "v")
+	testIdentifier(t, comp.Clause.Iterable, "h")
+
+	if comp.Clause.Condition != nil {
+		t.Errorf("condition should be nil. got=%s", comp.Clause.Condition)
+	}
+}
+
+func TestStructStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		ident  string
+		fields []string
+	}{
+		{"two fields", "struct Point { x, y }", "Point", []string{"x", "y"}},
+		{"trailing comma", "struct Point { x, y, };", "Point", []string{"x", "y"}},
+		{"no fields", "struct Unit {}", "Unit", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lexer.New(tt.input)
+			p := New(l)
+			program := p.ParseProgram()
+			checkParseErrors(t, p)
+
+			if len(program.Statements) != 1 {
+				t.Fatalf("program.Statements wrong length. expected=%d, got=%d", 1, len(program.Statements))
+			}
+
+			stmt, ok := program.Statements[0].(*ast.StructStatement)
+			if !ok {
+				t.Fatalf("stmt wrong type. expected=*ast.StructStatement, got=%T", program.Statements[0])
+			}
+
+			testIdentifier(t, stmt.Name, tt.ident)
+
+			if len(stmt.Fields) != len(tt.fields) {
+				t.Fatalf("wrong number of fields. expected=%d, got=%d", len(tt.fields), len(stmt.Fields))
+			}
+			for i, f := range tt.fields {
+				testIdentifier(t, stmt.Fields[i], f)
+			}
+		})
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	input := `match (shape) { Circle(r) => r, Empty => 0, f(1) => 2, _ => 3 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("statement wrong type. expected=*ast.ExpressionStatement, got=%T", program.Statements[0])
+	}
+
+	match, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("expression wrong type. expected=*ast.MatchExpression, got=%T", stmt.Expression)
+	}
+
+	testIdentifier(t, match.Subject, "shape")
+
+	if len(match.Arms) != 4 {
+		t.Fatalf("wrong number of arms. expected=%d, got=%d", 4, len(match.Arms))
+	}
+
+	variant := match.Arms[0]
+	testIdentifier(t, variant.Pattern, "Circle")
+	if len(variant.Bindings) != 1 {
+		t.Fatalf("wrong number of bindings. expected=%d, got=%d", 1, len(variant.Bindings))
+	}
+	testIdentifier(t, variant.Bindings[0], "r")
+
+	value := match.Arms[1]
+	testIdentifier(t, value.Pattern, "Empty")
+	if value.Bindings != nil {
+		t.Errorf("value pattern should have no bindings. got=%d", len(value.Bindings))
+	}
+
+	call := match.Arms[2]
+	if _, ok := call.Pattern.(*ast.CallExpression); !ok || call.Bindings != nil {
+		t.Errorf("call with literal arguments should be a value pattern. got=%T", call.Pattern)
+	}
+
+	if match.Arms[3].Pattern != nil {
+		t.Errorf("wildcard pattern should be nil. got=%s", match.Arms[3].Pattern)
+	}
+}
+
 func TestIfExpression(t *testing.T) {
 	input := `if (x < y) { x }`
 
@@ -555,9 +800,9 @@ func TestCallExpressionParsing(t *testing.T) {
 		t.Fatalf("argument list wrong length. expected=%d, got=%d", 3, len(function.Arguments))
 	}
 
-	testLiteralExpression(t, function.Arguments[0], 1.0)
-	testInfixExpression(t, function.Arguments[1], 2.0, "*", 3.0)
-	testInfixExpression(t, function.Arguments[2], 4.0, "+", 5.0)
+	testLiteralExpression(t, function.Arguments[0], 1)
+	testInfixExpression(t, function.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, function.Arguments[2], 4, "+", 5)
 }
 
 func TestStringLiteralExpression(t *testing.T) {
@@ -608,9 +853,9 @@ func TestParsingArrayLiterals(t *testing.T) {
 		t.Fatalf("array contains wrong number of elements. expected=%d, got=%d", 3, len(array.Elements))
 	}
 
-	testNumberLiteral(t, array.Elements[0], 1.0)
-	testInfixExpression(t, array.Elements[1], 2.0, "*", 2.0)
-	testInfixExpression(t, array.Elements[2], 3.0, "+", 3.0)
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
 }
 
 func TestParsingIndexExpressions(t *testing.T) {
@@ -636,7 +881,7 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 
 	testIdentifier(t, indexExp.Left, "myArray")
-	testInfixExpression(t, indexExp.Index, 1.0, "+", 2.0)
+	testInfixExpression(t, indexExp.Index, 1, "+", 2)
 }
 
 func TestParsingHashLiteralsStringKeys(t *testing.T) {
@@ -664,7 +909,7 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		t.Fatalf("hash pairs wrong length. expected=%d, got=%d", 3, len(hash.Pairs))
 	}
 
-	expected := map[string]float64{"one": 1.0, "two": 2.0, "three": 3.0}
+	expected := map[string]int64{"one": 1, "two": 2, "three": 3}
 	for k, v := range hash.Pairs {
 		lit, ok := k.(*ast.StringLiteral)
 		if !ok {
@@ -673,7 +918,7 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		}
 
 		val := expected[lit.String()]
-		testNumberLiteral(t, v, val)
+		testIntegerLiteral(t, v, val)
 	}
 }
 
@@ -703,6 +948,37 @@ func TestParsingEmptyHashLiteral(t *testing.T) {
 	}
 }
 
+func TestParsingSetLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"single", "{x}", "{x}"},
+		{"expressions", "{1, 2 * 2, x}", "{1, (2 * 2), x}"},
+		{"trailing hash literal", "{{1: 2}, 3}", "{{1:2}, 3}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lexer.New(tt.input)
+			p := New(l)
+			program := p.ParseProgram()
+			checkParseErrors(t, p)
+
+			stmt := program.Statements[0].(*ast.ExpressionStatement)
+			set, ok := stmt.Expression.(*ast.SetLiteral)
+			if !ok {
+				t.Fatalf("expression wrong type. expected=*ast.SetLiteral, got=%T (%+[1]v)", stmt.Expression)
+			}
+
+			if set.String() != tt.expected {
+				t.Errorf("wrong set. expected=%q, got=%q", tt.expected, set.String())
+			}
+		})
+	}
+}
+
 func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 	input := `{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5};`
 
@@ -729,9 +1005,9 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		left  float64
+		left  int64
 		oper  string
-		right float64
+		right int64
 	}{
 		"one":   {0, "+", 1},
 		"two":   {10, "-", 8},
@@ -769,15 +1045,15 @@ func checkParseErrors(t *testing.T, p *Parser) {
 	t.FailNow()
 }
 
-func testNumberLiteral(t *testing.T, nl ast.Expression, value float64) bool {
-	num, ok := nl.(*ast.NumberLiteral)
+func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
+	num, ok := il.(*ast.IntegerLiteral)
 	if !ok {
-		t.Errorf("expression wrong type. expected=*ast.NumberLiteral, got=%T", nl)
+		t.Errorf("expression wrong type. expected=*ast.IntegerLiteral, got=%T", il)
 		return false
 	}
 
 	if num.Value != value {
-		t.Errorf("number value is incorrect. expected=%f, got=%f", value, num.Value)
+		t.Errorf("integer value is incorrect. expected=%d, got=%d", value, num.Value)
 		return false
 	}
 
@@ -785,6 +1061,21 @@ func testNumberLiteral(t *testing.T, nl ast.Expression, value float64) bool {
 	return true
 }
 
+func testFloatLiteral(t *testing.T, fl ast.Expression, value float64) bool {
+	num, ok := fl.(*ast.FloatLiteral)
+	if !ok {
+		t.Errorf("expression wrong type. expected=*ast.FloatLiteral, got=%T", fl)
+		return false
+	}
+
+	if num.Value != value {
+		t.Errorf("float value is incorrect. expected=%f, got=%f", value, num.Value)
+		return false
+	}
+
+	return true
+}
+
 func testIdentifier(t *testing.T, exp ast.Expression, value string) bool {
 	ident, ok := exp.(*ast.Identifier)
 	if !ok {
@@ -827,8 +1118,12 @@ func testBoolean(t *testing.T, exp ast.Expression, value bool) bool {
 
 func testLiteralExpression(t *testing.T, exp ast.Expression, expected interface{}) bool {
 	switch v := expected.(type) {
+	case int:
+		return testIntegerLiteral(t, exp, int64(v))
+	case int64:
+		return testIntegerLiteral(t, exp, v)
 	case float64:
-		return testNumberLiteral(t, exp, v)
+		return testFloatLiteral(t, exp, v)
 	case string:
 		return testIdentifier(t, exp, v)
 	case bool:
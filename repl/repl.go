@@ -46,6 +46,10 @@ func Start(in io.Reader, out io.Writer) {
 			continue
 		}
 
+		for _, w := range comp.Warnings() {
+			_, _ = fmt.Fprintf(out, "Warning: %s\n", w)
+		}
+
 		code := comp.ByteCode()
 		consts = code.Constants
 
@@ -20,8 +20,11 @@ const (
 
 	// Identifiers & literals
 	Ident  = "IDENT"
-	Num    = "NUM"
+	Int    = "INT"
+	Float  = "FLOAT"
+	Dec    = "DECIMAL"
 	String = "STRING"
+	Bytes  = "BYTES"
 
 	// Operators
 	Assign = "="
@@ -31,6 +34,10 @@ const (
 	Star   = "*"
 	Slash  = "/"
 
+	OptDot      = "?."
+	OptLBracket = "?["
+	Coalesce    = "??"
+
 	// Comparison
 	Eq    = "=="
 	NotEq = "!="
@@ -43,6 +50,10 @@ const (
 	Comma     = ","
 	Semicolon = ";"
 	Colon     = ":"
+	Dot       = "."
+	FatArrow  = "=>"
+	DotDot    = ".."
+	DotDotEq  = "..="
 
 	LParen   = "("
 	RParen   = ")"
@@ -59,6 +70,11 @@ const (
 	If       = "IF"
 	Else     = "ELSE"
 	Return   = "RETURN"
+	In       = "IN"
+	For      = "FOR"
+	Struct   = "STRUCT"
+	Enum     = "ENUM"
+	Match    = "MATCH"
 	// TODO: Add null keyword
 )
 
@@ -70,6 +86,11 @@ var keywords = map[string]TokenType{
 	"true":   True,
 	"false":  False,
 	"return": Return,
+	"in":     In,
+	"for":    For,
+	"struct": Struct,
+	"enum":   Enum,
+	"match":  Match,
 }
 
 // LookupIdent returns the appropriate TokenType based on the ident string provided.
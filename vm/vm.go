@@ -5,6 +5,7 @@ import (
 	"github.com/butlermatt/monkey/code"
 	"github.com/butlermatt/monkey/compiler"
 	"github.com/butlermatt/monkey/object"
+	"math/big"
 )
 
 const MaxFrames = 1024
@@ -12,9 +13,9 @@ const StackSize = 2048
 const GlobalsSize = 65536
 
 var (
-	True  = &object.Boolean{Value: true}
-	False = &object.Boolean{Value: false}
-	Null  = &object.Null{}
+	True  = object.True
+	False = object.False
+	Null  = object.NullValue
 )
 
 type VM struct {
@@ -27,6 +28,10 @@ type VM struct {
 
 	frames   []*Frame
 	frameInd int
+
+	fs      object.FS
+	clock   object.Clock
+	decimal object.DecimalContext
 }
 
 func New(bytecode *compiler.ByteCode) *VM {
@@ -47,6 +52,9 @@ func New(bytecode *compiler.ByteCode) *VM {
 
 		frames:   frames,
 		frameInd: 1,
+
+		clock:   object.SystemClock,
+		decimal: object.DefaultDecimalContext(),
 	}
 }
 
@@ -56,6 +64,21 @@ func NewWithGlobalStore(bytecode *compiler.ByteCode, s []object.Object) *VM {
 	return vm
 }
 
+// SetFS gives the file builtins access to fsys. Without it they report an error.
+func (vm *VM) SetFS(fsys object.FS) {
+	vm.fs = fsys
+}
+
+// SetClock makes the time builtins use clock rather than the system clock.
+func (vm *VM) SetClock(clock object.Clock) {
+	vm.clock = clock
+}
+
+// SetDecimalContext makes decimal division use ctx rather than the DefaultDecimalContext.
+func (vm *VM) SetDecimalContext(ctx object.DecimalContext) {
+	vm.decimal = ctx
+}
+
 func (vm *VM) currentFrame() *Frame {
 	return vm.frames[vm.frameInd-1]
 }
@@ -75,11 +98,17 @@ func (vm *VM) LastPoppedStackElem() object.Object {
 }
 
 func (vm *VM) Run() error {
+	return vm.run(1)
+}
+
+// run executes instructions until the program ends or the frame at depth returns, which lets
+// builtins re-enter the VM to call closures.
+func (vm *VM) run(depth int) error {
 	var ip *int
 	var ins code.Instructions
 	var op code.OpCode
 
-	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+	for vm.frameInd >= depth && vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
 		ip = &(vm.currentFrame().ip)
 		*ip++
 		ins = vm.currentFrame().Instructions()
@@ -98,11 +127,16 @@ func (vm *VM) Run() error {
 			if err != nil {
 				return err
 			}
-		case code.OpGreater, code.OpGreaterEqual, code.OpEqual, code.OpNotEqual:
+		case code.OpGreater, code.OpGreaterEqual, code.OpLess, code.OpLessEqual, code.OpEqual, code.OpNotEqual:
 			err := vm.executeComparison(op)
 			if err != nil {
 				return err
 			}
+		case code.OpIn:
+			err := vm.executeIn()
+			if err != nil {
+				return err
+			}
 		case code.OpTrue:
 			err := vm.push(True)
 			if err != nil {
@@ -133,6 +167,22 @@ func (vm *VM) Run() error {
 		case code.OpJump:
 			pos := int(code.ReadUint16(ins[*ip+1:]))
 			*ip = pos - 1
+		case code.OpJumpNull:
+			pos := int(code.ReadUint16(ins[*ip+1:]))
+			*ip += 2
+
+			if vm.stack[vm.sp-1].Type() == object.NullObj {
+				*ip = pos - 1
+			}
+		case code.OpJumpNotNull:
+			pos := int(code.ReadUint16(ins[*ip+1:]))
+			*ip += 2
+
+			if vm.stack[vm.sp-1].Type() != object.NullObj {
+				*ip = pos - 1
+			} else {
+				vm.pop()
+			}
 		case code.OpJumpNotTrue:
 			pos := int(code.ReadUint16(ins[*ip+1:]))
 			*ip += 2
@@ -179,6 +229,46 @@ func (vm *VM) Run() error {
 			if err != nil {
 				return err
 			}
+		case code.OpSet:
+			numEls := int(code.ReadUint16(ins[*ip+1:]))
+			*ip += 2
+
+			set, err := vm.buildSet(vm.sp-numEls, vm.sp)
+			if err != nil {
+				return err
+			}
+			vm.sp = vm.sp - numEls
+
+			err = vm.push(set)
+			if err != nil {
+				return err
+			}
+		case code.OpTuple:
+			numEls := int(code.ReadUint16(ins[*ip+1:]))
+			*ip += 2
+
+			els := make([]object.Object, numEls)
+			copy(els, vm.stack[vm.sp-numEls:vm.sp])
+			vm.sp = vm.sp - numEls
+
+			err := vm.push(&object.Tuple{Elements: els})
+			if err != nil {
+				return err
+			}
+		case code.OpUnpack:
+			numEls := int(code.ReadUint16(ins[*ip+1:]))
+			*ip += 2
+
+			els, err := object.Unpack(vm.pop(), numEls)
+			if err != nil {
+				return err
+			}
+			for i := len(els) - 1; i >= 0; i-- {
+				err = vm.push(els[i])
+				if err != nil {
+					return err
+				}
+			}
 		case code.OpIndex:
 			ind := vm.pop()
 			left := vm.pop()
@@ -187,6 +277,84 @@ func (vm *VM) Run() error {
 			if err != nil {
 				return err
 			}
+		case code.OpField:
+			nameInd := code.ReadUint16(ins[*ip+1:])
+			*ip += 2
+
+			name := vm.constants[nameInd].(*object.String).Value
+			val, err := object.Field(vm.pop(), name)
+			if err != nil {
+				return err
+			}
+
+			err = vm.push(val)
+			if err != nil {
+				return err
+			}
+		case code.OpMatchValue:
+			pos := int(code.ReadUint16(ins[*ip+1:]))
+			*ip += 2
+
+			val := vm.pop()
+			if object.Equal(vm.stack[vm.sp-1], val) {
+				vm.pop()
+			} else {
+				*ip = pos - 1
+			}
+		case code.OpMatchVariant:
+			numBinds := code.ReadUint8(ins[*ip+1:])
+			pos := int(code.ReadUint16(ins[*ip+2:]))
+			*ip += 3
+
+			matched, err := vm.executeMatchVariant(int(numBinds))
+			if err != nil {
+				return err
+			}
+			if !matched {
+				*ip = pos - 1
+			}
+		case code.OpRange:
+			flags := code.ReadUint8(ins[*ip+1:])
+			*ip += 1
+
+			err := vm.executeRange(flags)
+			if err != nil {
+				return err
+			}
+		case code.OpIter:
+			iter, err := object.NewIterator(vm.pop())
+			if err != nil {
+				return err
+			}
+
+			err = vm.push(iter)
+			if err != nil {
+				return err
+			}
+		case code.OpIterNext:
+			numVars := code.ReadUint8(ins[*ip+1:])
+			pos := int(code.ReadUint16(ins[*ip+2:]))
+			*ip += 3
+
+			done, err := vm.executeIterNext(int(numVars))
+			if err != nil {
+				return err
+			}
+			if done {
+				*ip = pos - 1
+			}
+		case code.OpAppend:
+			val := vm.pop()
+			array := vm.stack[vm.sp-2].(*object.Array)
+			array.Elements = append(array.Elements, val)
+		case code.OpInsert:
+			val := vm.pop()
+			key := vm.pop()
+
+			err := vm.executeInsert(key, val)
+			if err != nil {
+				return err
+			}
 		case code.OpCall:
 			numArgs := code.ReadUint8(ins[*ip+1:])
 			*ip += 1
@@ -299,18 +467,30 @@ func (vm *VM) executeBinaryOperation(op code.OpCode) error {
 	lType := left.Type()
 	rType := right.Type()
 
-	if lType == object.NumberObj && rType == object.NumberObj {
+	if object.IsDecimalOperation(left, right) {
+		return vm.executeBinaryDecimalOperation(op, left, right)
+	} else if object.IsNumber(left) && object.IsNumber(right) {
 		return vm.executeBinaryNumberOperation(op, left, right)
 	} else if lType == object.StringObj && rType == object.StringObj {
 		return vm.executeBinaryStringOperation(op, left, right)
+	} else if lType == object.BytesObj && rType == object.BytesObj {
+		return vm.executeBinaryBytesOperation(op, left, right)
+	} else if object.IsTimeOperation(left, right) {
+		return vm.executeBinaryTimeOperation(op, left, right)
 	}
 
 	return fmt.Errorf("unsupported types for binary operation: %s %s", lType, rType)
 }
 
+// executeBinaryNumberOperation keeps arithmetic on two integers in integers, promoting to BIGINT
+// on overflow and dividing with truncation, and promotes to float when either side is a float.
 func (vm *VM) executeBinaryNumberOperation(op code.OpCode, left, right object.Object) error {
-	lVal := left.(*object.Number).Value
-	rVal := right.(*object.Number).Value
+	if object.IsInteger(left) && object.IsInteger(right) {
+		return vm.executeBinaryIntegerOperation(op, left, right)
+	}
+
+	lVal, _ := object.ToFloat(left)
+	rVal, _ := object.ToFloat(right)
 
 	var result float64
 	switch op {
@@ -326,7 +506,49 @@ func (vm *VM) executeBinaryNumberOperation(op code.OpCode, left, right object.Ob
 		return fmt.Errorf("unknown number operator: %d", op)
 	}
 
-	return vm.push(&object.Number{Value: result})
+	return vm.push(&object.Float{Value: result})
+}
+
+func (vm *VM) executeBinaryIntegerOperation(op code.OpCode, left, right object.Object) error {
+	operator, ok := arithmeticOperator(op)
+	if !ok {
+		return fmt.Errorf("unknown integer operator: %d", op)
+	}
+
+	result, err := object.IntegerArithmetic(operator, left, right)
+	if err != nil {
+		return err
+	}
+
+	return vm.push(result)
+}
+
+func (vm *VM) executeBinaryDecimalOperation(op code.OpCode, left, right object.Object) error {
+	operator, ok := arithmeticOperator(op)
+	if !ok {
+		return fmt.Errorf("unknown decimal operator: %d", op)
+	}
+
+	result, err := vm.decimal.Arithmetic(operator, left, right)
+	if err != nil {
+		return err
+	}
+
+	return vm.push(result)
+}
+
+func arithmeticOperator(op code.OpCode) (string, bool) {
+	switch op {
+	case code.OpAdd:
+		return "+", true
+	case code.OpSub:
+		return "-", true
+	case code.OpMul:
+		return "*", true
+	case code.OpDiv:
+		return "/", true
+	}
+	return "", false
 }
 
 func (vm *VM) executeBinaryStringOperation(op code.OpCode, left, right object.Object) error {
@@ -340,27 +562,77 @@ func (vm *VM) executeBinaryStringOperation(op code.OpCode, left, right object.Ob
 	return vm.push(&object.String{Value: lval + rval})
 }
 
+func (vm *VM) executeBinaryBytesOperation(op code.OpCode, left, right object.Object) error {
+	if op != code.OpAdd {
+		return fmt.Errorf("unknown bytes operator: %d", op)
+	}
+
+	lval := left.(*object.Bytes).Value
+	rval := right.(*object.Bytes).Value
+
+	return vm.push(&object.Bytes{Value: append(lval[:len(lval):len(lval)], rval...)})
+}
+
+func (vm *VM) executeBinaryTimeOperation(op code.OpCode, left, right object.Object) error {
+	operator, _ := arithmeticOperator(op)
+	result, err := object.TimeArithmetic(operator, left, right)
+	if err != nil {
+		return err
+	}
+
+	return vm.push(result)
+}
+
 func (vm *VM) executeComparison(op code.OpCode) error {
 	right := vm.pop()
 	left := vm.pop()
 
-	if left.Type() == object.NumberObj && right.Type() == object.NumberObj {
+	if object.IsDecimalOperation(left, right) {
+		return vm.executeOrderedComparison(op, object.CompareDecimals(left, right))
+	}
+
+	if object.IsNumber(left) && object.IsNumber(right) {
 		return vm.executeNumberComparison(op, left, right)
 	}
 
 	switch op {
 	case code.OpEqual:
-		return vm.push(nativeBoolToObject(right == left))
+		return vm.push(nativeBoolToObject(object.Equal(left, right)))
 	case code.OpNotEqual:
-		return vm.push(nativeBoolToObject(right != left))
-	default:
-		return fmt.Errorf("unknown operator: %d (%s %s)", op, left.Type(), right.Type())
+		return vm.push(nativeBoolToObject(!object.Equal(left, right)))
 	}
+
+	// Report the operator as written, the same way the evaluator does.
+	if left.Type() != right.Type() {
+		return fmt.Errorf("type mismatch: %s %s %s", left.Type(), comparisonOperator(op), right.Type())
+	}
+	if !isOrdered(left) {
+		return fmt.Errorf("unknown operator: %s %s %s", left.Type(), comparisonOperator(op), right.Type())
+	}
+
+	cmp, err := object.Compare(left, right)
+	if err != nil {
+		return err
+	}
+	return vm.executeOrderedComparison(op, cmp)
+}
+
+// isOrdered reports whether obj supports the ordering operators beyond numbers.
+func isOrdered(obj object.Object) bool {
+	switch obj.Type() {
+	case object.StringObj, object.BytesObj, object.ArrayObj, object.TupleObj, object.TimeObj, object.DurationObj:
+		return true
+	}
+	return false
 }
 
 func (vm *VM) executeNumberComparison(op code.OpCode, left, right object.Object) error {
-	lVal := left.(*object.Number).Value
-	rVal := right.(*object.Number).Value
+	if object.IsInteger(left) && object.IsInteger(right) {
+		return vm.executeOrderedComparison(op, object.CompareIntegers(left, right))
+	}
+
+	lVal, _ := object.ToFloat(left)
+	rVal, _ := object.ToFloat(right)
 
 	switch op {
 	case code.OpEqual:
@@ -371,11 +643,141 @@ func (vm *VM) executeNumberComparison(op code.OpCode, left, right object.Object)
 		return vm.push(nativeBoolToObject(lVal > rVal))
 	case code.OpGreaterEqual:
 		return vm.push(nativeBoolToObject(lVal >= rVal))
+	case code.OpLess:
+		return vm.push(nativeBoolToObject(lVal < rVal))
+	case code.OpLessEqual:
+		return vm.push(nativeBoolToObject(lVal <= rVal))
 	default:
 		return fmt.Errorf("unknown operator: %d", op)
 	}
 }
 
+// executeOrderedComparison pushes the result of comparing two values for which cmp is -1, 0 or 1
+// as the left is less than, equal to or greater than the right.
+func (vm *VM) executeOrderedComparison(op code.OpCode, cmp int) error {
+	switch op {
+	case code.OpEqual:
+		return vm.push(nativeBoolToObject(cmp == 0))
+	case code.OpNotEqual:
+		return vm.push(nativeBoolToObject(cmp != 0))
+	case code.OpGreater:
+		return vm.push(nativeBoolToObject(cmp > 0))
+	case code.OpGreaterEqual:
+		return vm.push(nativeBoolToObject(cmp >= 0))
+	case code.OpLess:
+		return vm.push(nativeBoolToObject(cmp < 0))
+	case code.OpLessEqual:
+		return vm.push(nativeBoolToObject(cmp <= 0))
+	default:
+		return fmt.Errorf("unknown operator: %d", op)
+	}
+}
+
+func comparisonOperator(op code.OpCode) string {
+	switch op {
+	case code.OpEqual:
+		return "=="
+	case code.OpNotEqual:
+		return "!="
+	case code.OpGreater:
+		return ">"
+	case code.OpGreaterEqual:
+		return ">="
+	case code.OpLess:
+		return "<"
+	case code.OpLessEqual:
+		return "<="
+	}
+	return ""
+}
+
+func (vm *VM) executeIn() error {
+	container := vm.pop()
+	item := vm.pop()
+
+	found, err := object.Contains(container, item)
+	if err != nil {
+		return err
+	}
+
+	return vm.push(nativeBoolToObject(found))
+}
+
+func (vm *VM) executeRange(flags uint8) error {
+	var step object.Object
+	if flags&code.RangeStep != 0 {
+		step = vm.pop()
+	}
+	end := vm.pop()
+	start := vm.pop()
+
+	r, err := object.NewRange(start, end, step, flags&code.RangeInclusive != 0)
+	if err != nil {
+		return err
+	}
+
+	return vm.push(r)
+}
+
+// executeIterNext advances the iterator on top of the stack and pushes numVars values for the
+// loop variables. When the iterator is exhausted it is popped and done is true.
+func (vm *VM) executeIterNext(numVars int) (done bool, err error) {
+	iter := vm.stack[vm.sp-1].(*object.Iterator)
+
+	if !iter.Next() {
+		vm.pop()
+		return true, nil
+	}
+
+	if numVars == 1 {
+		return false, vm.push(iter.Item())
+	}
+
+	key, val := iter.Pair()
+	err = vm.push(key)
+	if err != nil {
+		return false, err
+	}
+
+	return false, vm.push(val)
+}
+
+// executeInsert adds the pair to the hash beneath the active iterator.
+func (vm *VM) executeInsert(key, val object.Object) error {
+	hash := vm.stack[vm.sp-2].(*object.Hash)
+
+	return hash.Set(key, val)
+}
+
+// executeMatchVariant tests the match subject against the variant on top of the stack. When it
+// matches, the subject is replaced by its field values so they can be bound.
+func (vm *VM) executeMatchVariant(numBinds int) (bool, error) {
+	pattern := vm.pop()
+
+	variant, ok := pattern.(*object.Variant)
+	if !ok {
+		return false, fmt.Errorf("not an enum variant: %s", pattern.Type())
+	}
+	if len(variant.Fields) != numBinds {
+		return false, fmt.Errorf("wrong number of bindings for %s: expected=%d, got=%d", variant.Name, len(variant.Fields), numBinds)
+	}
+
+	value, ok := vm.stack[vm.sp-1].(*object.EnumValue)
+	if !ok || value.Variant != variant {
+		return false, nil
+	}
+
+	vm.pop()
+	for _, v := range value.Values {
+		err := vm.push(v)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
 func (vm *VM) executeBangOperator() error {
 	operand := vm.pop()
 
@@ -389,12 +791,20 @@ func (vm *VM) executeBangOperator() error {
 func (vm *VM) executeMinusOperator() error {
 	oper := vm.pop()
 
-	if oper.Type() != object.NumberObj {
-		return fmt.Errorf("unsupported type for negation: %s", oper.Type())
+	switch oper := oper.(type) {
+	case *object.Integer, *object.BigInt:
+		result, err := object.IntegerArithmetic("-", &object.Integer{Value: 0}, oper)
+		if err != nil {
+			return err
+		}
+		return vm.push(result)
+	case *object.Float:
+		return vm.push(&object.Float{Value: -oper.Value})
+	case *object.Decimal:
+		return vm.push(&object.Decimal{Unscaled: new(big.Int).Neg(oper.Unscaled), Scale: oper.Scale})
 	}
 
-	value := oper.(*object.Number).Value
-	return vm.push(&object.Number{Value: -value})
+	return fmt.Errorf("unsupported type for negation: %s", oper.Type())
 }
 
 func (vm *VM) executeCall(numArgs int) error {
@@ -404,6 +814,8 @@ func (vm *VM) executeCall(numArgs int) error {
 		return vm.callClosure(callee, numArgs)
 	case *object.Builtin:
 		return vm.callBuiltin(callee, numArgs)
+	case object.Constructor:
+		return vm.callConstructor(callee, numArgs)
 	}
 
 	return fmt.Errorf("calling non-function and non-built-in")
@@ -420,28 +832,52 @@ func (vm *VM) buildArray(start, end int) object.Object {
 }
 
 func (vm *VM) buildHash(start, end int) (object.Object, error) {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
 	for i := start; i < end; i += 2 {
 		key := vm.stack[i]
 		val := vm.stack[i+1]
 
-		pair := object.HashPair{Key: key, Value: val}
-		hk, ok := key.(object.Hashable)
-		if !ok {
-			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+		err := hash.Set(key, val)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		pairs[hk.HashKey()] = pair
+	return hash, nil
+}
+
+func (vm *VM) buildSet(start, end int) (object.Object, error) {
+	set := object.NewSet()
+
+	for i := start; i < end; i++ {
+		err := set.Add(vm.stack[i])
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return &object.Hash{Pairs: pairs}, nil
+	return set, nil
 }
 
 func (vm *VM) executeIndexExpression(left, index object.Object) error {
 	switch {
-	case left.Type() == object.ArrayObj && index.Type() == object.NumberObj:
-		return vm.executeArrayIndex(left, index)
+	case left.Type() == object.ArrayObj && index.Type() == object.IntegerObj:
+		return vm.executeArrayIndex(left.(*object.Array).Elements, index)
+	case left.Type() == object.TupleObj && index.Type() == object.IntegerObj:
+		return vm.executeArrayIndex(left.(*object.Tuple).Elements, index)
+	case left.Type() == object.BytesObj && index.Type() == object.IntegerObj:
+		return vm.executeBytesIndex(left, index)
+	case left.Type() == object.StringObj && index.Type() == object.IntegerObj:
+		return vm.executeStringIndex(left, index)
+	case left.Type() == object.RangeObj && index.Type() == object.IntegerObj:
+		return vm.executeRangeIndex(left, index)
+	case index.Type() == object.RangeObj && left.Type() != object.HashObj:
+		slice, err := object.Slice(left, index.(*object.Range))
+		if err != nil {
+			return err
+		}
+		return vm.push(slice)
 	case left.Type() == object.HashObj:
 		return vm.executeHashIndex(left, index)
 	default:
@@ -449,27 +885,56 @@ func (vm *VM) executeIndexExpression(left, index object.Object) error {
 	}
 }
 
-func (vm *VM) executeArrayIndex(array, index object.Object) error {
-	arr := array.(*object.Array)
-	i := int(index.(*object.Number).Value)
+func (vm *VM) executeArrayIndex(els []object.Object, index object.Object) error {
+	i := int(index.(*object.Integer).Value)
 
-	max := len(arr.Elements) - 1
+	max := len(els) - 1
 	if i < 0 || i > max {
 		return vm.push(Null)
 	}
 
-	return vm.push(arr.Elements[i])
+	return vm.push(els[i])
 }
 
-func (vm *VM) executeHashIndex(hash, index object.Object) error {
-	hashObj := hash.(*object.Hash)
+func (vm *VM) executeStringIndex(str, index object.Object) error {
+	char, ok := str.(*object.String).At(index.(*object.Integer).Value)
+	if !ok {
+		return vm.push(Null)
+	}
+
+	return vm.push(char)
+}
+
+func (vm *VM) executeBytesIndex(buf, index object.Object) error {
+	b := buf.(*object.Bytes).Value
+	i := index.(*object.Integer).Value
+
+	if i < 0 || i >= int64(len(b)) {
+		return vm.push(Null)
+	}
+
+	return vm.push(&object.Integer{Value: int64(b[i])})
+}
 
-	key, ok := index.(object.Hashable)
+func (vm *VM) executeRangeIndex(rng, index object.Object) error {
+	r := rng.(*object.Range)
+	i := index.(*object.Integer).Value
+
+	v, ok := r.At(i)
 	if !ok {
-		return fmt.Errorf("unusable as hash key: %s", index.Type())
+		return vm.push(Null)
 	}
 
-	pair, ok := hashObj.Pairs[key.HashKey()]
+	return vm.push(&object.Integer{Value: v})
+}
+
+func (vm *VM) executeHashIndex(hash, index object.Object) error {
+	hashObj := hash.(*object.Hash)
+
+	pair, ok, err := hashObj.Get(index)
+	if err != nil {
+		return err
+	}
 	if !ok {
 		return vm.push(Null)
 	}
@@ -496,7 +961,24 @@ func (vm *VM) callClosure(cl *object.Closure, numArgs int) error {
 func (vm *VM) callBuiltin(fn *object.Builtin, numArgs int) error {
 	args := vm.stack[vm.sp-numArgs : vm.sp]
 
-	result := fn.Fn(args...)
+	var result object.Object
+	if fn.HostFn != nil {
+		var callErr error
+		call := func(callee object.Object, args ...object.Object) object.Object {
+			val, err := vm.callFunction(callee, args)
+			if err != nil {
+				callErr = err
+				return &object.Error{Message: err.Error()}
+			}
+			return val
+		}
+		result = fn.HostFn(&object.Host{Call: call, FS: vm.fs, Clock: vm.clock}, args...)
+		if callErr != nil {
+			return callErr
+		}
+	} else {
+		result = fn.Fn(args...)
+	}
 	vm.sp = vm.sp - numArgs - 1
 
 	var err error
@@ -509,6 +991,51 @@ func (vm *VM) callBuiltin(fn *object.Builtin, numArgs int) error {
 	return err
 }
 
+// callFunction calls fn with args from inside another instruction, running the VM until the
+// call returns, and hands back the result.
+func (vm *VM) callFunction(fn object.Object, args []object.Object) (object.Object, error) {
+	base, depth := vm.sp, vm.frameInd
+	defer func() {
+		vm.sp, vm.frameInd = base, depth
+	}()
+
+	err := vm.push(fn)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		err = vm.push(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = vm.executeCall(len(args))
+	if err != nil {
+		return nil, err
+	}
+	if vm.frameInd > depth {
+		err = vm.run(vm.frameInd)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return vm.stack[vm.sp-1], nil
+}
+
+func (vm *VM) callConstructor(c object.Constructor, numArgs int) error {
+	args := vm.stack[vm.sp-numArgs : vm.sp]
+
+	inst, err := c.New(args)
+	if err != nil {
+		return err
+	}
+	vm.sp = vm.sp - numArgs - 1
+
+	return vm.push(inst)
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj := obj.(type) {
 	case *object.Boolean:
@@ -4,10 +4,17 @@ import (
 	"fmt"
 	"github.com/butlermatt/monkey/ast"
 	"github.com/butlermatt/monkey/compiler"
+	"github.com/butlermatt/monkey/evaluator"
 	"github.com/butlermatt/monkey/lexer"
 	"github.com/butlermatt/monkey/object"
 	"github.com/butlermatt/monkey/parser"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 type vmTestCase struct {
@@ -18,28 +25,51 @@ type vmTestCase struct {
 
 func TestNumberArithmetic(t *testing.T) {
 	tests := []vmTestCase{
-		{"one", "1;", 1.0},
-		{"two", "2;", 2.0},
-		{"one plus two", "1 + 2;", 3.0},
-		{"one minus two", "1 - 2;", -1.0},
-		{"one times two", "1 * 2;", 2.0},
-		{"four div two", "4 / 2;", 2.0},
-		{"compound 1", "50 / 2 * 2 + 10 - 5", 55.0},
-		{"compound 2", "5 * (2 + 10)", 60.0},
-		{"compound 3", "5 + 5 + 5 + 5 - 10", 10.0},
-		{"compound 4", "2 * 2 * 2 * 2 * 2", 32.0},
-		{"compound 5", "5 * 2 + 10", 20.0},
-		{"compound 6", "5 + 2 * 10", 25.0},
-		{"compound 7", "5 * (2 + 10)", 60.0},
-		{"negative 5", "-5;", -5.0},
+		{"one", "1;", 1},
+		{"two", "2;", 2},
+		{"one plus two", "1 + 2;", 3},
+		{"one minus two", "1 - 2;", -1},
+		{"one times two", "1 * 2;", 2},
+		{"four div two", "4 / 2;", 2},
+		{"compound 1", "50 / 2 * 2 + 10 - 5", 55},
+		{"compound 2", "5 * (2 + 10)", 60},
+		{"compound 3", "5 + 5 + 5 + 5 - 10", 10},
+		{"compound 4", "2 * 2 * 2 * 2 * 2", 32},
+		{"compound 5", "5 * 2 + 10", 20},
+		{"compound 6", "5 + 2 * 10", 25},
+		{"compound 7", "5 * (2 + 10)", 60},
+		{"negative 5", "-5;", -5},
 		{"negative 10.5", "-10.5;", -10.5},
-		{"negative compound 1", "-50 + 100 + -50", 0.0},
-		{"negative compound 2", "(5 + 10 * 2 + 15 / 3) * 2 + -10", 50.0},
+		{"negative compound 1", "-50 + 100 + -50", 0},
+		{"negative compound 2", "(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"float plus integer", "1.5 + 2", 3.5},
+		{"integer times float", "2 * 2.0", 4.0},
+		{"integer division truncates", "7 / 2", 3},
+		{"float division", "7 / 2.0", 3.5},
+		{"float division by zero", "1.0 / 0", math.Inf(1)},
 	}
 
 	runVmTests(t, tests)
 }
 
+func TestDivisionByZero(t *testing.T) {
+	comp := compiler.New()
+	err := comp.Compile(parse("1 / 0"))
+	if err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	vm := New(comp.ByteCode())
+	err = vm.Run()
+	if err == nil {
+		t.Fatalf("expected VM error but had none.")
+	}
+
+	if err.Error() != "division by zero" {
+		t.Fatalf("wrong VM error. expected=%q, got=%q", "division by zero", err)
+	}
+}
+
 func TestBooleanExpressions(t *testing.T) {
 	tests := []vmTestCase{
 		{"true", "true;", true},
@@ -54,6 +84,9 @@ func TestBooleanExpressions(t *testing.T) {
 		{"1 gteq 1", "1 >= 1", true},
 		{"1 eq 1", "1 == 1", true},
 		{"1 noteq 1", "1 != 1", false},
+		{"1 eq 1.0", "1 == 1.0", true},
+		{"1 lt 1.5", "1 < 1.5", true},
+		{"2.5 gteq 2", "2.5 >= 2", true},
 		{"true eq true", "true == true", true},
 		{"false eq false", "false == false", true},
 		{"true eq false", "true == false", false},
@@ -79,18 +112,627 @@ func TestBooleanExpressions(t *testing.T) {
 	runVmTests(t, tests)
 }
 
+func TestEquality(t *testing.T) {
+	tests := []vmTestCase{
+		{"strings", `"a" == "a"`, true},
+		{"different strings", `"a" != "b"`, true},
+		{"nested arrays", "[1, [2, 3]] == [1, [2, 3]]", true},
+		{"array lengths", "[1, 2] == [1, 2, 3]", false},
+		{"array elements", "[1, 2] == [1, 3]", false},
+		{"mixed numbers", "[1, 2.0] == [1.0, 2]", true},
+		{"hashes", `{"a": [1]} == {"a": [1]}`, true},
+		{"hash values", `{"a": 1} == {"a": 2}`, false},
+		{"hash keys", `{"a": 1} != {"b": 1}`, true},
+		{"ranges", "1..4 == 1..=3", true},
+		{"empty ranges", "0..0 == 5..5", true},
+		{"different types", `1 == "1"`, false},
+		{"array and string", `[1] != "a"`, true},
+		{"same function", "let f = fn() { 1 }; f == f", true},
+		{"different functions", "fn() { 1 } == fn() { 1 }", false},
+		{"instances with arrays", "struct P { x }; P([1]) == P([1])", true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestOrdering(t *testing.T) {
+	tests := []vmTestCase{
+		{"strings less", `"a" < "b"`, true},
+		{"strings differ late", `"abc" < "abd"`, true},
+		{"string prefix", `"ab" < "abc"`, true},
+		{"string first byte wins", `"b" >= "abc"`, true},
+		{"upper before lower", `"Z" < "a"`, true},
+		{"string less equal", `"a" <= "a"`, true},
+		{"string greater", `"a" > "a"`, false},
+		{"arrays", "[1, 2] < [1, 3]", true},
+		{"array prefix", "[1, 2] < [1, 2, 0]", true},
+		{"array first element wins", "[2] > [1, 9]", true},
+		{"empty arrays", "[] <= []", true},
+		{"arrays of strings", `[1, "b"] > [1, "a"]`, true},
+		{"nested arrays", "[[1, 2]] < [[1, 3]]", true},
+		{"mixed numbers", "[1.5] < [2]", true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestCompositeHashKeys(t *testing.T) {
+	tests := []vmTestCase{
+		{"array key", `{[1, 2]: "a"}[[1, 2]]`, "a"},
+		{"nested array key", "{[1, [2, 3]]: 5}[[1, [2, 3]]]", 5},
+		{"element order matters", "{[1, 2]: 1, [2, 1]: 2}[[2, 1]]", 2},
+		{"missing array key", "{[1, 2]: 1}[[1, 2, 3]]", Null},
+		{"numbers compare numerically", "{[1]: 1}[[1.0]]", 1},
+		{"hash key", `{{"a": 1, "b": 2}: 3}[{"b": 2, "a": 1}]`, 3},
+		{"repeated array key", "{[1, 2]: 1, [1, 2]: 2}[[1, 2]]", 2},
+		{"array key in hash", "[1, 2] in {[1, 2]: true}", true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestSets(t *testing.T) {
+	tests := []vmTestCase{
+		{"literal", "str({1, 2, 2, 3})", "{1, 2, 3}"},
+		{"empty", "str(set())", "set()"},
+		{"from array", "str(set([3, 1, 3]))", "{3, 1}"},
+		{"from hash", `str(set({"a": 1, "b": 2}))`, "{a, b}"},
+		{"len", "len({1, 2, 1.0})", 2},
+		{"in", "2 in {1, 2}", true},
+		{"has", "has({1, 2}, 3)", false},
+		{"has in condition", "if (has({1}, 2)) { 1 } else { 2 }", 2},
+		{"add", "str(add({1}, 2))", "{1, 2}"},
+		{"add copies", "let s = {1}; add(s, 2); len(s)", 1},
+		{"remove", "str(remove({1, 2, 3}, 2))", "{1, 3}"},
+		{"remove one argument", "remove({1})", &object.Error{Message: "wrong number of arguments. expected=2, got=1"}},
+		{"remove three arguments", "remove({1}, 1, 2)", &object.Error{Message: "wrong number of arguments. expected=2, got=3"}},
+		{"remove from string", `remove("a.txt", 1)`, &object.Error{Message: "argument to `remove` must be a SET, got STRING"}},
+		{"union", "str(union({1, 2}, {2, 3}))", "{1, 2, 3}"},
+		{"intersection", "str(intersection({1, 2, 3}, {3, 2}))", "{2, 3}"},
+		{"difference", "str(difference({1, 2, 3}, {2}))", "{1, 3}"},
+		{"subset", "subset({1, 2}, {2, 1, 3})", true},
+		{"not subset", "subset({1, 4}, {1, 2})", false},
+		{"equal", "{1, 2} == {2, 1}", true},
+		{"set key", `{{1, 2}: "a"}[{2, 1}]`, "a"},
+		{"array elements", "len({[1, 2], [1, 2]})", 1},
+		{"iterate", "str([x * 2 for x in {1, 2, 3}])", "[2, 4, 6]"},
+		{"add to array", "add([1], 2)", &object.Error{Message: "argument to `add` must be a SET, got ARRAY"}},
+		{"union with array", "union({1}, [1])", &object.Error{Message: "second argument to `union` must be a SET, got ARRAY"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestTuples(t *testing.T) {
+	tests := []vmTestCase{
+		{"inspect", `str((1, "a"))`, "(1, a)"},
+		{"single", "str((1,))", "(1,)"},
+		{"empty", "str(())", "()"},
+		{"grouping", "(1 + 2) * 2", 6},
+		{"index", "(1, 2)[1]", 2},
+		{"len", "len((1, 2, 3))", 3},
+		{"equal", "(1, 2) == (1, 2)", true},
+		{"not an array", "(1, 2) == [1, 2]", false},
+		{"ordering", "(1, 2) < (1, 3)", true},
+		{"hash key", `{(1, 2): "a"}[(1, 2)]`, "a"},
+		{"in", "2 in (1, 2)", true},
+		{"iterate", "str([x for x in (1, 2)])", "[1, 2]"},
+		{"return pair", "let f = fn() { return 1, 2; }; let a, b = f(); a * 10 + b", 12},
+		{"local unpack", "let f = fn(x) { let q, r = (x / 3, x - x / 3 * 3); q * 10 + r }; f(7)", 21},
+		{"unpack array", "let a, b = [3, 4]; a + b", 7},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBytes(t *testing.T) {
+	tests := []vmTestCase{
+		{"literal", `b"ab\x00\xff"`, bytesValue("ab\x00\xff")},
+		{"inspect", `str(b"a\"\n\x7f")`, `b"a\"\x0a\x7f"`},
+		{"index", `b"\x00\xff"[1]`, 255},
+		{"index out of range", `b"a"[1]`, Null},
+		{"slice", `b"hello"[1..3]`, bytesValue("el")},
+		{"concat", `b"ab" + b"\x00"`, bytesValue("ab\x00")},
+		{"len", `len(b"\x00\x01\x02")`, 3},
+		{"equal", `b"ab" == bytes("ab")`, true},
+		{"not a string", `b"ab" == "ab"`, false},
+		{"ordering", `b"ab" < b"b"`, true},
+		{"hash key", `{b"k": 1}[b"k"]`, 1},
+		{"iterate", `str([x for x in b"AB"])`, "[65, 66]"},
+		{"from array", "bytes([104, 105])", bytesValue("hi")},
+		{"encode utf-8", `len(bytes("é"))`, 2},
+		{"encode latin-1", `bytes("é", "latin-1")`, bytesValue("\xe9")},
+		{"decode", `decode(b"hi\xc3\xa9")`, "hié"},
+		{"decode latin-1", `decode(b"\xe9", "latin-1")`, "é"},
+		{"read uint8", `read_uint(b"\xff", 0, 1)`, 255},
+		{"read int8", `read_int(b"\xff", 0, 1)`, -1},
+		{"read big endian", `read_uint(b"\x01\x02", 0, 2)`, 258},
+		{"read little endian", `read_uint(b"\x01\x02", 0, 2, "little")`, 513},
+		{"read int32", `read_int(b"\x00\xff\xff\xff\xfe", 1, 4)`, -2},
+		{"read uint64", `read_uint(b"\xff\xff\xff\xff\xff\xff\xff\xff", 0, 8)`, bigInt("18446744073709551615")},
+		{"invalid utf-8", `decode(b"\xff")`, &object.Error{Message: `bytes are not valid utf-8`}},
+		{"invalid ascii", `decode(b"\xe9", "ascii")`, &object.Error{Message: `bytes are not valid ascii`}},
+		{"unencodable", `bytes("é", "ascii")`, &object.Error{Message: `cannot encode 'é' as ascii`}},
+		{"unknown encoding", `bytes("a", "ebcdic")`, &object.Error{Message: `unknown encoding: ebcdic`}},
+		{"bad byte", `bytes([256])`, &object.Error{Message: `byte values must be INTEGER between 0 and 255, got 256`}},
+		{"short read", `read_int(b"\x00", 0, 2)`, &object.Error{Message: `cannot read 2 bytes at offset 0 from 1 bytes`}},
+		{"huge offset", `read_int(b"abcdefgh", 9223372036854775807, 8, "big")`, &object.Error{Message: `cannot read 8 bytes at offset 9223372036854775807 from 8 bytes`}},
+		{"offset near max", `read_uint(b"abcdefgh", 9223372036854775800, 8)`, &object.Error{Message: `cannot read 8 bytes at offset 9223372036854775800 from 8 bytes`}},
+		{"bad width", `read_int(b"\x00\x00\x00", 0, 3)`, &object.Error{Message: `unsupported integer width: 3`}},
+		{"bad order", `read_int(b"\x00", 0, 1, "middle")`, &object.Error{Message: `unknown byte order: middle`}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestStringCharacters(t *testing.T) {
+	tests := []vmTestCase{
+		{"index", `"héllo"[1]`, "é"},
+		{"index past end", `"abc"[3]`, Null},
+		{"negative index", `"abc"[-1]`, Null},
+		{"len", `len("héllo")`, 5},
+		{"slice", `"héllo"[1..3]`, "él"},
+		{"iterate", `str([c for c in "hé!"])`, "[h, é, !]"},
+		{"wide characters", `"日本"[1] == "本"`, true},
+		{"ord", `ord("é")`, 233},
+		{"chr", "chr(233)", "é"},
+		{"round trip", `chr(ord("a") + 1)`, "b"},
+		{"ord of several characters", `ord("ab")`, &object.Error{Message: "argument to `ord` must be a single character, got \"ab\""}},
+		{"ord of empty string", `ord("")`, &object.Error{Message: "argument to `ord` must be a single character, got \"\""}},
+		{"negative code point", `chr(-1)`, &object.Error{Message: `invalid code point: -1`}},
+		{"surrogate", `chr(55296)`, &object.Error{Message: `invalid code point: 55296`}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []vmTestCase{
+		{"split", "str(split(\"a,b,,c\", \",\"))", "[a, b, , c]"},
+		{"split on whitespace", "str(split(\"  a b\tc \"))", "[a, b, c]"},
+		{"join", "join([\"a\", \"b\"], \"-\")", "a-b"},
+		{"join empty", "join([])", ""},
+		{"trim", "trim(\"  hi \n\")", "hi"},
+		{"trim characters", "trim(\"xxhixx\", \"x\")", "hi"},
+		{"trim_left", "trim_left(\"  hi \")", "hi "},
+		{"trim_right", "trim_right(\"  hi \")", "  hi"},
+		{"replace", "replace(\"aaa\", \"a\", \"b\")", "bbb"},
+		{"replace count", "replace(\"aaa\", \"a\", \"b\", 2)", "bba"},
+		{"contains", "contains(\"hello\", \"ell\")", true},
+		{"starts_with", "starts_with(\"hello\", \"he\")", true},
+		{"ends_with", "ends_with(\"hello\", \"he\")", false},
+		{"index_of", "index_of(\"héllo\", \"l\")", 2},
+		{"index_of missing", "index_of(\"hello\", \"z\")", -1},
+		{"upper", "upper(\"héllo\")", "HÉLLO"},
+		{"lower", "lower(\"ABC\")", "abc"},
+		{"repeat", "repeat(\"ab\", 3)", "ababab"},
+		{"pad_left", "pad_left(\"7\", 3, \"0\")", "007"},
+		{"pad_right", "pad_right(\"é\", 3)", "é  "},
+		{"pad shorter width", "pad_left(\"long\", 2)", "long"},
+		{"split non-string", "split(1)", &object.Error{Message: "first argument to `split` must be a STRING, got INTEGER"}},
+		{"join non-string", "join([\"a\", 1])", &object.Error{Message: "`join` expects an ARRAY of STRING, found INTEGER"}},
+		{"replace arguments", "replace(\"a\", \"b\")", &object.Error{Message: "wrong number of arguments. expected=3 or 4, got=2"}},
+		{"negative repeat", "repeat(\"a\", -1)", &object.Error{Message: "count given to `repeat` must not be negative, got -1"}},
+		{"huge repeat", "repeat(\"ab\", 9999999999999)", &object.Error{Message: "result of `repeat` is longer than 67108864 bytes"}},
+		{"largest repeat", "len(repeat(\"ab\", 33554432))", 67108864},
+		{"repeat empty", "repeat(\"\", 9999999999999)", ""},
+		{"huge pad", "pad_left(\"a\", 9999999999999)", &object.Error{Message: "result of `pad_left` is longer than 67108864 bytes"}},
+		{"long padding", "pad_left(\"a\", 3, \"ab\")", &object.Error{Message: "padding given to `pad_left` must be a single character, got \"ab\""}},
+		{"contains non-string", "contains(\"a\", 1)", &object.Error{Message: "second argument to `contains` must be a STRING, got INTEGER"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []vmTestCase{
+		{"abs", "math.abs(-5)", 5},
+		{"abs float", "math.abs(-2.5)", 2.5},
+		{"abs min integer", "math.abs(-9223372036854775807 - 1)", bigInt("9223372036854775808")},
+		{"abs decimal", "math.abs(-1.50d)", decimal("1.50")},
+		{"floor", "math.floor(2.7)", 2},
+		{"floor negative", "math.floor(-2.5)", -3},
+		{"ceil", "math.ceil(2.1)", 3},
+		{"ceil decimal", "math.ceil(-1.5d)", -1},
+		{"round half up", "math.round(2.5)", 3},
+		{"round half away from zero", "math.round(-2.5)", -3},
+		{"round digits", "math.round(3.14159, 2)", 3.14},
+		{"round decimal digits", "math.round(1.005d, 2)", decimal("1.01")},
+		{"round negative digits", "math.round(1234.5, -2)", 1200.0},
+		{"round many digits", "math.round(1.255, 400)", 1.255},
+		{"round large float", "math.round(math.pow(10.0, 300), 20) == math.pow(10.0, 300)", true},
+		{"round to nothing", "math.round(1234.5, -400)", 0.0},
+		{"round digits truncated", "math.round(1.25d, 4294967297)", &object.Error{Message: "digits for `math.round` out of range: 4294967297"}},
+		{"round decimal too fine", "math.round(1d, 100000000)", &object.Error{Message: "digits for `math.round` out of range: 100000000"}},
+		{"sqrt", "math.sqrt(16)", 4.0},
+		{"pow", "math.pow(2, 10)", 1024},
+		{"pow big", "math.pow(2, 100)", bigInt("1267650600228229401496703205376")},
+		{"pow decimal", "math.pow(1.5d, 2)", decimal("2.25")},
+		{"pow fraction", "math.pow(4, 0.5)", 2.0},
+		{"pow negative", "math.pow(2, -1)", 0.5},
+		{"pow largest", "math.pow(2, 1048575) > 0", true},
+		{"pow too large", "math.pow(10, 100000000)", &object.Error{Message: "exponent given to `math.pow` is too large, got 100000000"}},
+		{"pow huge exponent", "math.pow(2, 100000000000000000000)", &object.Error{Message: "exponent given to `math.pow` is too large, got 100000000000000000000"}},
+		{"pow of one", "math.pow(-1, 100000000001)", -1},
+		{"pow decimal too large", "math.pow(1.5d, 100000000)", &object.Error{Message: "exponent given to `math.pow` is too large, got 100000000"}},
+		{"pow decimal too fine", "math.pow(0.01d, 4611686018427387904)", &object.Error{Message: "exponent given to `math.pow` is too large, got 4611686018427387904"}},
+		{"min", "math.min(3, 1, 2)", 1},
+		{"max mixed", "math.max(3, 1.5, 2)", 3},
+		{"max array", "math.max([1, 7, 3])", 7},
+		{"min strings", "math.min(\"b\", \"a\")", "a"},
+		{"sin", "math.sin(0)", 0.0},
+		{"cos", "math.cos(math.pi)", -1.0},
+		{"atan2", "math.atan2(1, 1) * 4 == math.pi", true},
+		{"exp", "math.exp(0)", 1.0},
+		{"log", "math.log(math.e)", 1.0},
+		{"log base", "math.log(100, 10)", 2.0},
+		{"pi", "math.pi > 3.14", true},
+		{"is_nan", "math.is_nan(math.sqrt(-1))", true},
+		{"is_inf", "math.is_inf(math.log(0))", true},
+		{"integer is not nan", "math.is_nan(1)", false},
+		{"sqrt of string", "math.sqrt(\"a\")", &object.Error{Message: "first argument to `math.sqrt` must be a number, got STRING"}},
+		{"floor of infinity", "math.floor(math.log(0))", &object.Error{Message: "cannot convert -Inf to INTEGER"}},
+		{"min of nothing", "math.min()", &object.Error{Message: "`math.min` needs at least one value"}},
+		{"max of mixed types", "math.max(1, \"a\")", &object.Error{Message: "cannot compare STRING with INTEGER"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []vmTestCase{
+		{"map", "map([1, 2, 3], fn(x) { x * 2 })", []int{2, 4, 6}},
+		{"map closure", "let k = 3; map([1, 2], fn(x) { x + k })", []int{4, 5}},
+		{"map builtin", "map([\"a\", \"bc\"], len)", []int{1, 2}},
+		{"map string", "join(map(\"ab\", upper))", "AB"},
+		{"map range", "map(1..4, fn(x) { x * x })", []int{1, 4, 9}},
+		{"map hash keys", "join(map({\"a\": 1, \"b\": 2}, fn(k) { k + k }), \",\")", "aa,bb"},
+		{"map inside function", "let f = fn(xs) { let k = 2; map(xs, fn(x) { x * k }) }; f([1, 2])", []int{2, 4}},
+		{"map recursive", "let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; map([3, 4], fact)", []int{6, 24}},
+		{"nested", "map([[1, 2], [3]], fn(a) { reduce(a, fn(s, x) { s + x }, 0) })", []int{3, 3}},
+		{"filter", "filter([1, 2, 3, 4], fn(x) { x > 2 })", []int{3, 4}},
+		{"filter none", "filter([1, 2], fn(x) { false })", []int{}},
+		{"reduce", "reduce([1, 2, 3, 4], fn(acc, x) { acc + x })", 10},
+		{"reduce initial", "reduce([\"a\", \"b\"], fn(acc, x) { acc + x }, \">\")", ">ab"},
+		{"reduce empty", "reduce([], fn(acc, x) { acc + x }, 5)", 5},
+		{"sort_by", "sort_by([3, 1, 2], fn(x) { -x })", []int{3, 2, 1}},
+		{"sort_by stable", "join(sort_by([\"bb\", \"a\", \"cc\", \"d\"], len))", "adbbcc"},
+		{"each", "each([1, 2], fn(x) { x })", Null},
+		{"result used later", "let a = map([1, 2], fn(x) { x + 1 }); a[1] * 10", 30},
+		{"map non-iterable", "map(1, fn(x) { x })", &object.Error{Message: "first argument to `map` must be iterable, got INTEGER"}},
+		{"filter non-function", "filter([1], 2)", &object.Error{Message: "second argument to `filter` must be a function, got INTEGER"}},
+		{"reduce empty without initial", "reduce([], fn(acc, x) { acc })", &object.Error{Message: "`reduce` of an empty collection needs an initial value"}},
+		{"sort_by mixed keys", "sort_by([1, \"a\"], fn(x) { x })", &object.Error{Message: "cannot compare STRING with INTEGER"}},
+		{"each wrong arity", "each([1])", &object.Error{Message: "wrong number of arguments. expected=2, got=1"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []vmTestCase{
+		{"keys", "join(keys({\"b\": 1, \"a\": 2}))", "ba"},
+		{"keys empty", "keys({})", []int{}},
+		{"values", "values({\"b\": 2, \"a\": 1})", []int{2, 1}},
+		{"entries", "str(entries({\"a\": 1, 2: 3}))", "[(a, 1), (2, 3)]"},
+		{"entries unpack", "let k, v = entries({\"a\": 1})[0]; k + str(v)", "a1"},
+		{"has key", "has({\"a\": 1}, \"a\")", true},
+		{"has missing key", "has({\"a\": 1}, \"b\")", false},
+		{"has array key", "has({[1, 2]: 1}, [1, 2])", true},
+		{"delete", "let h = {\"a\": 1, \"b\": 2}; str(delete(h, \"a\")) + str(h)", "{b: 2}{a: 1, b: 2}"},
+		{"delete missing key", "str(delete({\"a\": 1}, \"z\"))", "{a: 1}"},
+		{"merge", "str(merge({\"a\": 1, \"b\": 2}, {\"b\": 3, \"c\": 4}))", "{a: 1, b: 3, c: 4}"},
+		{"merge one", "str(merge({\"a\": 1}))", "{a: 1}"},
+		{"get", "get({\"a\": 1}, \"a\")", 1},
+		{"get default", "get({\"a\": 1}, \"b\", 0)", 0},
+		{"get missing", "get({}, \"b\")", Null},
+		{"from_entries", "str(from_entries([(\"a\", 1), [\"b\", 2], (\"a\", 3)]))", "{a: 3, b: 2}"},
+		{"from_entries round trip", "let h = {\"a\": 1, [2]: 3}; from_entries(entries(h)) == h", true},
+		{"keys of array", "keys([1])", &object.Error{Message: "first argument to `keys` must be a HASH, got ARRAY"}},
+		{"has of integer", "has(1, 2)", &object.Error{Message: "argument to `has` must be a SET or HASH, got INTEGER"}},
+		{"has unhashable", "has({}, fn() {})", &object.Error{Message: "unusable as hash key: CLOSURE"}},
+		{"get unhashable", "get({}, [fn() {}])", &object.Error{Message: "unusable as hash key: CLOSURE"}},
+		{"delete unhashable", "delete({}, fn() {})", &object.Error{Message: "unusable as hash key: CLOSURE"}},
+		{"merge non-hash", "merge({}, 1)", &object.Error{Message: "arguments to `merge` must be HASH, got INTEGER"}},
+		{"merge nothing", "merge()", &object.Error{Message: "`merge` needs at least one HASH"}},
+		{"from_entries unhashable", "from_entries([(fn() {}, 1)])", &object.Error{Message: "unusable as hash key: CLOSURE"}},
+		{"from_entries non-pair", "from_entries([1])", &object.Error{Message: "`from_entries` expects key and value pairs: cannot unpack INTEGER"}},
+		{"from_entries triple", "from_entries([(1, 2, 3)])", &object.Error{Message: "`from_entries` expects key and value pairs: wrong number of values to unpack. expected=2, got=3"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestJSON(t *testing.T) {
+	tests := []vmTestCase{
+		{"parse array", "json_parse(\"[1, 2, 3]\")", []int{1, 2, 3}},
+		{"parse object", "let j = fn(s) { replace(s, \"'\", chr(34)) }; str(json_parse(j(\"{'b': 1, 'a': [true, null, 2.5, 'x']}\")))", "{b: 1, a: [true, null, 2.5, x]}"},
+		{"parse nested", "let j = fn(s) { replace(s, \"'\", chr(34)) }; json_parse(j(\"{'a': {'b': [0, 7]}}\"))[\"a\"][\"b\"][1]", 7},
+		{"parse null is falsy", "if (json_parse(\"[null]\")[0]) { 1 } else { 2 }", 2},
+		{"parse big integer", "json_parse(\"12345678901234567890\")", bigInt("12345678901234567890")},
+		{"parse exponent", "json_parse(\"1e2\")", 100.0},
+		{"parse escapes", "let j = fn(s) { replace(s, \"'\", chr(34)) }; json_parse(j(\"'caf\\u00e9\\n'\"))", "café\n"},
+		{"parse duplicate key", "let j = fn(s) { replace(s, \"'\", chr(34)) }; json_parse(j(\"{'a': 1, 'a': 2}\"))[\"a\"]", 2},
+		{"stringify", "json_stringify({\"b\": [1, 2.5, 1.50d], \"a\": (true, false), \"c\": {}, \"d\": []})", "{\"b\":[1,2.5,1.50],\"a\":[true,false],\"c\":{},\"d\":[]}"},
+		{"stringify string", "json_stringify(\"<a&b>\")", "\"<a&b>\""},
+		{"stringify set", "json_stringify({1, 2})", "[1,2]"},
+		{"stringify indent", "json_stringify({\"a\": [1, 2]}, 2)", "{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"stringify indent string", "json_stringify([1], \"\t\")", "[\n\t1\n]"},
+		{"round trip", "let h = {\"a\": [1, {\"b\": \"c\"}], \"d\": 2.5}; json_parse(json_stringify(h)) == h", true},
+		{"parse non-string", "json_parse(1)", &object.Error{Message: "first argument to `json_parse` must be a STRING, got INTEGER"}},
+		{"parse empty", "json_parse(\"\")", &object.Error{Message: "invalid JSON: unexpected end of JSON input"}},
+		{"parse unterminated", "json_parse(\"[1, 2\")", &object.Error{Message: "invalid JSON: unexpected end of JSON input"}},
+		{"parse trailing data", "json_parse(\"[1] 2\")", &object.Error{Message: "invalid JSON: unexpected data after value"}},
+		{"parse invalid", "json_parse(\"{x}\")", &object.Error{Message: "invalid JSON: invalid character 'x' looking for beginning of value"}},
+		{"stringify builtin", "json_stringify([len])", &object.Error{Message: "cannot encode BUILTIN as JSON"}},
+		{"stringify integer key", "json_stringify({1: 2})", &object.Error{Message: "JSON object keys must be STRING, got INTEGER"}},
+		{"stringify infinity", "json_stringify(math.log(0))", &object.Error{Message: "cannot encode -Inf as JSON"}},
+		{"stringify bad indent string", "json_stringify([1], \"xx\")", &object.Error{Message: "indent for `json_stringify` must contain only spaces and tabs, got \"xx\""}},
+		{"stringify bad indent", "json_stringify(1, -1)", &object.Error{Message: "indent for `json_stringify` must be between 0 and 16, got -1"}},
+		{"stringify closure", "json_stringify(fn(x) { x })", &object.Error{Message: "cannot encode CLOSURE as JSON"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestFiles(t *testing.T) {
+	tests := []vmTestCase{
+		{"read", "read_file(\"notes.txt\")", "hello"},
+		{"read through subdirectory", "read_file(\"./sub/../notes.txt\")", "hello"},
+		{"write", "write_file(\"new.txt\", \"hi\"); read_file(\"new.txt\")", "hi"},
+		{"write returns null", "write_file(\"new.txt\", \"\")", Null},
+		{"overwrite with bytes", "write_file(\"notes.txt\", b\"\\x00bye\"); len(read_file(\"notes.txt\"))", 4},
+		{"append", "append_file(\"notes.txt\", \" world\"); read_file(\"notes.txt\")", "hello world"},
+		{"append creates", "append_file(\"log.txt\", \"a\"); append_file(\"log.txt\", \"b\"); read_file(\"log.txt\")", "ab"},
+		{"list root", "join(list_dir(), \",\")", "notes.txt,sub"},
+		{"list subdirectory", "join(list_dir(\"sub\"), \",\")", "a.txt"},
+		{"exists", "exists(\"sub/a.txt\")", true},
+		{"exists missing", "exists(\"missing.txt\")", false},
+		{"remove_file", "remove_file(\"notes.txt\"); exists(\"notes.txt\")", false},
+		{"remove from set", "has(remove({1, 2}, 1), 1)", false},
+		{"read parent", "read_file(\"../secret\")", &object.Error{Message: "path \"../secret\" is outside the root directory"}},
+		{"read absolute", "read_file(\"/etc/passwd\")", &object.Error{Message: "path \"/etc/passwd\" is outside the root directory"}},
+		{"exists escaping", "exists(\"sub/../../x\")", &object.Error{Message: "path \"sub/../../x\" is outside the root directory"}},
+		{"remove_file parent", "remove_file(\"..\")", &object.Error{Message: "path \"..\" is outside the root directory"}},
+		{"read missing", "read_file(\"missing.txt\")", &object.Error{Message: "missing.txt: no such file or directory"}},
+		{"read non-string", "read_file(1)", &object.Error{Message: "first argument to `read_file` must be a STRING, got INTEGER"}},
+		{"write integer", "write_file(\"a.txt\", 1)", &object.Error{Message: "second argument to `write_file` must be a STRING or BYTES, got INTEGER"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := compiler.New()
+			err := comp.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			vm.SetFS(testFS(t))
+			err = vm.Run()
+			if err != nil {
+				t.Fatalf("vm error: %s", err)
+			}
+
+			testExpectedObject(t, tt.expected, vm.LastPoppedStackElem())
+		})
+	}
+}
+
+// testFS returns a file system holding notes.txt and sub/a.txt in a fresh directory.
+func testFS(t *testing.T) object.FS {
+	t.Helper()
+
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello"), 0644)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "sub", "a.txt"), nil, 0644)
+	}
+	if err != nil {
+		t.Fatalf("could not create test files: %s", err)
+	}
+
+	fsys, err := object.DirFS(dir)
+	if err != nil {
+		t.Fatalf("could not open test directory: %s", err)
+	}
+	return fsys
+}
+
+func TestRegex(t *testing.T) {
+	tests := []vmTestCase{
+		{"match", "regex(\"a+\").match(\"baaad\")[0]", "aaa"},
+		{"match groups", "regex(\"(\\w+)@(\\w+)\").match(\"mail bob@example now\")[2]", "example"},
+		{"match unused group", "regex(\"a(b)?\").match(\"a\")[1]", Null},
+		{"no match", "regex(\"x\").match(\"abc\")", Null},
+		{"match as value", "let m = regex(\"b\").match; m(\"abc\")[0]", "b"},
+		{"find_all", "join(regex(\"\\d+\").find_all(\"a1b22c333\"), \",\")", "1,22,333"},
+		{"find_all limit", "join(regex(\"\\d+\").find_all(\"a1b22c333\", 2), \",\")", "1,22"},
+		{"find_all none", "regex(\"\\d\").find_all(\"abc\")", []int{}},
+		{"find_all characters", "len(regex(\".\").find_all(\"héllo\"))", 5},
+		{"replace_all", "regex(\"(\\w)(\\w*)\").replace_all(\"hello world\", \"$2$1\")", "elloh orldw"},
+		{"replace_all named", "regex(\"(?P<y>\\d{4})-(?P<m>\\d\\d)\").replace_all(\"on 2024-05\", \"${m}/${y}\")", "on 05/2024"},
+		{"split", "join(regex(\"\\s*,\\s*\").split(\"a , b,c\"), \"|\")", "a|b|c"},
+		{"split limit", "join(regex(\",\").split(\"a,b,c\", 2), \"|\")", "a|b,c"},
+		{"pattern", "regex(\"a.b\").pattern", "a.b"},
+		{"inspect", "str(regex(\"a+\"))", "regex(a+)"},
+		{"invalid pattern", "regex(\"(a\")", &object.Error{Message: "invalid pattern for `regex`: error parsing regexp: missing closing ): `(a`"}},
+		{"pattern not a string", "regex(1)", &object.Error{Message: "first argument to `regex` must be a STRING, got INTEGER"}},
+		{"match non-string", "regex(\"a\").match(1)", &object.Error{Message: "first argument to `match` must be a STRING, got INTEGER"}},
+		{"negative limit", "regex(\"a\").find_all(\"a\", -1)", &object.Error{Message: "second argument to `find_all` must not be negative, got -1"}},
+		{"limit not an integer", "regex(\"a\").split(\"a\", \"b\")", &object.Error{Message: "second argument to `split` must be an INTEGER, got STRING"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestTime(t *testing.T) {
+	tests := []vmTestCase{
+		{"now", "str(now())", "2024-03-15T12:30:45Z"},
+		{"unix", "unix()", 1710505845},
+		{"unix of time", "unix(parse_time(\"1970-01-02T00:00:00Z\"))", 86400},
+		{"sleep advances the clock", "let start = now(); sleep(1500); (now() - start).ms", 1500},
+		{"sleep duration", "sleep(duration(\"2m\")); now().minute", 32},
+		{"format_time", "format_time(now(), \"2006-01-02 15:04\")", "2024-03-15 12:30"},
+		{"format_time default", "format_time(now())", "2024-03-15T12:30:45Z"},
+		{"parse_time layout", "let t = parse_time(\"2024-01-02\", \"2006-01-02\"); t.year * 10000 + t.month * 100 + t.day", 20240102},
+		{"parse_time offset", "parse_time(\"2024-03-15T14:30:45+02:00\") == now()", true},
+		{"weekday", "now().weekday", "Friday"},
+		{"time plus duration", "str(now() + duration(\"36h\"))", "2024-03-17T00:30:45Z"},
+		{"duration plus time", "str(duration(\"1h\") + now())", "2024-03-15T13:30:45Z"},
+		{"time minus duration", "str(now() - duration(1000))", "2024-03-15T12:30:44Z"},
+		{"add durations", "str(duration(\"1h\") + duration(90000))", "1h1m30s"},
+		{"scale duration", "str(duration(1000) * 3)", "3s"},
+		{"scale duration left", "str(2 * duration(500))", "1s"},
+		{"divide duration", "str(duration(\"1m\") / 4)", "15s"},
+		{"duration ratio", "duration(\"1m\") / duration(\"15s\")", 4.0},
+		{"duration seconds", "duration(1500).seconds", 1.5},
+		{"compare times", "now() - duration(\"1s\") < now()", true},
+		{"compare durations", "duration(1) > duration(2)", false},
+		{"duration hash key", "{duration(1000): \"a\"}[duration(\"1s\")]", "a"},
+		{"parse_time invalid", "parse_time(\"nope\")", &object.Error{Message: "invalid time for `parse_time`: parsing time \"nope\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"nope\" as \"2006\""}},
+		{"duration invalid", "duration(\"x\")", &object.Error{Message: "invalid duration for `duration`: time: invalid duration \"x\""}},
+		{"sleep negative", "sleep(-1)", &object.Error{Message: "cannot sleep for a negative duration, got -1ms"}},
+		{"format_time non-time", "format_time(1)", &object.Error{Message: "first argument to `format_time` must be a TIME, got INTEGER"}},
+		{"largest duration", "duration(9223372036854).ms", 9223372036854},
+		{"smallest duration", "duration(-9223372036854).ms", -9223372036854},
+		{"duration too long", "duration(9223372036855)", &object.Error{Message: "duration of 9223372036855ms given to `duration` is out of range"}},
+		{"duration too negative", "duration(-9223372036855)", &object.Error{Message: "duration of -9223372036855ms given to `duration` is out of range"}},
+		{"sleep too long", "sleep(9223372036855)", &object.Error{Message: "duration of 9223372036855ms given to `sleep` is out of range"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := compiler.New()
+			err := comp.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			vm.SetClock(&testClock{now: time.Date(2024, 3, 15, 12, 30, 45, 0, time.UTC)})
+			err = vm.Run()
+			if err != nil {
+				t.Fatalf("vm error: %s", err)
+			}
+
+			testExpectedObject(t, tt.expected, vm.LastPoppedStackElem())
+		})
+	}
+}
+
+// testClock stands still until slept on.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time        { return c.now }
+func (c *testClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestFormat(t *testing.T) {
+	tests := []vmTestCase{
+		{"plain", "format(\"no directives\")", "no directives"},
+		{"percent", "format(\"100%%\")", "100%"},
+		{"integer", "format(\"%d items\", 3)", "3 items"},
+		{"integer width", "format(\"[%5d|%-5d|%05d]\", 42, 42, -42)", "[   42|42   |-0042]"},
+		{"integer sign", "format(\"%+d % d\", 5, 5)", "+5  5"},
+		{"big integer", "format(\"%d\", 100000000000000000000)", "100000000000000000000"},
+		{"float", "format(\"%f\", 3.0)", "3.000000"},
+		{"float precision", "format(\"%.2f\", 3.14159)", "3.14"},
+		{"float width", "format(\"%8.3f|%-8.1f|\", 2.5, 2.5)", "   2.500|2.5     |"},
+		{"integer as float", "format(\"%.2f\", 3)", "3.00"},
+		{"decimal", "format(\"%f\", 1.50d)", "1.50"},
+		{"decimal precision", "format(\"%.1f|%.3f\", 2.25d, 2.25d)", "2.2|2.250"},
+		{"decimal padding", "format(\"%08.2f|%+.1f|%7.2f\", -1.5d, 1.5d, 12.345d)", "-0001.50|+1.5|  12.34"},
+		{"string", "format(\"%s and %s\", \"this\", \"that\")", "this and that"},
+		{"string width", "format(\"[%-6s|%6s]\", \"ab\", \"héllo\")", "[ab    | héllo]"},
+		{"string precision", "format(\"%.3s\", \"héllo\")", "hél"},
+		{"bytes as string", "format(\"%s\", b\"raw\")", "raw"},
+		{"quoted", "format(\"%q\", \"tab\there\")", "\"tab\\there\""},
+		{"hex integer", "format(\"%x|%#x|%04x\", 255, 255, 10)", "ff|0xff|000a"},
+		{"hex string", "format(\"%x\", \"hi\")", "6869"},
+		{"hex bytes", "format(\"%x\", b\"\\x01\\xff\")", "01ff"},
+		{"values", "format(\"%v|%v|%v\", 3.0, [1, \"a\"], (1,))", "3.0|[1, a]|(1,)"},
+		{"value width", "format(\"%-4v|\", 1)", "1   |"},
+		{"no arguments", "format()", &object.Error{Message: "wrong number of arguments. expected at least 1, got=0"}},
+		{"format not a string", "format(1)", &object.Error{Message: "first argument to `format` must be a STRING, got INTEGER"}},
+		{"integer verb with float", "format(\"%5d\", 1.5)", &object.Error{Message: "%5d in `format` needs an INTEGER, got FLOAT"}},
+		{"float verb with string", "format(\"%f\", \"1\")", &object.Error{Message: "%f in `format` needs a number, got STRING"}},
+		{"string verb with integer", "format(\"%s\", 1)", &object.Error{Message: "%s in `format` needs a STRING or BYTES, got INTEGER"}},
+		{"quote verb with bytes", "format(\"%q\", b\"a\")", &object.Error{Message: "%q in `format` needs a STRING, got BYTES"}},
+		{"hex verb with float", "format(\"%x\", 1.5)", &object.Error{Message: "%x in `format` needs an INTEGER, STRING or BYTES, got FLOAT"}},
+		{"unknown verb", "format(\"%y\", 1)", &object.Error{Message: "unknown verb %y in `format`"}},
+		{"incomplete directive", "format(\"50%\")", &object.Error{Message: "`format` ends with an incomplete directive %"}},
+		{"missing argument", "format(\"%d and %d\", 1)", &object.Error{Message: "`format` is missing an argument for %d"}},
+		{"extra argument", "format(\"%d\", 1, 2)", &object.Error{Message: "`format` has 2 arguments but only uses 1"}},
+		{"huge width", "format(\"%5000d\", 1)", &object.Error{Message: "width in `format` is larger than 1000"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestOrderingErrors(t *testing.T) {
+	tests := []vmTestCase{
+		{"mismatched elements", `[1] > ["a"]`, "cannot compare INTEGER with STRING"},
+		{"unordered elements", "[true] > [false]", "cannot compare BOOLEAN with BOOLEAN"},
+		{"less than keeps operand order", `[1, "a"] < [1, 2]`, "cannot compare STRING with INTEGER"},
+		{"less equal keeps operand order", `["a"] <= [1]`, "cannot compare STRING with INTEGER"},
+		{"mismatched less than", `"a" < 1`, "type mismatch: STRING < INTEGER"},
+		{"mismatched greater equal", `1 >= "a"`, "type mismatch: INTEGER >= STRING"},
+		{"unordered less equal", "true <= false", "unknown operator: BOOLEAN <= BOOLEAN"},
+		{"unhashable element", "{[1, fn() {}]: 1}", "unusable as hash key: CLOSURE"},
+		{"unhashable set element", "{1, fn() {}}", "unusable as hash key: CLOSURE"},
+		{"unpack too many", "let a, b = (1, 2, 3);", "wrong number of values to unpack. expected=2, got=3"},
+		{"unpack integer", "let a, b = 1;", "cannot unpack INTEGER"},
+		{"missing module member", "math.tau", "module math has no member tau"},
+		{"missing regex member", `regex("a").test`, "regex has no member test"},
+		{"add times", "now() + now()", "unknown operator: TIME + TIME"},
+		{"divide duration by zero", "duration(1) / 0", "division by zero"},
+		{"missing time member", "now().century", "time has no member century"},
+		{"add durations overflow", "duration(9223372036854) + duration(9223372036854)", "duration out of range"},
+		{"subtract durations overflow", "duration(-9223372036854) - duration(9223372036854)", "duration out of range"},
+		{"subtract distant times", "now() - parse_time(\"0001-01-01T00:00:00Z\")", "duration out of range"},
+		{"subtract smallest duration", "now() - duration(\"-2562047h47m16.854775808s\")", "duration out of range"},
+		{"callback arity", "map([1], fn(a, b) { a })", "wrong number of arguments: expected=2, got=1"},
+		{"callback error", `map([1], fn(x) { x + "a" })`, "unsupported types for binary operation: INTEGER STRING"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := compiler.New()
+			err := comp.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			err = vm.Run()
+			if err == nil {
+				t.Fatalf("expected VM error but had none.")
+			}
+
+			if err.Error() != tt.expected {
+				t.Fatalf("wrong VM error. expected=%q, got=%q", tt.expected, err)
+			}
+		})
+	}
+}
+
 func TestConditionals(t *testing.T) {
 	tests := []vmTestCase{
-		{"if true ten", "if (true) { 10 }", 10.0},
-		{"if true ten else twenty", "if (true) { 10 } else { 20 }", 10.0},
-		{"if false ten else twenty", "if (false) { 10 } else { 20 }", 20.0},
-		{"if one ten", "if (1) { 10 }", 10.0},
-		{"if 1 lt 2 ten", "if (1 < 2) { 10 }", 10.0},
-		{"if 1 lteq 2 ten else twenty", "if (1 <= 2) { 10 } else { 20 }", 10.0},
-		{"if 1 gteq 2 then else twenty", "if (1 >= 2) { 10 } else { 20 }", 20.0},
+		{"if true ten", "if (true) { 10 }", 10},
+		{"if true ten else twenty", "if (true) { 10 } else { 20 }", 10},
+		{"if false ten else twenty", "if (false) { 10 } else { 20 }", 20},
+		{"if one ten", "if (1) { 10 }", 10},
+		{"if 1 lt 2 ten", "if (1 < 2) { 10 }", 10},
+		{"if 1 lteq 2 ten else twenty", "if (1 <= 2) { 10 } else { 20 }", 10},
+		{"if 1 gteq 2 then else twenty", "if (1 >= 2) { 10 } else { 20 }", 20},
 		{"if 1 gteq 2 ten", "if (1 >= 2) { 10; }", Null},
 		{"if false ten", "if (false) { 10; }", Null},
-		{"if null", "if ((if (false) { 10; })) { 10; } else { 20; }", 20.0},
+		{"if null", "if ((if (false) { 10; })) { 10; } else { 20; }", 20},
 	}
 
 	runVmTests(t, tests)
@@ -98,9 +740,9 @@ func TestConditionals(t *testing.T) {
 
 func TestGlobalLetStatements(t *testing.T) {
 	tests := []vmTestCase{
-		{"let one", "let one = 1; one", 1.0},
-		{"let one and two", "let one = 1; let two = 2; one + two", 3.0},
-		{"let one and one", "let one = 1; let two = one + one; one + two", 3.0},
+		{"let one", "let one = 1; one", 1},
+		{"let one and two", "let one = 1; let two = 2; one + two", 3},
+		{"let one and one", "let one = 1; let two = one + one; one + two", 3},
 	}
 
 	runVmTests(t, tests)
@@ -118,9 +760,9 @@ func TestStringExpressions(t *testing.T) {
 
 func TestArrayLiterals(t *testing.T) {
 	tests := []vmTestCase{
-		{"empty array", "[]", []float64{}},
-		{"simple array", "[1, 2, 3]", []float64{1, 2, 3}},
-		{"simple expression array", "[1 + 2, 3 * 4, 5 + 6]", []float64{3, 12, 11}},
+		{"empty array", "[]", []int{}},
+		{"simple array", "[1, 2, 3]", []int{1, 2, 3}},
+		{"simple expression array", "[1 + 2, 3 * 4, 5 + 6]", []int{3, 12, 11}},
 	}
 
 	runVmTests(t, tests)
@@ -128,17 +770,28 @@ func TestArrayLiterals(t *testing.T) {
 
 func TestHashLiterals(t *testing.T) {
 	tests := []vmTestCase{
-		{"empty hash", "{}", map[object.HashKey]float64{}},
+		{"empty hash", "{}", map[object.HashKey]int64{}},
 		{"simple hash", "{1: 2, 2: 3}",
-			map[object.HashKey]float64{
-				(&object.Number{Value: 1}).HashKey(): 2,
-				(&object.Number{Value: 2}).HashKey(): 3,
+			map[object.HashKey]int64{
+				(&object.Integer{Value: 1}).HashKey(): 2,
+				(&object.Integer{Value: 2}).HashKey(): 3,
 			},
 		},
 		{"complex hash", "{1 + 1: 2 * 2, 3 + 3: 4 * 4}",
-			map[object.HashKey]float64{
-				(&object.Number{Value: 2}).HashKey(): 4,
-				(&object.Number{Value: 6}).HashKey(): 16,
+			map[object.HashKey]int64{
+				(&object.Integer{Value: 2}).HashKey(): 4,
+				(&object.Integer{Value: 6}).HashKey(): 16,
+			},
+		},
+		{"float keys", "{1: 2, 1.5: 3}",
+			map[object.HashKey]int64{
+				(&object.Integer{Value: 1}).HashKey(): 2,
+				(&object.Float{Value: 1.5}).HashKey(): 3,
+			},
+		},
+		{"whole float key", "{1: 2, 1.0: 3}",
+			map[object.HashKey]int64{
+				(&object.Integer{Value: 1}).HashKey(): 3,
 			},
 		},
 	}
@@ -146,16 +799,27 @@ func TestHashLiterals(t *testing.T) {
 	runVmTests(t, tests)
 }
 
+func TestHashOrder(t *testing.T) {
+	tests := []vmTestCase{
+		{"literal order", `str({"b": 1, "a": 2, 3: 4})`, "{b: 1, a: 2, 3: 4}"},
+		{"repeated key keeps position", `str({"a": 1, "b": 2, "a": 3})`, "{a: 3, b: 2}"},
+		{"iteration order", `str([k for k in {"z": 1, "y": 2, "x": 3}])`, "[z, y, x]"},
+		{"comprehension order", "str({x: x * x for x in [3, 1, 2]})", "{3: 9, 1: 1, 2: 4}"},
+	}
+
+	runVmTests(t, tests)
+}
+
 func TestIndexExpressions(t *testing.T) {
 	tests := []vmTestCase{
-		{"array index", "[1, 2, 3][1]", 2.0},
-		{"array expression index", "[1, 2, 3][0 + 2]", 3.0},
-		{"array of array", "[[1, 2, 3]][0][0]", 1.0},
+		{"array index", "[1, 2, 3][1]", 2},
+		{"array expression index", "[1, 2, 3][0 + 2]", 3},
+		{"array of array", "[[1, 2, 3]][0][0]", 1},
 		{"empty array first element", "[][0]", Null},
 		{"array index out of bounds", "[1, 2, 3][99]", Null},
 		{"array negative index", "[1][-1]", Null},
-		{"hash index", "{1: 1, 2: 2}[1]", 1.0},
-		{"hash index 2", "{1: 1, 2: 2}[2]", 2.0},
+		{"hash index", "{1: 1, 2: 2}[1]", 1},
+		{"hash index 2", "{1: 1, 2: 2}[2]", 2},
 		{"hash absent index", "{1:1}[0]", Null},
 		{"empty hash index", "{}[0]", Null},
 	}
@@ -163,20 +827,322 @@ func TestIndexExpressions(t *testing.T) {
 	runVmTests(t, tests)
 }
 
+func TestRanges(t *testing.T) {
+	tests := []vmTestCase{
+		{"len exclusive", "len(1..10)", 9},
+		{"len inclusive", "len(1..=10)", 10},
+		{"len step", "len(0..10 step 3)", 4},
+		{"len descending", "len(10..=0 step -5)", 3},
+		{"len empty", "len(10..0)", 0},
+		{"index", "(1..10)[3]", 4},
+		{"index step", "(0..100 step 10)[5]", 50},
+		{"index out of bounds", "(1..10)[9]", Null},
+		{"array slice", "[1, 2, 3, 4, 5][1..3]", []int{2, 3}},
+		{"array slice clamped", "[1, 2, 3][1..=10]", []int{2, 3}},
+		{"array slice step", "[1, 2, 3, 4, 5][0..5 step 2]", []int{1, 3, 5}},
+		{"range slice", "len((0..100 step 2)[10..20])", 10},
+		{"range slice index", "(0..100 step 2)[10..20][0]", 20},
+		{"in range", "5 in 1..10", true},
+		{"not in range", "10 in 1..10", false},
+		{"in inclusive range", "10 in 1..=10", true},
+		{"in stepped range", "4 in 0..10 step 3", false},
+		{"in array", "2 in [1, 2, 3]", true},
+		{"string in array", `"b" in ["a", "b"]`, true},
+		{"in string", `"ell" in "hello"`, true},
+		{"in hash", `"a" in {"a": 1}`, true},
+		{"first", "first(5..10)", 5},
+		{"last", "last(5..=10)", 10},
+		{"rest", "first(rest(5..10))", 6},
+		{"rest empty", "rest(1..1)", Null},
+		{"len too long", "len(-9223372036854775807..9223372036854775807)",
+			&object.Error{Message: "range -9223372036854775807..9223372036854775807 is too long"}},
+		{"len inclusive too long", "len(0..=9223372036854775807)",
+			&object.Error{Message: "range 0..=9223372036854775807 is too long"}},
+		{"len largest", "len(0..9223372036854775807)", 9223372036854775807},
+		{"in extreme range", "9223372036854775807 in (-9223372036854775807 - 1)..=9223372036854775807", true},
+		{"not in extreme range", "-9223372036854775807 - 1 in -9223372036854775807..9223372036854775807", false},
+		{"index extreme range", "((-9223372036854775807 - 1)..=9223372036854775807)[9223372036854775807]", -1},
+		{"last extreme range", "last((-9223372036854775807 - 1)..=9223372036854775807)", 9223372036854775807},
+		{"rest at max", "len(rest(9223372036854775807..=9223372036854775807))", 0},
+		{"slice extreme range", "last((0..=9223372036854775807)[9223372036854775805..9223372036854775807])", 9223372036854775806},
+		{"slice ending at max", "last((0..=9223372036854775807 step 9223372036854775807)[0..2])", 9223372036854775807},
+		{
+			name: "recursive sum",
+			input: `let sum = fn(r) { if (len(r) == 0) { 0 } else { first(r) + sum(rest(r)) } };
+					sum(1..=100);`,
+			expected: 5050,
+		},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRangeErrors(t *testing.T) {
+	tests := []vmTestCase{
+		{"float bound", "1..2.5", "range bounds must be INTEGER, got FLOAT"},
+		{"zero step", "1..2 step 0", "range step cannot be zero"},
+		{"string bound", `1.."a"`, "range bounds must be INTEGER, got STRING"},
+		{"negative slice step", "[1, 2][2..0 step -1]", "slice step must be positive, got -1"},
+		{"bad membership", "1 in 2", "unknown operator: INTEGER in INTEGER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := compiler.New()
+			err := comp.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			err = vm.Run()
+			if err == nil {
+				t.Fatalf("expected VM error but had none.")
+			}
+
+			if err.Error() != tt.expected {
+				t.Fatalf("wrong VM error. expected=%q, got=%q", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []vmTestCase{
+		{"map", "[x * 2 for x in [1, 2, 3]]", []int{2, 4, 6}},
+		{"filter", "[x for x in [-1, 2, -3, 4] if x > 0]", []int{2, 4}},
+		{"range", "[x * x for x in 1..=4]", []int{1, 4, 9, 16}},
+		{"index and element", "[i * x for i, x in [5, 5, 5]]", []int{0, 5, 10}},
+		{"empty", "[x for x in []]", []int{}},
+		{"nested", "len([[y for y in 0..x] for x in 0..5][4])", 4},
+		{"hash keys", `len([k for k in {"a": 1, "b": 2}])`, 2},
+		{"hash comprehension", `{k: v * 10 for k, v in {"a": 1}}["a"]`, 10},
+		{"hash from range", "{x: x * x for x in 1..4}[3]", 9},
+		{"shadowed global", "let x = 5; [x for x in [1, 2]]; x", 5},
+		{"uses global", "let n = 3; [x + n for x in [1]]", []int{4}},
+		{
+			name: "inside function",
+			input: `let scale = fn(xs, n) { [x * n for x in xs if x != n] };
+					scale([1, 2, 3], 2);`,
+			expected: []int{2, 6},
+		},
+		{
+			name:     "closures capture each value",
+			input:    "let fs = [fn() { x } for x in 1..3]; fs[0]() + fs[1]();",
+			expected: 3,
+		},
+		{"return in function", "[fn() { return x * 2 }() for x in [1, 2]]", []int{2, 4}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestStructs(t *testing.T) {
+	tests := []vmTestCase{
+		{"field access", "struct Point { x, y }; let p = Point(1, 2); p.x + p.y", 3},
+		{"nested access", "struct Box { v }; Box(Box(5)).v.v", 5},
+		{"equal", "struct P { x, y }; P(1, 2) == P(1, 2)", true},
+		{"not equal", "struct P { x, y }; P(1, 2) != P(1, 3)", true},
+		{"different types", "struct A { x }; struct B { x }; A(1) == B(1)", false},
+		{"local struct", "let f = fn(a) { struct P { v }; P(a).v * 2 }; f(4)", 8},
+		{"field in comprehension", "struct P { x }; [p.x for p in [P(1), P(2)]]", []int{1, 2}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []vmTestCase{
+		{"unknown field", "struct Point { x, y }; Point(1, 2).z", "struct Point has no field z"},
+		{"wrong arity", "struct Point { x, y }; Point(1)", "wrong number of arguments: expected=2, got=1"},
+		{"field on number", "5.x", "field access not supported: INTEGER.x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := compiler.New()
+			err := comp.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			err = vm.Run()
+			if err == nil {
+				t.Fatalf("expected VM error but had none.")
+			}
+
+			if err.Error() != tt.expected {
+				t.Fatalf("wrong VM error. expected=%q, got=%q", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestOptionalChaining(t *testing.T) {
+	tests := []vmTestCase{
+		{"field on null", "let n = if (false) { 1 }; n?.x", Null},
+		{"skips rest of chain", "let n = if (false) { 1 }; n?.x.y[0]", Null},
+		{"skips call", "let n = if (false) { 1 }; n?.f(1)", Null},
+		{"index on null", "let n = if (false) { 1 }; n?[0]", Null},
+		{"field on value", "struct P { x }; P(3)?.x", 3},
+		{"index on value", "[1, 2]?[1]", 2},
+		{"missing index", "{\"a\": 1}?[\"b\"]?.x", Null},
+		{"coalesce null", "let n = if (false) { 1 }; n ?? 5", 5},
+		{"coalesce value", "3 ?? 5", 3},
+		{"coalesce false", "false ?? true", false},
+		{"coalesce chain", "let n = if (false) { 1 }; n?.x ?? n ?? 7", 7},
+		{"coalesce in expression", "let n = if (false) { 1 }; (n ?? 2) * 3", 6},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestEnumsAndMatch(t *testing.T) {
+	shapes := `enum Shape { Circle(r), Rect(w, h), Empty };
+		let area = fn(s) {
+			match (s) {
+				Circle(r) => 3 * r * r,
+				Rect(w, h) => w * h,
+				Empty => 0,
+			}
+		};`
+
+	tests := []vmTestCase{
+		{"circle", shapes + "area(Circle(2))", 12},
+		{"rect", shapes + "area(Rect(2, 5))", 10},
+		{"unit", shapes + "area(Empty)", 0},
+		{"qualified constructor", shapes + "area(Shape.Rect(1, 2))", 2},
+		{"field access", shapes + "Rect(3, 4).h", 4},
+		{"equal", shapes + "Rect(1, 2) == Rect(1, 2)", true},
+		{"not equal fields", shapes + "Rect(1, 2) == Rect(2, 1)", false},
+		{"not equal variants", shapes + "Circle(1) != Empty", true},
+		{"unit equal", shapes + "Empty == Shape.Empty", true},
+		{"no arm matches", shapes + "match (Empty) { Circle(r) => r }", Null},
+		{"wildcard", "match (5) { 1 => 10, _ => 20 }", 20},
+		{"value arm", `match ("b") { "a" => 1, "b" => 2 }`, 2},
+		{"ignored binding", shapes + "match (Rect(1, 2)) { Rect(_, h) => h }", 2},
+		{"block arm", shapes + "match (Circle(3)) { Circle(r) => { let d = r * 2; d } }", 6},
+		{"empty block arm", "match (1) { _ => {} }", Null},
+		{"return from arm", shapes + "let f = fn(s) { match (s) { Empty => { return 1; } }; 2 }; f(Empty) + f(Circle(1))", 3},
+		{"binding does not overwrite", shapes + "let r = 10; match (Circle(1)) { Circle(r) => r }; r", 10},
+		{"binding shadows in arm", shapes + "let r = 10; match (Circle(1)) { Circle(r) => r }", 1},
+		{"binding hidden from closures", "enum E { A(x) }; let x = 100; let g = fn() { x }; match (A(1)) { A(x) => g() }", 100},
+		{"local binding does not overwrite", shapes + "let f = fn() { let r = 10; match (Circle(1)) { Circle(r) => r }; r }; f()", 10},
+		{"closure captures binding", shapes + "let f = match (Circle(7)) { Circle(r) => fn() { r } }; f()", 7},
+		{"arm let does not leak", "let d = 1; match (1) { _ => { let d = 2; d } }; d", 1},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestMatchScopeAgreesWithEvaluator(t *testing.T) {
+	tests := []string{
+		"enum E { A(x) }; let x = 100; let g = fn() { x }; match (A(1)) { A(x) => g() }",
+		"enum E { A(x) }; let x = 100; match (A(1)) { A(x) => x }; x",
+		"enum E { A(x) }; let f = fn(x) { match (A(1)) { A(x) => x } + x }; f(10)",
+		"enum E { A(x), B(y) }; let y = 5; [match (v) { A(y) => y, B(x) => y } for v in [A(1), B(2)]]",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			comp := compiler.New()
+			if err := comp.Compile(parse(input)); err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			if err := vm.Run(); err != nil {
+				t.Fatalf("vm error: %s", err)
+			}
+
+			got := vm.LastPoppedStackElem().Inspect()
+			want := evaluator.Eval(parse(input), object.NewEnvironment()).Inspect()
+			if got != want {
+				t.Errorf("engines disagree. vm=%s, evaluator=%s", got, want)
+			}
+		})
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []vmTestCase{
+		{"not a variant", "let f = fn(x) { x }; match (1) { f(a) => a }", "not an enum variant: CLOSURE"},
+		{"wrong bindings", "enum E { A(x) }; match (A(1)) { A(x, y) => x }", "wrong number of bindings for A: expected=1, got=2"},
+		{"constructor arity", "enum E { A(x) }; A(1, 2)", "wrong number of arguments: expected=1, got=2"},
+		{"unknown variant", "enum E { A(x) }; E.B", "enum E has no variant B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := compiler.New()
+			err := comp.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			err = vm.Run()
+			if err == nil {
+				t.Fatalf("expected VM error but had none.")
+			}
+
+			if err.Error() != tt.expected {
+				t.Fatalf("wrong VM error. expected=%q, got=%q", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestComparisonErrorsAgreeWithEvaluator(t *testing.T) {
+	tests := []string{
+		`[1, "a"] < [1, 2]`,
+		`[1, "a"] <= [1, 2]`,
+		`[1, "a"] > [1, 2]`,
+		`"a" < 1`,
+		`1 <= "a"`,
+		`true < false`,
+		`(1, "a") >= (1, 2)`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			comp := compiler.New()
+			if err := comp.Compile(parse(input)); err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			err := New(comp.ByteCode()).Run()
+			if err == nil {
+				t.Fatalf("expected VM error but had none.")
+			}
+
+			evaluated, ok := evaluator.Eval(parse(input), object.NewEnvironment()).(*object.Error)
+			if !ok {
+				t.Fatalf("expected evaluator error, got=%T (%+[1]v)", evaluated)
+			}
+
+			if want := strings.TrimPrefix(evaluated.Message, "on line 1 - "); err.Error() != want {
+				t.Errorf("engines disagree. vm=%q, evaluator=%q", err, want)
+			}
+		})
+	}
+}
+
 func TestCallingFunctionsWithoutArguments(t *testing.T) {
 	tests := []vmTestCase{
 		{
 			name: "assigned fn 5 plus 10 implicit return",
 			input: `let fivePlusTen = fn() { 5 + 10; };
 					fivePlusTen();`,
-			expected: 15.0,
+			expected: 15,
 		},
 		{
 			name: "multiple fns in an expression",
 			input: `let one = fn() { 1; };
 					let two = fn() { 2; };
 					one() + two();`,
-			expected: 3.0,
+			expected: 3,
 		},
 		{
 			name: "nested calls",
@@ -184,19 +1150,19 @@ func TestCallingFunctionsWithoutArguments(t *testing.T) {
 					let b = fn() { a() + 1 };
 					let c = fn() { b() + 1 };
 					c();`,
-			expected: 3.0,
+			expected: 3,
 		},
 		{
 			name: "explicit return early",
 			input: `let exitEarly = fn() { return 99; 100; };
 					exitEarly();`,
-			expected: 99.0,
+			expected: 99,
 		},
 		{
 			name: "explicit return early return",
 			input: `let exitEarly = fn() { return 99; return 100; };
 					exitEarly();`,
-			expected: 99.0,
+			expected: 99,
 		},
 		{
 			name: "no return",
@@ -216,7 +1182,7 @@ func TestCallingFunctionsWithoutArguments(t *testing.T) {
 			input: `let returnsOne = fn() { 1; };
 					let returnsFunc = fn() { returnsOne; };
 					returnsFunc()();`,
-			expected: 1.0,
+			expected: 1,
 		},
 	}
 
@@ -229,27 +1195,27 @@ func TestCallingFunctionsWithBindings(t *testing.T) {
 			name: "shadow binding one",
 			input: `let one = fn() { let one = 1; one };
 					one();`,
-			expected: 1.0,
+			expected: 1,
 		},
 		{
 			name: "add one and two",
 			input: `let oneAndTwo = fn() { let one = 1; let two = 2; one + two; };
 					oneAndTwo();`,
-			expected: 3.0,
+			expected: 3,
 		},
 		{
 			name: "add oneAndTwo and threeAndFour",
 			input: `let oneAndTwo = fn() { let one = 1; let two = 2; one + two; };
 					let threeAndFour = fn() { let three = 3; let four = 4; three + four; };
 					oneAndTwo() + threeAndFour();`,
-			expected: 10.0,
+			expected: 10,
 		},
 		{
 			name: "two local foobar",
 			input: `let firstFoo = fn() { let foobar = 50; foobar; };
 					let secondFoo = fn() { let foobar = 100; foobar; };
 					firstFoo() + secondFoo();`,
-			expected: 150.0,
+			expected: 150,
 		},
 		{
 			name: "with globals",
@@ -257,7 +1223,7 @@ func TestCallingFunctionsWithBindings(t *testing.T) {
 					let minusOne = fn() { let num = 1; globalSeed - num; };
 					let minusTwo = fn() { let num = 2; globalSeed - num; };
 					minusOne() + minusTwo();`,
-			expected: 97.0,
+			expected: 97,
 		},
 		{
 			name: "nested function",
@@ -266,7 +1232,7 @@ func TestCallingFunctionsWithBindings(t *testing.T) {
 						return returnsOne;
 					}
 					returnsOneReturner()();`,
-			expected: 1.0,
+			expected: 1,
 		},
 	}
 
@@ -279,32 +1245,32 @@ func TestCallFunctionsWithArgsAndBindings(t *testing.T) {
 			name: "single arg returns itself",
 			input: `let identity = fn(a) { a; };
 					identity(4);`,
-			expected: 4.0,
+			expected: 4,
 		},
 		{
 			name: "sum two arguments",
 			input: `let sum = fn(a, b) { a + b; };
 					sum(1, 2);`,
-			expected: 3.0,
+			expected: 3,
 		},
 		{
 			name: "sum and assign to local and return",
 			input: `let sum = fn(a, b) { let c = a + b; c; };
 					sum(1, 2);`,
-			expected: 3.0,
+			expected: 3,
 		},
 		{
 			name: "sum and assign to local and return added",
 			input: `let sum = fn(a, b) { let c = a + b; c; };
 					sum(1, 2) + sum(3, 4);`,
-			expected: 10.0,
+			expected: 10,
 		},
 		{
 			name: "sum and assign to local and return called by another func",
 			input: `let sum = fn(a, b) { let c = a + b; c; };
 					let outer = fn() { sum(1, 2) + sum(3, 4); };
 					outer();`,
-			expected: 10.0,
+			expected: 10,
 		},
 	}
 
@@ -353,27 +1319,162 @@ func TestCallingFunctionWrongArgs(t *testing.T) {
 	}
 }
 
+func TestBigIntegers(t *testing.T) {
+	tests := []vmTestCase{
+		{"add overflow", "9223372036854775807 + 1", bigInt("9223372036854775808")},
+		{"sub overflow", "-9223372036854775807 - 2", bigInt("-9223372036854775809")},
+		{"mul overflow", "4294967296 * 4294967296", bigInt("18446744073709551616")},
+		{"literal", "123456789012345678901234567890", bigInt("123456789012345678901234567890")},
+		{"demoted", "9223372036854775808 - 1", 9223372036854775807},
+		{"negate min", "-(-9223372036854775807 - 1)", bigInt("9223372036854775808")},
+		{"divide", "100000000000000000000 / 10", bigInt("10000000000000000000")},
+		{"divide min", "(-9223372036854775807 - 1) / -1", bigInt("9223372036854775808")},
+		{"greater", "100000000000000000000 > 9223372036854775807", true},
+		{"equal", "100000000000000000000 == 10000000000000000000 * 10", true},
+		{"compare float", "100000000000000000000 > 1.5", true},
+		{"add float", "100000000000000000000 + 0.5", 1e20},
+		{"hash key", "{100000000000000000000: 1}[10000000000000000000 * 10]", 1},
+		{"int from string", `int("123456789012345678901234567890")`, bigInt("123456789012345678901234567890")},
+		{"int from float", "int(-2.9)", -2},
+		{"int from huge float", "int(1.0 * 100000000000000000000)", bigInt("100000000000000000000")},
+		{"float from big", "float(100000000000000000000)", 1e20},
+		{"float from string", `float("2.5")`, 2.5},
+		{"str from big", "str(100000000000000000000)", "100000000000000000000"},
+		{"int bad string", `int("abc")`, &object.Error{Message: `could not parse "abc" as integer`}},
+		{"int of array", `int([])`, &object.Error{Message: "argument to `int` not supported, got ARRAY"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestDecimals(t *testing.T) {
+	tests := []vmTestCase{
+		{"sum", "0.1d + 0.2d", decimal("0.3")},
+		{"keeps scale", "1.10d", decimal("1.10")},
+		{"times integer", "1.10d * 2", decimal("2.20")},
+		{"times decimal", "1.10d * 1.10d", decimal("1.2100")},
+		{"integer minus", "5 - 5.25d", decimal("-0.25")},
+		{"negate", "-0.05d", decimal("-0.05")},
+		{"exact division", "10d / 4", decimal("2.5")},
+		{"division keeps scale", "10.00d / 4", decimal("2.50")},
+		{"repeating division", "1d / 3", decimal("0.3333333333333333")},
+		{"rounded division", "2.00d / 3", decimal("0.6666666666666667")},
+		{"equal scales differ", "1.10d == 1.1d", true},
+		{"equal integer", "1.0d == 1", true},
+		{"exact sum", "0.1d + 0.2d == 0.3d", true},
+		{"greater", "1.5d > 1", true},
+		{"less equal", "1.5d <= 1.49d", false},
+		{"hash key", "{1.10d: 1}[1.1d]", 1},
+		{"whole hash key", "{1: 5}[1.0d]", 5},
+		{"from string", `decimal("12.345")`, decimal("12.345")},
+		{"from float", "decimal(0.1)", decimal("0.1")},
+		{"rescale half even", "rescale(2.345d, 2)", decimal("2.34")},
+		{"rescale half up", `rescale(2.345d, 2, "half_up")`, decimal("2.35")},
+		{"rescale floor", `rescale(-2.341d, 2, "floor")`, decimal("-2.35")},
+		{"rescale widen", "rescale(1.5d, 3)", decimal("1.500")},
+		{"rescale largest", "len(str(rescale(1d, 1000)))", 1002},
+		{"rescale too large", "rescale(1d, 100000000)", &object.Error{Message: "scale for `rescale` out of range: 100000000"}},
+		{"rescale max int32", "rescale(1.0d, 2147483647)", &object.Error{Message: "scale for `rescale` out of range: 2147483647"}},
+		{"multiply largest scale", "len(str(rescale(1d, 500) * rescale(1d, 500)))", 1002},
+		{"int", "int(-2.9d)", -2},
+		{"float", "float(2.5d)", 2.5},
+		{"str", "str(1.10d)", "1.10"},
+		{"bad string", `decimal("1.")`, &object.Error{Message: `could not parse "1." as decimal`}},
+		{"bad mode", `rescale(1d, 2, "sideways")`, &object.Error{Message: "unknown rounding mode: sideways"}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestDecimalContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      object.DecimalContext
+		input    string
+		expected decimal
+	}{
+		{"short scale", object.DecimalContext{DivisionScale: 2, Rounding: object.RoundHalfEven}, "2d / 3", "0.67"},
+		{"rounding mode", object.DecimalContext{DivisionScale: 2, Rounding: object.RoundDown}, "2d / 3", "0.66"},
+		{"operand scale wins", object.DecimalContext{DivisionScale: 1, Rounding: object.RoundHalfEven}, "2.000d / 3", "0.667"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := compiler.New()
+			err := comp.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			vm.SetDecimalContext(tt.ctx)
+			err = vm.Run()
+			if err != nil {
+				t.Fatalf("vm error: %s", err)
+			}
+			testExpectedObject(t, tt.expected, vm.LastPoppedStackElem())
+
+			// Other VMs keep the default context.
+			other := New(comp.ByteCode())
+			err = other.Run()
+			if err != nil {
+				t.Fatalf("vm error: %s", err)
+			}
+			testExpectedObject(t, decimal("0.6666666666666667"), other.LastPoppedStackElem())
+		})
+	}
+}
+
+func TestDecimalErrors(t *testing.T) {
+	tests := []vmTestCase{
+		{"mixed with float", "1.5d + 1.5", "unsupported types for binary operation: DECIMAL FLOAT"},
+		{"division by zero", "1d / 0", "division by zero"},
+		{"multiplied scale too large", "rescale(1d, 600) * rescale(1d, 600)", "decimal scale is larger than 1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := compiler.New()
+			err := comp.Compile(parse(tt.input))
+			if err != nil {
+				t.Fatalf("compiler error: %s", err)
+			}
+
+			vm := New(comp.ByteCode())
+			err = vm.Run()
+			if err == nil {
+				t.Fatalf("expected VM error but had none.")
+			}
+
+			if err.Error() != tt.expected {
+				t.Fatalf("wrong VM error. expected=%q, got=%q", tt.expected, err)
+			}
+		})
+	}
+}
+
 func TestBuiltinFunctions(t *testing.T) {
 	tests := []vmTestCase{
-		{"len empty string", `len("")`, 0.0},
-		{"len four", `len("four")`, 4.0},
-		{"len hello world", `len("hello world")`, 11.0},
-		{"len number", `len(1)`, &object.Error{Message: "argument to `len` not supported, got NUMBER"}},
+		{"len empty string", `len("")`, 0},
+		{"len four", `len("four")`, 4},
+		{"len hello world", `len("hello world")`, 11},
+		{"len number", `len(1)`, &object.Error{Message: "argument to `len` not supported, got INTEGER"}},
 		{"len two args", `len("one", "two")`, &object.Error{Message: "wrong number of arguments. expected=1, got=2"}},
-		{"len array", `len([1, 2, 3])`, 3.0},
-		{"len empty array", `len([])`, 0.0},
+		{"len array", `len([1, 2, 3])`, 3},
+		{"len empty array", `len([])`, 0},
 		{"puts two strings", `puts("hello", "world")`, Null},
-		{"first array", `first([1, 2, 3])`, 1.0},
+		{"first array", `first([1, 2, 3])`, 1},
 		{"first empty array", `first([])`, Null},
-		{"first number", `first(1)`, &object.Error{Message: "argument to `first` must be an ARRAY, got NUMBER"}},
-		{"last array", `last([1, 2, 3])`, 3.0},
+		{"first number", `first(1)`, &object.Error{Message: "argument to `first` must be an ARRAY, got INTEGER"}},
+		{"last array", `last([1, 2, 3])`, 3},
 		{"last empty array", `last([])`, Null},
-		{"last number", `last(1)`, &object.Error{Message: "argument to `last` must be an ARRAY, got NUMBER"}},
-		{"rest array", `rest([1, 2, 3])`, []float64{2, 3}},
+		{"last number", `last(1)`, &object.Error{Message: "argument to `last` must be an ARRAY, got INTEGER"}},
+		{"rest array", `rest([1, 2, 3])`, []int{2, 3}},
 		{"rest empty array", `rest([])`, Null},
-		{"rest number", `rest(1)`, &object.Error{Message: "argument to `rest` must be an ARRAY, got NUMBER"}},
-		{"push one", `push([], 1)`, []float64{1}},
-		{"push number", `push(1, 1)`, &object.Error{Message: "argument to `push` must be an ARRAY, got NUMBER"}},
+		{"rest number", `rest(1)`, &object.Error{Message: "argument to `rest` must be an ARRAY, got INTEGER"}},
+		{"push one", `push([], 1)`, []int{1}},
+		{"push number", `push(1, 1)`, &object.Error{Message: "argument to `push` must be an ARRAY, got INTEGER"}},
+		{"read_file without file access", `read_file("a.txt")`, &object.Error{Message: "`read_file` is unavailable: file access is not enabled"}},
 	}
 
 	runVmTests(t, tests)
@@ -388,7 +1489,7 @@ func TestClosures(t *testing.T) {
 					};
 					let closure = newClosure(99);
 					closure();`,
-			expected: 99.0,
+			expected: 99,
 		},
 		{
 			name: "adder 1",
@@ -397,7 +1498,7 @@ func TestClosures(t *testing.T) {
 					};
 					let adder = newAdder(1, 2);
 					adder(8);`,
-			expected: 11.0,
+			expected: 11,
 		},
 		{
 			name: "adder 2",
@@ -407,7 +1508,7 @@ func TestClosures(t *testing.T) {
 					};
 					let adder = newAdder(1, 2);
 					adder(8);`,
-			expected: 11.0,
+			expected: 11,
 		},
 	}
 
@@ -443,10 +1544,34 @@ func testExpectedObject(t *testing.T, expected interface{}, actual object.Object
 	t.Helper()
 
 	switch expected := expected.(type) {
+	case int:
+		err := testIntegerObject(int64(expected), actual)
+		if err != nil {
+			t.Errorf("testIntegerObject failed: %s", err)
+		}
 	case float64:
-		err := testNumberObject(expected, actual)
+		err := testFloatObject(expected, actual)
+		if err != nil {
+			t.Errorf("testFloatObject failed: %s", err)
+		}
+	case decimal:
+		err := testDecimalObject(string(expected), actual)
+		if err != nil {
+			t.Errorf("testDecimalObject failed: %s", err)
+		}
+	case *big.Int:
+		err := testBigIntObject(expected, actual)
 		if err != nil {
-			t.Errorf("testNumberObject failed: %s", err)
+			t.Errorf("testBigIntObject failed: %s", err)
+		}
+	case bytesValue:
+		b, ok := actual.(*object.Bytes)
+		if !ok {
+			t.Errorf("object is wrong type. expected=*object.Bytes, got=%T (%+[1]v)", actual)
+			return
+		}
+		if string(b.Value) != string(expected) {
+			t.Errorf("object has wrong value. expected=%q, got=%s", expected, b.Inspect())
 		}
 	case bool:
 		err := testBooleanObject(expected, actual)
@@ -458,7 +1583,7 @@ func testExpectedObject(t *testing.T, expected interface{}, actual object.Object
 		if err != nil {
 			t.Errorf("testStringObject failed: %s", err)
 		}
-	case []float64:
+	case []int:
 		array, ok := actual.(*object.Array)
 		if !ok {
 			t.Errorf("object incorrect type. expected=*object.Array, got=%T (%+[1]v)", actual)
@@ -471,31 +1596,37 @@ func testExpectedObject(t *testing.T, expected interface{}, actual object.Object
 		}
 
 		for i, el := range expected {
-			err := testNumberObject(el, array.Elements[i])
+			err := testIntegerObject(int64(el), array.Elements[i])
 			if err != nil {
-				t.Errorf("testNumberObject failed: %s", err)
+				t.Errorf("testIntegerObject failed: %s", err)
 			}
 		}
-	case map[object.HashKey]float64:
+	case map[object.HashKey]int64:
 		hash, ok := actual.(*object.Hash)
 		if !ok {
 			t.Errorf("object is wrong type. expected=*object.Hash, got=%T (%+[1]v)", actual)
 			return
 		}
 
-		if len(hash.Pairs) != len(expected) {
-			t.Errorf("hash has wrong number of values. expected=%d, got=%d", len(expected), len(hash.Pairs))
+		if hash.Len() != len(expected) {
+			t.Errorf("hash has wrong number of values. expected=%d, got=%d", len(expected), hash.Len())
 			return
 		}
+
+		pairs := make(map[object.HashKey]object.HashPair)
+		for _, pair := range hash.Ordered() {
+			key, _ := object.HashKeyOf(pair.Key)
+			pairs[key] = pair
+		}
 		for eKey, eVal := range expected {
-			pair, ok := hash.Pairs[eKey]
+			pair, ok := pairs[eKey]
 			if !ok {
 				t.Errorf("no pair for given key in Pairs")
 			}
 
-			err := testNumberObject(eVal, pair.Value)
+			err := testIntegerObject(eVal, pair.Value)
 			if err != nil {
-				t.Errorf("testNumberObject failed: %s", err)
+				t.Errorf("testIntegerObject failed: %s", err)
 			}
 		}
 	case *object.Null:
@@ -520,10 +1651,23 @@ func parse(input string) *ast.Program {
 	return p.ParseProgram()
 }
 
-func testNumberObject(expected float64, actual object.Object) error {
-	res, ok := actual.(*object.Number)
+func testIntegerObject(expected int64, actual object.Object) error {
+	res, ok := actual.(*object.Integer)
 	if !ok {
-		return fmt.Errorf("object is wrong type. expected=*object.Number got=%T (%+[1]v)", actual)
+		return fmt.Errorf("object is wrong type. expected=*object.Integer got=%T (%+[1]v)", actual)
+	}
+
+	if res.Value != expected {
+		return fmt.Errorf("object has wrong value. expected=%d, got=%d", expected, res.Value)
+	}
+
+	return nil
+}
+
+func testFloatObject(expected float64, actual object.Object) error {
+	res, ok := actual.(*object.Float)
+	if !ok {
+		return fmt.Errorf("object is wrong type. expected=*object.Float got=%T (%+[1]v)", actual)
 	}
 
 	if res.Value != expected {
@@ -533,6 +1677,43 @@ func testNumberObject(expected float64, actual object.Object) error {
 	return nil
 }
 
+func testBigIntObject(expected *big.Int, actual object.Object) error {
+	res, ok := actual.(*object.BigInt)
+	if !ok {
+		return fmt.Errorf("object is wrong type. expected=*object.BigInt got=%T (%+[1]v)", actual)
+	}
+
+	if res.Value.Cmp(expected) != 0 {
+		return fmt.Errorf("object has wrong value. expected=%s, got=%s", expected, res.Value)
+	}
+
+	return nil
+}
+
+// decimal is the expected Inspect output of a DECIMAL.
+type decimal string
+
+// bytesValue is the expected contents of a BYTES result.
+type bytesValue string
+
+func testDecimalObject(expected string, actual object.Object) error {
+	res, ok := actual.(*object.Decimal)
+	if !ok {
+		return fmt.Errorf("object is wrong type. expected=*object.Decimal got=%T (%+[1]v)", actual)
+	}
+
+	if res.Inspect() != expected {
+		return fmt.Errorf("object has wrong value. expected=%s, got=%s", expected, res.Inspect())
+	}
+
+	return nil
+}
+
+func bigInt(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
+
 func testBooleanObject(expected bool, actual object.Object) error {
 	res, ok := actual.(*object.Boolean)
 	if !ok {